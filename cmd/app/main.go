@@ -8,7 +8,11 @@ import (
 	"ienergy-template-go/pkg/database"
 	"ienergy-template-go/pkg/graceful"
 	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/oidc"
+	"ienergy-template-go/pkg/password"
 	"ienergy-template-go/pkg/swagger"
+	"ienergy-template-go/pkg/util"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -47,6 +51,10 @@ func main() {
 		fx.Provide(config.NewConfig),
 		fx.Provide(database.NewDatabase),
 		fx.Provide(logger.NewLogger),
+		fx.Provide(util.NewJWTKeySet),
+		fx.Provide(notifier.NewNotifier),
+		fx.Provide(oidc.NewRegistry),
+		fx.Provide(password.NewPolicy),
 		app.Module,
 		fx.Invoke(
 			registerSwaggerHandler,
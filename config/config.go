@@ -1,9 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/spf13/viper"
@@ -12,9 +14,15 @@ import (
 
 // Config is the top-level configuration struct
 type Config struct {
-	DB     DBConfig
-	JWT    JWTConfig
-	Server ServerCfg
+	DB       DBConfig
+	JWT      JWTConfig
+	Server   ServerCfg
+	Auth     AuthConfig
+	Notifier NotifierConfig
+	OIDC     OIDCConfig
+	Password PasswordPolicyConfig
+	Privacy  PrivacyConfig
+	Admin    AdminConfig
 }
 
 // DBConfig holds the database-related configuration values
@@ -32,10 +40,108 @@ type DBConfig struct {
 
 // JWTConfig holds the JWT-related configuration values
 type JWTConfig struct {
-	Secret                string `envconfig:"JWT_SECRET"`                  // JWT secret key
+	Secret                string `envconfig:"JWT_SECRET"`                  // JWT secret key, at least 32 bytes unless JWT_PRIVATE_KEY_FILE is set
 	ExpirationTime        string `envconfig:"JWT_EXPIRATION_TIME"`         // JWT expiration time
-	RefreshSecret         string `envconfig:"JWT_REFRESH_SECRET"`          // JWT refresh token secret key
+	RefreshSecret         string `envconfig:"JWT_REFRESH_SECRET"`          // JWT refresh token secret key, at least 32 bytes
 	RefreshExpirationTime string `envconfig:"JWT_REFRESH_EXPIRATION_TIME"` // JWT refresh token expiration time
+
+	Issuer   string        `envconfig:"JWT_ISSUER" default:"ienergy-template-go"` // iss claim set on and required from access tokens
+	Audience string        `envconfig:"JWT_AUDIENCE" default:"ienergy-api"`       // aud claim set on and required from access tokens
+	Leeway   time.Duration `envconfig:"JWT_LEEWAY" default:"30s"`                 // Clock skew tolerated when checking exp, nbf and iat
+
+	PrivateKeyFile       string   `envconfig:"JWT_PRIVATE_KEY_FILE"`       // PEM key (RSA, EC P-256 or Ed25519) signing access tokens; HS256 with JWT_SECRET when empty
+	VerificationKeyFiles []string `envconfig:"JWT_VERIFICATION_KEY_FILES"` // Comma-separated PEM public keys still accepted while rotating keys
+
+	RevocationStore         string        `envconfig:"JWT_REVOCATION_STORE" default:"postgres"`    // Revocation list backend (memory or postgres)
+	RevocationPruneInterval time.Duration `envconfig:"JWT_REVOCATION_PRUNE_INTERVAL" default:"1h"` // How often expired revocations are pruned
+}
+
+// MinSecretLength is the shortest HMAC secret accepted (ACTION_TOKEN_SECRET, JWT_SECRET, JWT_REFRESH_SECRET),
+// the output size of the HS256 hash
+const MinSecretLength = 32
+
+// AuthConfig holds the account-flow related configuration values
+type AuthConfig struct {
+	PasswordResetURL      string        `envconfig:"PASSWORD_RESET_URL" default:"http://localhost:3000/reset-password"` // Front-end page receiving ?token=
+	PasswordResetTokenTTL time.Duration `envconfig:"PASSWORD_RESET_TOKEN_TTL" default:"30m"`                            // Lifetime of a password reset token
+
+	ActionTokenSecret string `envconfig:"ACTION_TOKEN_SECRET"` // HMAC secret signing tokens mailed to users (email verification, ...), at least 32 bytes
+
+	RequireEmailVerification        bool          `envconfig:"REQUIRE_EMAIL_VERIFICATION" default:"false"`                          // Refuse login until the email address is verified
+	EmailVerificationURL            string        `envconfig:"EMAIL_VERIFICATION_URL" default:"http://localhost:3000/verify-email"` // Front-end page receiving ?token=
+	EmailVerificationTokenTTL       time.Duration `envconfig:"EMAIL_VERIFICATION_TOKEN_TTL" default:"24h"`                          // Lifetime of an email verification token
+	EmailVerificationResendInterval time.Duration `envconfig:"EMAIL_VERIFICATION_RESEND_INTERVAL" default:"1m"`                     // Minimum time between two verification emails
+	EmailChangeURL                  string        `envconfig:"EMAIL_CHANGE_URL" default:"http://localhost:3000/confirm-email"`      // Front-end page receiving ?token= to confirm a new email address
+
+	MagicLinkEnabled  bool          `envconfig:"MAGIC_LINK_ENABLED" default:"false"`                        // Allow passwordless login with a link mailed to the user
+	MagicLinkURL      string        `envconfig:"MAGIC_LINK_URL" default:"http://localhost:3000/magic-link"` // Front-end page receiving ?token=
+	MagicLinkTokenTTL time.Duration `envconfig:"MAGIC_LINK_TOKEN_TTL" default:"15m"`                        // Lifetime of a magic link
+
+	InvitationURL      string        `envconfig:"INVITATION_URL" default:"http://localhost:3000/accept-invitation"` // Front-end page receiving ?token= to join an organization
+	InvitationTokenTTL time.Duration `envconfig:"INVITATION_TOKEN_TTL" default:"72h"`                               // Lifetime of an invitation to join an organization
+
+	OAuthClientTokenTTL time.Duration `envconfig:"OAUTH_CLIENT_TOKEN_TTL" default:"1h"` // Lifetime of tokens from the client credentials grant
+
+	ImpersonationTokenTTL time.Duration `envconfig:"IMPERSONATION_TOKEN_TTL" default:"15m"` // Lifetime of a token an admin obtains to act as another user
+
+	MFAIssuer       string        `envconfig:"MFA_ISSUER" default:"iEnergy"`   // Issuer shown by authenticator apps
+	MFAChallengeTTL time.Duration `envconfig:"MFA_CHALLENGE_TTL" default:"5m"` // Time allowed between password and MFA code at login
+
+	LoginAttemptStore         string        `envconfig:"LOGIN_ATTEMPT_STORE" default:"postgres"`    // Failed login counter backend (memory or postgres)
+	LoginLockoutThreshold     int           `envconfig:"LOGIN_LOCKOUT_THRESHOLD" default:"5"`       // Failures per account before it is locked, 0 disables
+	LoginIPLockoutThreshold   int           `envconfig:"LOGIN_IP_LOCKOUT_THRESHOLD" default:"20"`   // Failures per client IP before it is locked, 0 disables
+	LoginLockoutDuration      time.Duration `envconfig:"LOGIN_LOCKOUT_DURATION" default:"1m"`       // First lockout, doubled for every further failure
+	LoginLockoutMaxDuration   time.Duration `envconfig:"LOGIN_LOCKOUT_MAX_DURATION" default:"1h"`   // Upper bound of a single lockout
+	LoginAttemptWindow        time.Duration `envconfig:"LOGIN_ATTEMPT_WINDOW" default:"1h"`         // Quiet period after which failures are forgotten
+	LoginAttemptPruneInterval time.Duration `envconfig:"LOGIN_ATTEMPT_PRUNE_INTERVAL" default:"1h"` // How often stale counters are pruned
+}
+
+// PasswordPolicyConfig holds the rules new passwords must follow.
+// Composition rules are off by default, in line with NIST SP 800-63B; length and the breached list matter more.
+type PasswordPolicyConfig struct {
+	MinLength            int    `envconfig:"PASSWORD_MIN_LENGTH" default:"8"`                // Minimum number of characters, requests under 8 are always refused
+	MaxLength            int    `envconfig:"PASSWORD_MAX_LENGTH" default:"72"`               // Maximum number of bytes, bcrypt cannot hash more than 72
+	RequireUppercase     bool   `envconfig:"PASSWORD_REQUIRE_UPPERCASE" default:"false"`     // Require an upper case letter
+	RequireLowercase     bool   `envconfig:"PASSWORD_REQUIRE_LOWERCASE" default:"false"`     // Require a lower case letter
+	RequireDigit         bool   `envconfig:"PASSWORD_REQUIRE_DIGIT" default:"false"`         // Require a digit
+	RequireSymbol        bool   `envconfig:"PASSWORD_REQUIRE_SYMBOL" default:"false"`        // Require a character that is neither a letter nor a digit
+	DisallowPersonalInfo bool   `envconfig:"PASSWORD_DISALLOW_PERSONAL_INFO" default:"true"` // Refuse passwords containing the user's email name or names
+	CheckBreached        bool   `envconfig:"PASSWORD_CHECK_BREACHED" default:"true"`         // Refuse passwords found in the breached password list
+	BreachedListFile     string `envconfig:"PASSWORD_BREACHED_LIST_FILE"`                    // Extra list, one password or SHA-1 hash (HIBP format) per line
+}
+
+// PrivacyConfig holds the configuration for answering data subject requests
+type PrivacyConfig struct {
+	DataExportTTL           time.Duration `envconfig:"DATA_EXPORT_TTL" default:"168h"`          // How long a generated data export can be downloaded
+	DataExportPruneInterval time.Duration `envconfig:"DATA_EXPORT_PRUNE_INTERVAL" default:"1h"` // How often expired exports are deleted
+}
+
+// AdminConfig holds the configuration of the admin user management
+type AdminConfig struct {
+	UserImportMaxRows int `envconfig:"USER_IMPORT_MAX_ROWS" default:"5000"` // Most users a single CSV import may create
+}
+
+// NotifierConfig holds the configuration for delivering messages to users
+type NotifierConfig struct {
+	Driver  string `envconfig:"NOTIFIER_DRIVER" default:"log"`        // Delivery backend (log or file)
+	FileDir string `envconfig:"NOTIFIER_FILE_DIR" default:"tmp/mail"` // Output directory for the file driver
+}
+
+// OIDCConfig holds the external identity providers users can sign in with.
+// Each name listed in OIDC_PROVIDERS is configured by OIDC_<NAME>_* variables, e.g. OIDC_GOOGLE_CLIENT_ID.
+type OIDCConfig struct {
+	ProviderNames []string                      `envconfig:"OIDC_PROVIDERS"`               // Comma-separated provider names (e.g. google,microsoft)
+	StateTTL      time.Duration                 `envconfig:"OIDC_STATE_TTL" default:"10m"` // Time allowed to complete a login at the provider
+	Providers     map[string]OIDCProviderConfig `ignored:"true"`
+}
+
+// OIDCProviderConfig holds the client registration at a single OpenID Connect provider
+type OIDCProviderConfig struct {
+	Issuer       string   `envconfig:"ISSUER"`                                // Issuer URL, the discovery document is read from <issuer>/.well-known/openid-configuration
+	ClientID     string   `envconfig:"CLIENT_ID"`                             // OAuth client ID
+	ClientSecret string   `envconfig:"CLIENT_SECRET"`                         // OAuth client secret
+	RedirectURL  string   `envconfig:"REDIRECT_URL"`                          // Front-end callback registered at the provider
+	Scopes       []string `envconfig:"SCOPES" default:"openid,email,profile"` // Requested scopes
 }
 
 // ServerCfg holds the server-related configuration values
@@ -45,6 +151,9 @@ type ServerCfg struct {
 	Env        string `envconfig:"ENVIRONMENT" default:"development"` // Environment (e.g., development, production)
 	GINMode    string `envconfig:"GIN_MODE" default:"debug"`          // Gin framework mode
 	Production bool   `envconfig:"PRODUCTION" default:"false"`        // Is production environment
+	// Comma-separated IPs or CIDRs of the reverse proxies whose X-Forwarded-For is believed for the client IP.
+	// None by default, so the client IP is the address of the connection.
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
 }
 
 func NewConfig() (*Config, error) {
@@ -58,9 +167,50 @@ func NewConfig() (*Config, error) {
 	if err := envconfig.Process("", &cfg.JWT); err != nil {
 		log.Fatalf("Failed to process JWT config: %v", err)
 	}
+	// Without a private key file access tokens are signed with JWT_SECRET
+	if cfg.JWT.PrivateKeyFile == "" && len(cfg.JWT.Secret) < MinSecretLength {
+		return nil, fmt.Errorf("JWT_SECRET must be set to at least %d bytes", MinSecretLength)
+	}
+	if len(cfg.JWT.RefreshSecret) < MinSecretLength {
+		return nil, fmt.Errorf("JWT_REFRESH_SECRET must be set to at least %d bytes", MinSecretLength)
+	}
 	if err := envconfig.Process("", &cfg.Server); err != nil {
 		log.Fatalf("Failed to process Server config: %v", err)
 	}
+	if err := envconfig.Process("", &cfg.Auth); err != nil {
+		log.Fatalf("Failed to process Auth config: %v", err)
+	}
+	// Every link mailed to users and every MFA challenge is signed with this secret
+	if len(cfg.Auth.ActionTokenSecret) < MinSecretLength {
+		return nil, fmt.Errorf("ACTION_TOKEN_SECRET must be set to at least %d bytes", MinSecretLength)
+	}
+	if err := envconfig.Process("", &cfg.Notifier); err != nil {
+		log.Fatalf("Failed to process Notifier config: %v", err)
+	}
+	if err := envconfig.Process("", &cfg.Password); err != nil {
+		log.Fatalf("Failed to process Password config: %v", err)
+	}
+	if err := envconfig.Process("", &cfg.Privacy); err != nil {
+		log.Fatalf("Failed to process Privacy config: %v", err)
+	}
+	if err := envconfig.Process("", &cfg.Admin); err != nil {
+		log.Fatalf("Failed to process Admin config: %v", err)
+	}
+	if err := envconfig.Process("", &cfg.OIDC); err != nil {
+		log.Fatalf("Failed to process OIDC config: %v", err)
+	}
+	cfg.OIDC.Providers = make(map[string]OIDCProviderConfig, len(cfg.OIDC.ProviderNames))
+	for _, name := range cfg.OIDC.ProviderNames {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		var provider OIDCProviderConfig
+		if err := envconfig.Process("OIDC_"+strings.ToUpper(name), &provider); err != nil {
+			log.Fatalf("Failed to process OIDC provider %s config: %v", name, err)
+		}
+		cfg.OIDC.Providers[name] = provider
+	}
 
 	return &cfg, nil
 }
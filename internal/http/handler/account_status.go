@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AccountStatusHandler struct {
+	accountStatusService service.AccountStatusService
+}
+
+func NewAccountStatusHandler(accountStatusService service.AccountStatusService) AccountStatusHandler {
+	return AccountStatusHandler{
+		accountStatusService: accountStatusService,
+	}
+}
+
+type changeStateFunc func(ctx context.Context, userID uuid.UUID, req request.ChangeUserStateRequest) (response.AdminUserResponse, error)
+
+// AccountStatus godoc
+// @Summary API for suspending a user
+// @Description Blocks an active account and ends all its sessions until an admin reactivates it.
+// @Description The reason is written to the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Param model body request.ChangeUserStateRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.AdminUserResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id}/suspend [post]
+func (h *AccountStatusHandler) Suspend() gin.HandlerFunc {
+	return h.changeState(h.accountStatusService.SuspendUser)
+}
+
+// AccountStatus godoc
+// @Summary API for reactivating a user
+// @Description Lets a suspended or deactivated account log in again.
+// @Description The reason is written to the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Param model body request.ChangeUserStateRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.AdminUserResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id}/reactivate [post]
+func (h *AccountStatusHandler) Reactivate() gin.HandlerFunc {
+	return h.changeState(h.accountStatusService.ReactivateUser)
+}
+
+// AccountStatus godoc
+// @Summary API for deactivating a user
+// @Description Closes an account and ends all its sessions. Only an admin can reactivate it.
+// @Description The reason is written to the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Param model body request.ChangeUserStateRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.AdminUserResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id}/deactivate [post]
+func (h *AccountStatusHandler) Deactivate() gin.HandlerFunc {
+	return h.changeState(h.accountStatusService.DeactivateUser)
+}
+
+func (h *AccountStatusHandler) changeState(change changeStateFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		var req request.ChangeUserStateRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err = req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := change(c, userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
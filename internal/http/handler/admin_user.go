@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"fmt"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AdminUserHandler struct {
+	adminUserService service.AdminUserService
+}
+
+func NewAdminUserHandler(adminUserService service.AdminUserService) AdminUserHandler {
+	return AdminUserHandler{
+		adminUserService: adminUserService,
+	}
+}
+
+// AdminUser godoc
+// @Summary API for listing users
+// @Description Returns one page of users, newest first unless sorted otherwise.
+// @Description name matches the full name or email; from_date and to_date (unix seconds) bound the registration date.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page_index query int false "zero-based page index"
+// @Param page_size query int false "page size, 20 by default and at most 100"
+// @Param name query string false "full name or email contains"
+// @Param from_date query int false "registered at or after, unix seconds"
+// @Param to_date query int false "registered at or before, unix seconds"
+// @Param sort query string false "created_at, updated_at, email, first_name or last_name; prefix with - to sort descending"
+// @Param deleted query bool false "list deleted users instead"
+// @Success 200 {object} wrapper.Response{data=response.PaginatedResponse{items=[]response.AdminUserResponse}}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users [get]
+func (h *AdminUserHandler) ListUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.UserFilterRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.Error(errors.NewBadRequestError("Invalid query parameters"))
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.adminUserService.ListUsers(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// AdminUser godoc
+// @Summary API for exporting users
+// @Description Downloads every user matching the filters, oldest first unless sorted otherwise, as CSV or as a JSON array.
+// @Description The filters are those of the user list; the file is streamed from the database as it is read.
+// @Tags admin
+// @Produce text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param format query string false "csv (default) or json"
+// @Param name query string false "full name or email contains"
+// @Param from_date query int false "registered at or after, unix seconds"
+// @Param to_date query int false "registered at or before, unix seconds"
+// @Param sort query string false "created_at, updated_at, email, first_name or last_name; prefix with - to sort descending"
+// @Param deleted query bool false "export deleted users instead"
+// @Success 200 {file} file
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/export [get]
+func (h *AdminUserHandler) ExportUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.UserExportRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.Error(errors.NewBadRequestError("Invalid query parameters"))
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		contentType := "text/csv; charset=utf-8"
+		if req.Format == constant.UserExportFormatJSON {
+			contentType = "application/json; charset=utf-8"
+		}
+		fileName := fmt.Sprintf("users-%s.%s", time.Now().Format("20060102"), req.Format)
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		c.Header("Cache-Control", "no-store")
+		err = h.adminUserService.ExportUsers(c, req, c.Writer)
+		// Once the file has started, the error can't be reported in the response anymore
+		if err != nil && !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.Error(err)
+		}
+	}
+}
+
+// AdminUser godoc
+// @Summary API for getting a user
+// @Description Returns a user with their roles.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Success 200 {object} wrapper.Response{data=response.AdminUserResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id} [get]
+func (h *AdminUserHandler) GetUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		resp, err := h.adminUserService.GetUser(c, userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// AdminUser godoc
+// @Summary API for updating a user
+// @Description Changes the given fields of a user. A new email address has to be verified again.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Param model body request.AdminUpdateUserRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.AdminUserResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id} [patch]
+func (h *AdminUserHandler) UpdateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		var req request.AdminUpdateUserRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err = req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.adminUserService.UpdateUser(c, userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// AdminUser godoc
+// @Summary API for deleting a user
+// @Description Soft-deletes a user and ends all their sessions. The user can be restored later.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id} [delete]
+func (h *AdminUserHandler) DeleteUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		err = h.adminUserService.DeleteUser(c, userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// AdminUser godoc
+// @Summary API for restoring a deleted user
+// @Description Brings back a soft-deleted user, who can then log in again.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Success 200 {object} wrapper.Response{data=response.AdminUserResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id}/restore [post]
+func (h *AdminUserHandler) RestoreUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		resp, err := h.adminUserService.RestoreUser(c, userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
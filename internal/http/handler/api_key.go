@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) APIKeyHandler {
+	return APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// APIKey godoc
+// @Summary API for creating a personal API key
+// @Description Creates an API key limited to the given scopes. The full key is returned only once;
+// @Description send it as "Authorization: ApiKey <key>" or in the X-API-Key header.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.CreateAPIKeyRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.CreatedAPIKeyResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/api-keys [post]
+func (h *APIKeyHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.CreateAPIKeyRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.apiKeyService.CreateAPIKey(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// APIKey godoc
+// @Summary API for listing personal API keys
+// @Description Lists the caller's API keys that have not been revoked. Secrets are never returned.
+// @Tags user
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.APIKeyResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/api-keys [get]
+func (h *APIKeyHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.apiKeyService.ListAPIKeys(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// APIKey godoc
+// @Summary API for revoking a personal API key
+// @Description Revokes one of the caller's API keys. It stops working immediately.
+// @Tags user
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid API key ID"))
+			return
+		}
+		err = h.apiKeyService.RevokeAPIKey(c, keyID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
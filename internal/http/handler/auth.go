@@ -55,11 +55,12 @@ func (h *AuthHandler) Register() gin.HandlerFunc {
 // User godoc
 // @Summary API for get token from user name email and password
 // @Description API for get token from user name email and password
+// @Description When the account has MFA enabled, mfa_required and an mfa_token to send to /auth/mfa/verify are returned instead of tokens.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param model body request.UserLoginRequest true "model"
-// @Success 200 {object} wrapper.Response{data=string} "success"
+// @Success 200 {object} wrapper.Response{data=response.TokenResponse} "success"
 // @Failure 400 {object} wrapper.Response
 // @Failure 500 {object} wrapper.Response
 // @Router /auth/login [post]
@@ -75,17 +76,80 @@ func (h *AuthHandler) Login() gin.HandlerFunc {
 			c.Error(err)
 			return
 		}
+		req.ClientIP = c.ClientIP()
 		resp, err := h.authService.Login(c, req)
 
 		if err != nil {
 			c.Error(err)
 			return
 		}
+		// With MFA enabled there is no token yet, only the challenge for /auth/mfa/verify
+		wrapper.JSONOk(c, resp)
+	}
+}
 
-		if len(resp.Token) == 0 {
+// User godoc
+// @Summary API for exchanging a refresh token for a new token pair
+// @Description Rotates the refresh token: the presented token is revoked and a new access/refresh pair is returned.
+// @Description Presenting a refresh token that was already used revokes every token issued from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.RefreshTokenRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.TokenResponse} "success"
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.RefreshTokenRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.authService.Refresh(c, req)
+		if err != nil {
 			c.Error(err)
 			return
 		}
 		wrapper.JSONOk(c, resp)
 	}
 }
+
+// User godoc
+// @Summary API for logging out
+// @Description Revokes the access token used for this request; pass refresh_token to also revoke its refresh token family.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Param model body request.LogoutRequest false "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.LogoutRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.BindJSON(&req); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+		err := h.authService.Logout(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
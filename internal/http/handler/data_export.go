@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"fmt"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DataExportHandler struct {
+	dataExportService service.DataExportService
+}
+
+func NewDataExportHandler(dataExportService service.DataExportService) DataExportHandler {
+	return DataExportHandler{
+		dataExportService: dataExportService,
+	}
+}
+
+// DataExport godoc
+// @Summary API for requesting a copy of your data
+// @Description Prepares an export of everything stored about the caller: profile, sessions, API keys, linked identities, organization memberships, invitations and audit entries.
+// @Description The export is generated in the background; the caller is emailed when it can be downloaded.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.DataExportRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.DataExportResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/data-exports [post]
+func (h *DataExportHandler) Request() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.DataExportRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.dataExportService.RequestExport(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// DataExport godoc
+// @Summary API for listing your data exports
+// @Description Lists the caller's exports that are being prepared or can still be downloaded, newest first.
+// @Tags user
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.DataExportResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/data-exports [get]
+func (h *DataExportHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.dataExportService.ListExports(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// DataExport godoc
+// @Summary API for getting the status of a data export
+// @Tags user
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Export ID"
+// @Success 200 {object} wrapper.Response{data=response.DataExportResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/data-exports/{id} [get]
+func (h *DataExportHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exportID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid export ID"))
+			return
+		}
+		resp, err := h.dataExportService.GetExport(c, exportID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// DataExport godoc
+// @Summary API for downloading a data export
+// @Description Returns the JSON document or ZIP archive of a finished export until it expires.
+// @Tags user
+// @Produce json
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param id path string true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/data-exports/{id}/download [get]
+func (h *DataExportHandler) Download() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exportID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid export ID"))
+			return
+		}
+		file, err := h.dataExportService.DownloadExport(c, exportID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, file.ContentType, file.Data)
+	}
+}
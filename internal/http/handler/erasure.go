@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ErasureHandler struct {
+	erasureService service.ErasureService
+}
+
+func NewErasureHandler(erasureService service.ErasureService) ErasureHandler {
+	return ErasureHandler{
+		erasureService: erasureService,
+	}
+}
+
+// Erasure godoc
+// @Summary API for erasing your account
+// @Description Permanently anonymises the caller's personal data and logs them out everywhere. This can't be undone. The only owner of an organization must make another member an owner first.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.EraseAccountRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/account/erase [post]
+func (h *ErasureHandler) EraseAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.EraseAccountRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.erasureService.EraseAccount(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// Erasure godoc
+// @Summary API for erasing a user
+// @Description Permanently anonymises the personal data of a user, who may already be deleted. The reason is written to the audit log. The only owner of an organization can't be erased.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Param model body request.EraseUserRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id}/erase [post]
+func (h *ErasureHandler) EraseUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		var req request.EraseUserRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err = req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.erasureService.EraseUser(c, userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
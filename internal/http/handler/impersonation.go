@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ImpersonationHandler struct {
+	impersonationService service.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService service.ImpersonationService) ImpersonationHandler {
+	return ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// Impersonation godoc
+// @Summary API for impersonating a user
+// @Description Returns a short-lived access token to use the app as the given user, for support.
+// @Description The token carries the admin in its act claim, can't be refreshed and can't change the password,
+// @Description API keys, sessions or MFA. Every impersonation is recorded in the audit log with its reason.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "user ID"
+// @Param model body request.ImpersonateRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.ImpersonationResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		var req request.ImpersonateRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err = req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.impersonationService.Impersonate(c, userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
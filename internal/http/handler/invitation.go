@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvitationHandler struct {
+	invitationService service.InvitationService
+}
+
+func NewInvitationHandler(invitationService service.InvitationService) InvitationHandler {
+	return InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+// Invitation godoc
+// @Summary API for inviting someone to the active organization
+// @Description Mails a link to join the organization with the role. Only owners can invite owners.
+// @Description A pending invitation to the same email is replaced, so this also resends a link.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.CreateInvitationRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.InvitationResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization/invitations [post]
+func (h *InvitationHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.CreateInvitationRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.invitationService.CreateInvitation(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Invitation godoc
+// @Summary API for listing the invitations of the active organization
+// @Description Lists invitations in every status (pending, accepted, revoked or expired), newest first.
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.InvitationResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization/invitations [get]
+func (h *InvitationHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.invitationService.ListInvitations(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Invitation godoc
+// @Summary API for revoking an invitation to the active organization
+// @Description Makes a pending invitation unusable. Only owners can revoke an invitation to become owner.
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization/invitations/{id} [delete]
+func (h *InvitationHandler) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invitationID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid invitation ID"))
+			return
+		}
+		err = h.invitationService.RevokeInvitation(c, invitationID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// Invitation godoc
+// @Summary API for accepting an invitation to an organization
+// @Description Redeems the token from an invitation email, adding the invited account to the organization.
+// @Description When no account exists for the invited email one is registered with the name and password of the request.
+// @Description The token can only be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.AcceptInvitationRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.AcceptInvitationResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/invitations/accept [post]
+func (h *InvitationHandler) Accept() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.AcceptInvitationRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.invitationService.AcceptInvitation(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"ienergy-template-go/pkg/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keySet *util.JWTKeySet
+}
+
+func NewJWKSHandler(keySet *util.JWTKeySet) JWKSHandler {
+	return JWKSHandler{
+		keySet: keySet,
+	}
+}
+
+// JWKS godoc
+// @Summary Public keys for verifying access tokens
+// @Description Returns the JSON Web Key Set (RFC 7517) of every key currently accepted for access tokens.
+// @Description The document is served unwrapped so standard JWT libraries can consume it directly.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} util.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) Keys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, h.keySet.JWKS())
+	}
+}
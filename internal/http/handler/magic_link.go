@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MagicLinkHandler struct {
+	magicLinkService service.MagicLinkService
+}
+
+func NewMagicLinkHandler(magicLinkService service.MagicLinkService) MagicLinkHandler {
+	return MagicLinkHandler{
+		magicLinkService: magicLinkService,
+	}
+}
+
+// MagicLink godoc
+// @Summary API for requesting a passwordless login link
+// @Description Sends a single-use login link to the email if it belongs to an account.
+// @Description The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.MagicLinkRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/magic-link [post]
+func (h *MagicLinkHandler) Request() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.MagicLinkRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.magicLinkService.RequestMagicLink(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// MagicLink godoc
+// @Summary API for logging in with a magic link token
+// @Description Redeems the token from a magic link for a token pair. The token can only be used once.
+// @Description When the user has MFA enabled the response carries an MFA challenge instead.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.MagicLinkVerifyRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.TokenResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/magic-link/verify [post]
+func (h *MagicLinkHandler) Verify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.MagicLinkVerifyRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.magicLinkService.VerifyMagicLink(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
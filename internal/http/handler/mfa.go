@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+func NewMFAHandler(mfaService service.MFAService) MFAHandler {
+	return MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+// MFA godoc
+// @Summary API for starting TOTP enrolment
+// @Description Generates a TOTP secret and otpauth URI for the current user. MFA is enforced only after confirmation.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=response.MFAEnrollmentResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/mfa/enroll [post]
+func (h *MFAHandler) Enroll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.mfaService.Enroll(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// MFA godoc
+// @Summary API for confirming TOTP enrolment
+// @Description Enables MFA once a code from the authenticator app is accepted and returns single-use recovery codes.
+// @Description The recovery codes are shown only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.MFAConfirmRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.MFARecoveryCodesResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/mfa/confirm [post]
+func (h *MFAHandler) Confirm() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.MFAConfirmRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.mfaService.Confirm(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// MFA godoc
+// @Summary API for completing a login with an MFA code
+// @Description Exchanges the mfa_token returned by /auth/login and a TOTP or recovery code for the token pair.
+// @Description The mfa_token is spent once it has issued tokens, and too many wrong codes lock MFA for the user for a while.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.MFAVerifyRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.TokenResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 429 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/mfa/verify [post]
+func (h *MFAHandler) Verify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.MFAVerifyRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.mfaService.Verify(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
@@ -5,4 +5,21 @@ import "go.uber.org/fx"
 var Module = fx.Options(
 	fx.Provide(NewUserHandler),
 	fx.Provide(NewAuthHandler),
+	fx.Provide(NewJWKSHandler),
+	fx.Provide(NewPasswordHandler),
+	fx.Provide(NewVerificationHandler),
+	fx.Provide(NewMFAHandler),
+	fx.Provide(NewOIDCHandler),
+	fx.Provide(NewAPIKeyHandler),
+	fx.Provide(NewSessionHandler),
+	fx.Provide(NewMagicLinkHandler),
+	fx.Provide(NewOAuthHandler),
+	fx.Provide(NewImpersonationHandler),
+	fx.Provide(NewAdminUserHandler),
+	fx.Provide(NewAccountStatusHandler),
+	fx.Provide(NewDataExportHandler),
+	fx.Provide(NewUserImportHandler),
+	fx.Provide(NewErasureHandler),
+	fx.Provide(NewOrganizationHandler),
+	fx.Provide(NewInvitationHandler),
 )
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OAuthHandler struct {
+	oauthService service.OAuthService
+}
+
+func NewOAuthHandler(oauthService service.OAuthService) OAuthHandler {
+	return OAuthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// OAuth godoc
+// @Summary OAuth 2.0 token endpoint for the client credentials grant
+// @Description Issues an access token to a registered client for service-to-service calls (RFC 6749 section 4.4).
+// @Description The client authenticates with HTTP Basic or with client_id and client_secret in the form.
+// @Description Responses follow RFC 6749 and are not wrapped like other responses.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "client_credentials"
+// @Param scope formData string false "space-separated scopes, defaults to every scope the client is allowed"
+// @Param client_id formData string false "client ID when not using HTTP Basic"
+// @Param client_secret formData string false "client secret when not using HTTP Basic"
+// @Success 200 {object} response.OAuthTokenResponse
+// @Failure 400 {object} response.OAuthErrorResponse
+// @Failure 401 {object} response.OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Tokens must never be cached (RFC 6749 section 5.1)
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		var req request.ClientCredentialsRequest
+		if err := c.ShouldBind(&req); err != nil {
+			writeOAuthError(c, http.StatusBadRequest, constant.OAuthErrorInvalidRequest, "Malformed token request")
+			return
+		}
+		if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+			if req.ClientID != "" || req.ClientSecret != "" {
+				writeOAuthError(c, http.StatusBadRequest, constant.OAuthErrorInvalidRequest,
+					"Use only one client authentication method")
+				return
+			}
+			// Basic credentials are form-encoded before being base64 encoded (RFC 6749 section 2.3.1)
+			var errID, errSecret error
+			req.ClientID, errID = url.QueryUnescape(clientID)
+			req.ClientSecret, errSecret = url.QueryUnescape(clientSecret)
+			if errID != nil || errSecret != nil {
+				writeOAuthError(c, http.StatusBadRequest, constant.OAuthErrorInvalidRequest, "Malformed client credentials")
+				return
+			}
+		}
+
+		resp, err := h.oauthService.ClientCredentialsToken(c, req)
+		if err != nil {
+			if oauthErr, ok := err.(*service.OAuthError); ok {
+				if oauthErr.Code == constant.OAuthErrorInvalidClient {
+					c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+				}
+				writeOAuthError(c, oauthErr.Status, oauthErr.Code, oauthErr.Description)
+				return
+			}
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// OAuth godoc
+// @Summary OAuth 2.0 token introspection endpoint
+// @Description Tells a gateway or resource server whether an access token is active and who it belongs to (RFC 7662).
+// @Description A token is inactive once it has expired or been revoked, its session was logged out, or its client was revoked.
+// @Description The caller authenticates with a client token granted the tokens:introspect scope.
+// @Description Responses follow RFC 7662 and are not wrapped like other responses.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security ApiKeyAuth
+// @Param token formData string true "the access token to introspect"
+// @Param token_type_hint formData string false "access_token"
+// @Success 200 {object} response.IntrospectionResponse
+// @Failure 400 {object} response.OAuthErrorResponse
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) Introspect() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+
+		var req request.IntrospectionRequest
+		if err := c.ShouldBind(&req); err != nil || req.Token == "" {
+			writeOAuthError(c, http.StatusBadRequest, constant.OAuthErrorInvalidRequest, "token is required")
+			return
+		}
+
+		resp, err := h.oauthService.IntrospectToken(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func writeOAuthError(c *gin.Context, status int, code, description string) {
+	c.JSON(status, response.OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// OAuth godoc
+// @Summary API for registering an OAuth client
+// @Description Registers a backend service that can obtain tokens with the client credentials grant.
+// @Description Scopes are permission names the admin holds. The client secret is returned only once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.CreateOAuthClientRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.CreatedOAuthClientResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/oauth-clients [post]
+func (h *OAuthHandler) CreateClient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.CreateOAuthClientRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.oauthService.CreateClient(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// OAuth godoc
+// @Summary API for listing OAuth clients
+// @Description Lists the registered OAuth clients that have not been revoked. Secrets are never returned.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.OAuthClientResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/oauth-clients [get]
+func (h *OAuthHandler) ListClients() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.oauthService.ListClients(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// OAuth godoc
+// @Summary API for revoking an OAuth client
+// @Description Revokes an OAuth client. It can't obtain new tokens and the tokens it holds stop working.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "OAuth client ID"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/oauth-clients/{id} [delete]
+func (h *OAuthHandler) RevokeClient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid OAuth client ID"))
+			return
+		}
+		err = h.oauthService.RevokeClient(c, id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcService service.OIDCService
+}
+
+func NewOIDCHandler(oidcService service.OIDCService) OIDCHandler {
+	return OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// OIDC godoc
+// @Summary API for starting a login with an external identity provider
+// @Description Returns the provider URL the browser must be sent to and the state to expect back on the callback.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "provider name, e.g. google"
+// @Success 200 {object} wrapper.Response{data=response.OIDCAuthorizationResponse}
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/oidc/{provider}/authorize [get]
+func (h *OIDCHandler) Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.oidcService.AuthorizationURL(c, c.Param("provider"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// OIDC godoc
+// @Summary API for completing a login with an external identity provider
+// @Description Exchanges the code and state the provider redirected back with for our token pair.
+// @Description The external identity is linked to the account with the same email if that account verified it, or a new account is created.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "provider name, e.g. google"
+// @Param model body request.OIDCCallbackRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.TokenResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *OIDCHandler) Callback() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.OIDCCallbackRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.oidcService.Callback(c, c.Param("provider"), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
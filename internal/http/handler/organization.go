@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	organizationService service.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService service.OrganizationService) OrganizationHandler {
+	return OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+// Organization godoc
+// @Summary API for creating an organization
+// @Description Creates an organization with the caller as its owner. Switch to it to work in it.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.CreateOrganizationRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.MembershipResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/organizations [post]
+func (h *OrganizationHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.CreateOrganizationRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.organizationService.CreateOrganization(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for listing your organizations
+// @Description Lists the organizations the caller belongs to with their role, marking the one their token works in.
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.MembershipResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/organizations [get]
+func (h *OrganizationHandler) ListMemberships() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.organizationService.ListMemberships(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for switching organization
+// @Description Makes the caller's session work in another of their organizations and returns an access token for it.
+// @Description The session's refresh token keeps working and issues tokens for the new organization.
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} wrapper.Response{data=response.SwitchOrganizationResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/organizations/{id}/switch [post]
+func (h *OrganizationHandler) Switch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid organization ID"))
+			return
+		}
+		resp, err := h.organizationService.SwitchOrganization(c, organizationID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for getting the active organization
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=response.OrganizationResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization [get]
+func (h *OrganizationHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.organizationService.GetOrganization(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for renaming the active organization
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.UpdateOrganizationRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.OrganizationResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization [patch]
+func (h *OrganizationHandler) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.UpdateOrganizationRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.organizationService.UpdateOrganization(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for listing the members of the active organization
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.OrganizationMemberResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization/members [get]
+func (h *OrganizationHandler) ListMembers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.organizationService.ListMembers(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for changing the role of a member of the active organization
+// @Description Only owners can change an owner or make someone an owner, and the last owner can't be demoted.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_id path string true "User ID"
+// @Param model body request.UpdateMemberRoleRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.OrganizationMemberResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization/members/{user_id} [patch]
+func (h *OrganizationHandler) UpdateMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("user_id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		var req request.UpdateMemberRoleRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err = req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resp, err := h.organizationService.UpdateMemberRole(c, userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Organization godoc
+// @Summary API for removing a member from the active organization
+// @Description Only owners can remove an owner, and the last owner can't be removed.
+// @Tags organization
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_id path string true "User ID"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /organization/members/{user_id} [delete]
+func (h *OrganizationHandler) RemoveMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.Param("user_id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid user ID"))
+			return
+		}
+		err = h.organizationService.RemoveMember(c, userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
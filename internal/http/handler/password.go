@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordService service.PasswordService
+}
+
+func NewPasswordHandler(passwordService service.PasswordService) PasswordHandler {
+	return PasswordHandler{
+		passwordService: passwordService,
+	}
+}
+
+// Password godoc
+// @Summary API for requesting a password reset link
+// @Description Sends a single-use password reset link to the email if it belongs to an account.
+// @Description The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.ForgotPasswordRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.ForgotPasswordRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.passwordService.ForgotPassword(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// Password godoc
+// @Summary API for setting a new password with a reset token
+// @Description Redeems a password reset token and replaces the password. The token can only be used once.
+// @Description Every session and API key of the user is revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.ResetPasswordRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/password/reset [post]
+func (h *PasswordHandler) ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.ResetPasswordRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.passwordService.ResetPassword(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// Password godoc
+// @Summary API for changing the password of the logged-in user
+// @Description Requires the current password; wrong guesses count towards the login lockout. Every other session of the user is logged out.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param model body request.ChangePasswordRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 429 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/password [put]
+func (h *PasswordHandler) ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.ChangePasswordRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.passwordService.ChangePassword(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
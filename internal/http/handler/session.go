@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	sessionService service.SessionService
+}
+
+func NewSessionHandler(sessionService service.SessionService) SessionHandler {
+	return SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// Session godoc
+// @Summary API for listing active sessions
+// @Description Lists the devices the caller is logged in on. The session making the request is flagged as current.
+// @Tags user
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.SessionResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/sessions [get]
+func (h *SessionHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.sessionService.ListSessions(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// Session godoc
+// @Summary API for revoking a session
+// @Description Logs the caller out on one device. Its refresh token stops working and its access tokens are rejected.
+// @Tags user
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/sessions/{id} [delete]
+func (h *SessionHandler) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid session ID"))
+			return
+		}
+		err = h.sessionService.RevokeSession(c, sessionID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
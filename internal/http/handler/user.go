@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/service"
 	"ienergy-template-go/pkg/wrapper"
 
@@ -40,3 +41,43 @@ func (h *UserHandler) Info() gin.HandlerFunc {
 		wrapper.JSONOk(c, info)
 	}
 }
+
+// User godoc
+// @Summary API for updating the user's profile
+// @Description Changes the given profile fields. A new email address is mailed a confirmation link and
+// @Description only replaces the current one once confirmed at /auth/verify-email/change; until then it is
+// @Description returned as pending_email. Sending the current email cancels a pending change.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Param model body request.UpdateUserInfoRequest true "model"
+// @Success 200 {object} wrapper.Response{data=response.UserInfoResponse} "success"
+// @Failure 400 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 429 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /user/info [patch]
+func (h *UserHandler) UpdateInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.UpdateUserInfoRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		info, err := h.userService.UpdateUserInfo(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		wrapper.JSONOk(c, info)
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	stderrors "errors"
+	"fmt"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/wrapper"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxUserImportSize is the largest request body accepted for a user import
+const maxUserImportSize = 10 << 20
+
+type UserImportHandler struct {
+	userImportService service.UserImportService
+}
+
+func NewUserImportHandler(userImportService service.UserImportService) UserImportHandler {
+	return UserImportHandler{
+		userImportService: userImportService,
+	}
+}
+
+// UserImport godoc
+// @Summary API for importing users from a CSV
+// @Description Registers a user for each row of the CSV, validated like /auth/register. The header must name the columns
+// @Description email, first_name, last_name and password, in any order; confirm_password is optional and other columns are ignored.
+// @Description The import runs in the background, get it by ID for the reason each failed row was not imported.
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "CSV of users"
+// @Success 200 {object} wrapper.Response{data=response.UserImportResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/imports [post]
+func (h *UserImportHandler) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUserImportSize)
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if stderrors.As(err, &maxBytesErr) {
+				c.Error(errors.NewBadRequestError(fmt.Sprintf("file must be at most %d MB!", maxUserImportSize>>20)))
+				return
+			}
+			c.Error(errors.NewBadRequestError("file is required!"))
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid file"))
+			return
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid file"))
+			return
+		}
+		resp, err := h.userImportService.ImportUsers(c, fileHeader.Filename, content)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// UserImport godoc
+// @Summary API for listing user imports
+// @Description Lists every import with its counts, newest first. The row errors are only returned by ID.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} wrapper.Response{data=[]response.UserImportResponse}
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/imports [get]
+func (h *UserImportHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.userImportService.ListImports(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
+
+// UserImport godoc
+// @Summary API for getting a user import
+// @Description Returns the status of an import and, once completed, the line, email and reason of each row that was not imported.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Import ID"
+// @Success 200 {object} wrapper.Response{data=response.UserImportResponse}
+// @Failure 400 {object} wrapper.Response
+// @Failure 401 {object} wrapper.Response
+// @Failure 403 {object} wrapper.Response
+// @Failure 404 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /admin/users/imports/{id} [get]
+func (h *UserImportHandler) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		importID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid import ID"))
+			return
+		}
+		resp, err := h.userImportService.GetImport(c, importID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, resp)
+	}
+}
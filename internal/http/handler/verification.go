@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationHandler struct {
+	verificationService service.VerificationService
+}
+
+func NewVerificationHandler(verificationService service.VerificationService) VerificationHandler {
+	return VerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// Verification godoc
+// @Summary API for verifying an email address
+// @Description Redeems the token mailed on registration and marks the email address as verified.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.VerifyEmailRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/verify-email [post]
+func (h *VerificationHandler) VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.VerifyEmailRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.verificationService.VerifyEmail(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// Verification godoc
+// @Summary API for resending the email verification link
+// @Description Sends a new verification link if the email belongs to an unverified account.
+// @Description The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.ResendVerificationEmailRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 429 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/verify-email/resend [post]
+func (h *VerificationHandler) ResendVerificationEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.ResendVerificationEmailRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.verificationService.ResendVerificationEmail(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
+
+// Verification godoc
+// @Summary API for confirming a new email address
+// @Description Redeems the token mailed to the new address when the user changed their email, and replaces
+// @Description the current address with it. Only the link of the latest change request works.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param model body request.VerifyEmailRequest true "model"
+// @Success 200 {object} wrapper.Response
+// @Failure 400 {object} wrapper.Response
+// @Failure 409 {object} wrapper.Response
+// @Failure 500 {object} wrapper.Response
+// @Router /auth/verify-email/change [post]
+func (h *VerificationHandler) ConfirmEmailChange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request.VerifyEmailRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.Error(err)
+			return
+		}
+		err := req.Validate()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		err = h.verificationService.ConfirmEmailChange(c, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		wrapper.JSONOk(c, nil)
+	}
+}
@@ -0,0 +1,129 @@
+package router
+
+import (
+	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminRoutes interface {
+	Setup(r *gin.RouterGroup)
+}
+
+type adminRoutes struct {
+	oauthHandler         handler.OAuthHandler
+	impersonationHandler handler.ImpersonationHandler
+	adminUserHandler     handler.AdminUserHandler
+	accountStatusHandler handler.AccountStatusHandler
+	erasureHandler       handler.ErasureHandler
+	userImportHandler    handler.UserImportHandler
+	keySet               *util.JWTKeySet
+	revocationStore      repository.TokenRevocationStore
+	sessionService       service.SessionService
+	accountStatusService service.AccountStatusService
+}
+
+func (sr *adminRoutes) Setup(r *gin.RouterGroup) {
+	admin := r.Group("/admin")
+	admin.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	admin.Use(middleware.DenyImpersonation())
+
+	oauthClients := admin.Group("/oauth-clients")
+	oauthClients.Use(middleware.RequirePermission(constant.PermissionClientManage))
+	{
+		oauthClients.GET("", sr.oauthHandler.ListClients())
+		oauthClients.POST("", sr.oauthHandler.CreateClient())
+		oauthClients.DELETE("/:id", sr.oauthHandler.RevokeClient())
+	}
+
+	users := admin.Group("/users")
+	{
+		users.GET("",
+			middleware.RequirePermission(constant.PermissionUserRead),
+			sr.adminUserHandler.ListUsers(),
+		)
+		users.GET("/export",
+			middleware.RequirePermission(constant.PermissionUserRead),
+			sr.adminUserHandler.ExportUsers(),
+		)
+		users.POST("/imports",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.userImportHandler.Create(),
+		)
+		users.GET("/imports",
+			middleware.RequirePermission(constant.PermissionUserRead),
+			sr.userImportHandler.List(),
+		)
+		users.GET("/imports/:id",
+			middleware.RequirePermission(constant.PermissionUserRead),
+			sr.userImportHandler.Get(),
+		)
+		users.GET("/:id",
+			middleware.RequirePermission(constant.PermissionUserRead),
+			sr.adminUserHandler.GetUser(),
+		)
+		users.PATCH("/:id",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.adminUserHandler.UpdateUser(),
+		)
+		users.DELETE("/:id",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.adminUserHandler.DeleteUser(),
+		)
+		users.POST("/:id/restore",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.adminUserHandler.RestoreUser(),
+		)
+		users.POST("/:id/suspend",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.accountStatusHandler.Suspend(),
+		)
+		users.POST("/:id/reactivate",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.accountStatusHandler.Reactivate(),
+		)
+		users.POST("/:id/deactivate",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.accountStatusHandler.Deactivate(),
+		)
+		users.POST("/:id/erase",
+			middleware.RequirePermission(constant.PermissionUserWrite),
+			sr.erasureHandler.EraseUser(),
+		)
+		users.POST("/:id/impersonate",
+			middleware.RequirePermission(constant.PermissionImpersonate),
+			sr.impersonationHandler.Impersonate(),
+		)
+	}
+}
+
+func NewAdminRoutes(
+	oauthHandler handler.OAuthHandler,
+	impersonationHandler handler.ImpersonationHandler,
+	adminUserHandler handler.AdminUserHandler,
+	accountStatusHandler handler.AccountStatusHandler,
+	erasureHandler handler.ErasureHandler,
+	userImportHandler handler.UserImportHandler,
+	keySet *util.JWTKeySet,
+	revocationStore repository.TokenRevocationStore,
+	sessionService service.SessionService,
+	accountStatusService service.AccountStatusService,
+) AdminRoutes {
+	return &adminRoutes{
+		oauthHandler:         oauthHandler,
+		impersonationHandler: impersonationHandler,
+		adminUserHandler:     adminUserHandler,
+		accountStatusHandler: accountStatusHandler,
+		erasureHandler:       erasureHandler,
+		userImportHandler:    userImportHandler,
+		keySet:               keySet,
+		revocationStore:      revocationStore,
+		sessionService:       sessionService,
+		accountStatusService: accountStatusService,
+	}
+}
@@ -2,6 +2,10 @@ package router
 
 import (
 	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/util"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,7 +15,17 @@ type AuthRoutes interface {
 }
 
 type authRoutes struct {
-	authHandler handler.AuthHandler
+	authHandler          handler.AuthHandler
+	passwordHandler      handler.PasswordHandler
+	verificationHandler  handler.VerificationHandler
+	mfaHandler           handler.MFAHandler
+	oidcHandler          handler.OIDCHandler
+	magicLinkHandler     handler.MagicLinkHandler
+	invitationHandler    handler.InvitationHandler
+	keySet               *util.JWTKeySet
+	revocationStore      repository.TokenRevocationStore
+	sessionService       service.SessionService
+	accountStatusService service.AccountStatusService
 }
 
 func (sr *authRoutes) Setup(r *gin.RouterGroup) {
@@ -19,11 +33,81 @@ func (sr *authRoutes) Setup(r *gin.RouterGroup) {
 	{
 		auth.POST("/register", sr.authHandler.Register())
 		auth.POST("/login", sr.authHandler.Login())
+		auth.POST("/refresh", sr.authHandler.Refresh())
+		// Logging out doesn't check the account status, so suspended users can still end their session
+		auth.POST("/logout", middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, nil), sr.authHandler.Logout())
+	}
+
+	password := auth.Group("/password")
+	{
+		password.POST("/forgot", sr.passwordHandler.ForgotPassword())
+		password.POST("/reset", sr.passwordHandler.ResetPassword())
+	}
+
+	verifyEmail := auth.Group("/verify-email")
+	{
+		verifyEmail.POST("", sr.verificationHandler.VerifyEmail())
+		verifyEmail.POST("/resend", sr.verificationHandler.ResendVerificationEmail())
+		verifyEmail.POST("/change", sr.verificationHandler.ConfirmEmailChange())
+	}
+
+	mfa := auth.Group("/mfa")
+	{
+		mfa.POST("/enroll",
+			middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService),
+			middleware.DenyImpersonation(),
+			sr.mfaHandler.Enroll(),
+		)
+		mfa.POST("/confirm",
+			middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService),
+			middleware.DenyImpersonation(),
+			sr.mfaHandler.Confirm(),
+		)
+		mfa.POST("/verify", sr.mfaHandler.Verify())
+	}
+
+	oidc := auth.Group("/oidc/:provider")
+	{
+		oidc.GET("/authorize", sr.oidcHandler.Authorize())
+		oidc.POST("/callback", sr.oidcHandler.Callback())
+	}
+
+	magicLink := auth.Group("/magic-link")
+	{
+		magicLink.POST("", sr.magicLinkHandler.Request())
+		magicLink.POST("/verify", sr.magicLinkHandler.Verify())
+	}
+
+	invitations := auth.Group("/invitations")
+	{
+		invitations.POST("/accept", sr.invitationHandler.Accept())
 	}
 }
 
-func NewAuthRoutes(authHandler handler.AuthHandler) AuthRoutes {
+func NewAuthRoutes(
+	authHandler handler.AuthHandler,
+	passwordHandler handler.PasswordHandler,
+	verificationHandler handler.VerificationHandler,
+	mfaHandler handler.MFAHandler,
+	oidcHandler handler.OIDCHandler,
+	magicLinkHandler handler.MagicLinkHandler,
+	invitationHandler handler.InvitationHandler,
+	keySet *util.JWTKeySet,
+	revocationStore repository.TokenRevocationStore,
+	sessionService service.SessionService,
+	accountStatusService service.AccountStatusService,
+) AuthRoutes {
 	return &authRoutes{
-		authHandler: authHandler,
+		authHandler:          authHandler,
+		passwordHandler:      passwordHandler,
+		verificationHandler:  verificationHandler,
+		mfaHandler:           mfaHandler,
+		oidcHandler:          oidcHandler,
+		magicLinkHandler:     magicLinkHandler,
+		invitationHandler:    invitationHandler,
+		keySet:               keySet,
+		revocationStore:      revocationStore,
+		sessionService:       sessionService,
+		accountStatusService: accountStatusService,
 	}
 }
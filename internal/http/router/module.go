@@ -1,6 +1,7 @@
 package router
 
 import (
+	"ienergy-template-go/config"
 	"ienergy-template-go/internal/middleware"
 	"ienergy-template-go/pkg/logger"
 
@@ -10,28 +11,47 @@ import (
 
 type RouterParams struct {
 	fx.In
-	AuthRoutes   AuthRoutes
-	UserRoutes   UserRoutes
-	Logger       *logger.StandardLogger
-	ErrorHandler *middleware.ErrorHandler
+	AuthRoutes         AuthRoutes
+	UserRoutes         UserRoutes
+	OAuthRoutes        OAuthRoutes
+	AdminRoutes        AdminRoutes
+	OrganizationRoutes OrganizationRoutes
+	WellKnown          WellKnownRoutes
+	Logger             *logger.StandardLogger
+	ErrorHandler       *middleware.ErrorHandler
+	Config             *config.Config
 }
 
-func NewRouter(params RouterParams) *gin.Engine {
+func NewRouter(params RouterParams) (*gin.Engine, error) {
 	router := gin.Default()
+	// The client IP counts login failures, so X-Forwarded-For is only believed from configured proxies
+	if err := router.SetTrustedProxies(params.Config.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
 
 	router.Use(middleware.CorsMiddleware())
 	router.Use(middleware.LoggingMiddleware(params.Logger))
+	router.Use(middleware.ClientInfoMiddleware())
 	router.Use(params.ErrorHandler.Handle())
 
+	params.WellKnown.Setup(&router.RouterGroup)
+
 	api := router.Group("/api/v1")
 	params.AuthRoutes.Setup(api)
 	params.UserRoutes.Setup(api)
-	return router
+	params.OAuthRoutes.Setup(api)
+	params.AdminRoutes.Setup(api)
+	params.OrganizationRoutes.Setup(api)
+	return router, nil
 }
 
 var Module = fx.Options(
 	fx.Provide(NewAuthRoutes),
 	fx.Provide(NewUserRoutes),
+	fx.Provide(NewWellKnownRoutes),
+	fx.Provide(NewOAuthRoutes),
+	fx.Provide(NewAdminRoutes),
+	fx.Provide(NewOrganizationRoutes),
 	fx.Provide(middleware.NewErrorHandler),
 	fx.Provide(NewRouter),
 )
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthRoutes interface {
+	Setup(r *gin.RouterGroup)
+}
+
+type oauthRoutes struct {
+	oauthHandler    handler.OAuthHandler
+	keySet          *util.JWTKeySet
+	revocationStore repository.TokenRevocationStore
+	oauthService    service.OAuthService
+}
+
+func (sr *oauthRoutes) Setup(r *gin.RouterGroup) {
+	oauth := r.Group("/oauth")
+	{
+		oauth.POST("/token", sr.oauthHandler.Token())
+		oauth.POST("/introspect",
+			middleware.ClientAuthMiddleware(sr.keySet, sr.revocationStore, sr.oauthService),
+			middleware.RequireScope(constant.PermissionTokenIntrospect),
+			sr.oauthHandler.Introspect(),
+		)
+	}
+}
+
+func NewOAuthRoutes(
+	oauthHandler handler.OAuthHandler,
+	keySet *util.JWTKeySet,
+	revocationStore repository.TokenRevocationStore,
+	oauthService service.OAuthService,
+) OAuthRoutes {
+	return &oauthRoutes{
+		oauthHandler:    oauthHandler,
+		keySet:          keySet,
+		revocationStore: revocationStore,
+		oauthService:    oauthService,
+	}
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationRoutes interface {
+	Setup(r *gin.RouterGroup)
+}
+
+type organizationRoutes struct {
+	organizationHandler  handler.OrganizationHandler
+	invitationHandler    handler.InvitationHandler
+	keySet               *util.JWTKeySet
+	revocationStore      repository.TokenRevocationStore
+	sessionService       service.SessionService
+	accountStatusService service.AccountStatusService
+	organizationService  service.OrganizationService
+}
+
+// Setup registers the routes working on the organization in the caller's token.
+// API keys carry no organization, so these routes take a JWT only.
+func (sr *organizationRoutes) Setup(r *gin.RouterGroup) {
+	organization := r.Group("/organization")
+	organization.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	{
+		organization.GET("",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionOrganizationRead),
+			sr.organizationHandler.Get(),
+		)
+		organization.PATCH("",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionOrganizationWrite),
+			sr.organizationHandler.Update(),
+		)
+	}
+
+	members := organization.Group("/members")
+	{
+		members.GET("",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionMembersRead),
+			sr.organizationHandler.ListMembers(),
+		)
+		members.PATCH("/:user_id",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionMembersWrite),
+			sr.organizationHandler.UpdateMember(),
+		)
+		members.DELETE("/:user_id",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionMembersWrite),
+			sr.organizationHandler.RemoveMember(),
+		)
+	}
+
+	invitations := organization.Group("/invitations")
+	{
+		invitations.GET("",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionMembersRead),
+			sr.invitationHandler.List(),
+		)
+		invitations.POST("",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionMembersWrite),
+			sr.invitationHandler.Create(),
+		)
+		invitations.DELETE("/:id",
+			middleware.RequireOrganization(sr.organizationService, constant.PermissionMembersWrite),
+			sr.invitationHandler.Revoke(),
+		)
+	}
+}
+
+func NewOrganizationRoutes(
+	organizationHandler handler.OrganizationHandler,
+	invitationHandler handler.InvitationHandler,
+	keySet *util.JWTKeySet,
+	revocationStore repository.TokenRevocationStore,
+	sessionService service.SessionService,
+	accountStatusService service.AccountStatusService,
+	organizationService service.OrganizationService,
+) OrganizationRoutes {
+	return &organizationRoutes{
+		organizationHandler:  organizationHandler,
+		invitationHandler:    invitationHandler,
+		keySet:               keySet,
+		revocationStore:      revocationStore,
+		sessionService:       sessionService,
+		accountStatusService: accountStatusService,
+		organizationService:  organizationService,
+	}
+}
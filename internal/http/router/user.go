@@ -1,9 +1,12 @@
 package router
 
 import (
-	"ienergy-template-go/config"
 	"ienergy-template-go/internal/http/handler"
 	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/util"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,21 +16,112 @@ type UserRoutes interface {
 }
 
 type userRoutes struct {
-	userHandler handler.UserHandler
-	config      *config.Config
+	userHandler          handler.UserHandler
+	apiKeyHandler        handler.APIKeyHandler
+	sessionHandler       handler.SessionHandler
+	passwordHandler      handler.PasswordHandler
+	dataExportHandler    handler.DataExportHandler
+	erasureHandler       handler.ErasureHandler
+	organizationHandler  handler.OrganizationHandler
+	keySet               *util.JWTKeySet
+	revocationStore      repository.TokenRevocationStore
+	apiKeyService        service.APIKeyService
+	sessionService       service.SessionService
+	accountStatusService service.AccountStatusService
 }
 
 func (sr *userRoutes) Setup(r *gin.RouterGroup) {
 	userInfo := r.Group("/user/info")
-	userInfo.Use(middleware.JwtAuthMiddleware(sr.config))
+	userInfo.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, sr.apiKeyService, sr.accountStatusService))
 	{
-		userInfo.GET("", sr.userHandler.Info())
+		userInfo.GET("", middleware.RequirePermission(constant.PermissionProfileRead), sr.userHandler.Info())
+		userInfo.PATCH("", middleware.RequirePermission(constant.PermissionProfileWrite), sr.userHandler.UpdateInfo())
+	}
+
+	// API keys, the password, sessions and the user's data are managed with a user's JWT only, so a leaked key
+	// cannot be used to take over the account, and never by an admin impersonating the user
+	apiKeys := r.Group("/user/api-keys")
+	apiKeys.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	apiKeys.Use(middleware.DenyImpersonation())
+	{
+		apiKeys.GET("", middleware.RequirePermission(constant.PermissionProfileRead), sr.apiKeyHandler.List())
+		apiKeys.POST("", middleware.RequirePermission(constant.PermissionProfileWrite), sr.apiKeyHandler.Create())
+		apiKeys.DELETE("/:id", middleware.RequirePermission(constant.PermissionProfileWrite), sr.apiKeyHandler.Revoke())
+	}
+
+	password := r.Group("/user/password")
+	password.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	password.Use(middleware.DenyImpersonation())
+	{
+		password.PUT("", middleware.RequirePermission(constant.PermissionProfileWrite), sr.passwordHandler.ChangePassword())
+	}
+
+	sessions := r.Group("/user/sessions")
+	sessions.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	sessions.Use(middleware.DenyImpersonation())
+	{
+		sessions.GET("", middleware.RequirePermission(constant.PermissionProfileRead), sr.sessionHandler.List())
+		sessions.DELETE("/:id", middleware.RequirePermission(constant.PermissionProfileWrite), sr.sessionHandler.Revoke())
+	}
+
+	dataExports := r.Group("/user/data-exports")
+	dataExports.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	dataExports.Use(middleware.DenyImpersonation())
+	dataExports.Use(middleware.RequirePermission(constant.PermissionProfileRead))
+	{
+		dataExports.POST("", sr.dataExportHandler.Request())
+		dataExports.GET("", sr.dataExportHandler.List())
+		dataExports.GET("/:id", sr.dataExportHandler.Get())
+		dataExports.GET("/:id/download", sr.dataExportHandler.Download())
+	}
+
+	account := r.Group("/user/account")
+	account.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	account.Use(middleware.DenyImpersonation())
+	{
+		account.POST("/erase", middleware.RequirePermission(constant.PermissionProfileWrite), sr.erasureHandler.EraseAccount())
+	}
+
+	// Switching changes the organization of the login session, which an impersonating admin shares with their own login
+	organizations := r.Group("/user/organizations")
+	organizations.Use(middleware.JwtAuthMiddleware(sr.keySet, sr.revocationStore, sr.sessionService, nil, sr.accountStatusService))
+	{
+		organizations.GET("", middleware.RequirePermission(constant.PermissionProfileRead), sr.organizationHandler.ListMemberships())
+		organizations.POST("", middleware.RequirePermission(constant.PermissionProfileWrite), sr.organizationHandler.Create())
+		organizations.POST("/:id/switch",
+			middleware.DenyImpersonation(),
+			middleware.RequirePermission(constant.PermissionProfileRead),
+			sr.organizationHandler.Switch(),
+		)
 	}
 }
 
-func NewUserRoutes(userHandler handler.UserHandler, config *config.Config) UserRoutes {
+func NewUserRoutes(
+	userHandler handler.UserHandler,
+	apiKeyHandler handler.APIKeyHandler,
+	sessionHandler handler.SessionHandler,
+	passwordHandler handler.PasswordHandler,
+	dataExportHandler handler.DataExportHandler,
+	erasureHandler handler.ErasureHandler,
+	organizationHandler handler.OrganizationHandler,
+	keySet *util.JWTKeySet,
+	revocationStore repository.TokenRevocationStore,
+	apiKeyService service.APIKeyService,
+	sessionService service.SessionService,
+	accountStatusService service.AccountStatusService,
+) UserRoutes {
 	return &userRoutes{
-		userHandler: userHandler,
-		config:      config,
+		userHandler:          userHandler,
+		apiKeyHandler:        apiKeyHandler,
+		sessionHandler:       sessionHandler,
+		passwordHandler:      passwordHandler,
+		dataExportHandler:    dataExportHandler,
+		erasureHandler:       erasureHandler,
+		organizationHandler:  organizationHandler,
+		keySet:               keySet,
+		revocationStore:      revocationStore,
+		apiKeyService:        apiKeyService,
+		sessionService:       sessionService,
+		accountStatusService: accountStatusService,
 	}
 }
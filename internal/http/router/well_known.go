@@ -0,0 +1,28 @@
+package router
+
+import (
+	"ienergy-template-go/internal/http/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WellKnownRoutes interface {
+	Setup(r *gin.RouterGroup)
+}
+
+type wellKnownRoutes struct {
+	jwksHandler handler.JWKSHandler
+}
+
+func (sr *wellKnownRoutes) Setup(r *gin.RouterGroup) {
+	wellKnown := r.Group("/.well-known")
+	{
+		wellKnown.GET("/jwks.json", sr.jwksHandler.Keys())
+	}
+}
+
+func NewWellKnownRoutes(jwksHandler handler.JWKSHandler) WellKnownRoutes {
+	return &wellKnownRoutes{
+		jwksHandler: jwksHandler,
+	}
+}
@@ -0,0 +1,106 @@
+package handler_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAccountChecker struct {
+	mock.Mock
+}
+
+func (m *MockAccountChecker) IsAccountActive(ctx context.Context, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// TestJwtAuthMiddleware_AccountStatus tests that valid tokens of suspended or deactivated
+// accounts are refused
+func TestJwtAuthMiddleware_AccountStatus(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT:    config.JWTConfig{Secret: "test-jwt-secret-of-at-least-32-bytes"},
+	}
+	keySet, err := util.NewJWTKeySet(cfg)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	now := time.Now()
+	token, err := keySet.SignAccessToken(util.AccessClaims{
+		Email: "user@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		active       bool
+		checkErr     error
+		expectedCode int
+	}{
+		{
+			name:         "active account",
+			active:       true,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "inactive account",
+			active:       false,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "status can't be checked",
+			checkErr:     errors.NewInternalServerError("Database error: connection refused"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			accounts := new(MockAccountChecker)
+			accounts.On("IsAccountActive", mock.Anything, userID).Return(tc.active, tc.checkErr)
+
+			router := gin.New()
+			router.Use(middleware.JwtAuthMiddleware(keySet, nil, nil, nil, accounts))
+			router.GET("/info", func(c *gin.Context) {
+				wrapper.JSONOk(c, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/info", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			accounts.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package handler_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAdminUserService struct {
+	mock.Mock
+}
+
+func (m *MockAdminUserService) ListUsers(
+	ctx context.Context,
+	req request.UserFilterRequest,
+) (response.PaginatedResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.PaginatedResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) GetUser(ctx context.Context, userID uuid.UUID) (response.AdminUserResponse, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(response.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) UpdateUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	req request.AdminUpdateUserRequest,
+) (response.AdminUserResponse, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(response.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUserService) RestoreUser(ctx context.Context, userID uuid.UUID) (response.AdminUserResponse, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(response.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) ExportUsers(ctx context.Context, req request.UserExportRequest, w io.Writer) error {
+	args := m.Called(ctx, req, w)
+	return args.Error(0)
+}
+
+// TestAdminUserHandler_ListUsers tests that the list filters are read from the query string and validated
+func TestAdminUserHandler_ListUsers(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedFilter request.UserFilterRequest
+	}{
+		{
+			name:         "all filters",
+			query:        "?page_index=2&page_size=50&name=john&from_date=1700000000&to_date=1800000000&sort=-email&deleted=true",
+			expectedCode: http.StatusOK,
+			expectedFilter: request.UserFilterRequest{
+				BaseFilterRequest: request.BaseFilterRequest{
+					PageIndex: 2,
+					PageSize:  50,
+					Names:     "john",
+					FromDate:  1700000000,
+					ToDate:    1800000000,
+					Sort:      "-email",
+				},
+				Deleted: true,
+			},
+		},
+		{
+			name:         "no filters",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown sort field",
+			query:        "?sort=password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "page too large",
+			query:        "?page_size=1000",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "inverted date range",
+			query:        "?from_date=1800000000&to_date=1700000000",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "malformed page index",
+			query:        "?page_index=first",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := new(MockAdminUserService)
+			mockService.On("ListUsers", mock.Anything, tc.expectedFilter).
+				Return(response.PaginatedResponse{Items: []response.AdminUserResponse{}}, nil)
+			adminUserHandler := handler.NewAdminUserHandler(mockService)
+
+			cfg := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+			router := gin.New()
+			router.Use(middleware.NewErrorHandler(logger.NewLogger(cfg)).Handle())
+			router.GET("/admin/users", adminUserHandler.ListUsers())
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/users"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusOK {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// TestAdminUserHandler_ExportUsers tests that the export is sent as a download, and that an error
+// raised before anything was written is returned as a regular JSON error
+func TestAdminUserHandler_ExportUsers(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name                string
+		query               string
+		serviceError        error
+		expectedCode        int
+		expectedContentType string
+		expectedFormat      string
+	}{
+		{
+			name:                "csv by default",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedFormat:      constant.UserExportFormatCSV,
+		},
+		{
+			name:                "json",
+			query:               "?format=json&name=example",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedFormat:      constant.UserExportFormatJSON,
+		},
+		{
+			name:           "service error",
+			serviceError:   errors.NewInternalServerError("Database error: connection refused"),
+			expectedCode:   http.StatusInternalServerError,
+			expectedFormat: constant.UserExportFormatCSV,
+		},
+		{
+			name:         "unknown format",
+			query:        "?format=xml",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := new(MockAdminUserService)
+			mockService.On("ExportUsers", mock.Anything, mock.MatchedBy(func(req request.UserExportRequest) bool {
+				return req.Format == tc.expectedFormat
+			}), mock.Anything).
+				Run(func(args mock.Arguments) {
+					if tc.serviceError == nil {
+						_, _ = io.WriteString(args.Get(2).(io.Writer), "id\n")
+					}
+				}).
+				Return(tc.serviceError)
+			adminUserHandler := handler.NewAdminUserHandler(mockService)
+
+			cfg := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+			router := gin.New()
+			router.Use(middleware.NewErrorHandler(logger.NewLogger(cfg)).Handle())
+			router.GET("/admin/users/export", adminUserHandler.ExportUsers())
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/users/export"+tc.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				assert.Empty(t, w.Header().Get("Content-Disposition"))
+				assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+				return
+			}
+			assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"users-")
+			assert.Equal(t, "id\n", w.Body.String())
+		})
+	}
+}
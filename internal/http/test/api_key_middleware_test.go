@@ -0,0 +1,104 @@
+package handler_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAPIKeyAuthenticator struct {
+	key       string
+	principal util.APIKeyPrincipal
+}
+
+func (s stubAPIKeyAuthenticator) AuthenticateAPIKey(ctx context.Context, key string) (util.APIKeyPrincipal, error) {
+	if key != s.key {
+		return util.APIKeyPrincipal{}, errors.NewUnauthorizedError("Invalid API key")
+	}
+	return s.principal, nil
+}
+
+// TestJwtAuthMiddleware_APIKey tests that API keys populate the same context values as a JWT
+func TestJwtAuthMiddleware_APIKey(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	principal := util.APIKeyPrincipal{
+		KeyID:       uuid.New(),
+		UserID:      uuid.New(),
+		Email:       "test@example.com",
+		Permissions: []string{"profile:read"},
+	}
+	authenticator := stubAPIKeyAuthenticator{key: "iek_valid", principal: principal}
+
+	testCases := []struct {
+		name          string
+		header        string
+		value         string
+		authenticator util.APIKeyAuthenticator
+		expectedCode  int
+	}{
+		{
+			name:          "authorization header",
+			header:        "Authorization",
+			value:         "ApiKey iek_valid",
+			authenticator: authenticator,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "x-api-key header",
+			header:        util.APIKeyHeader,
+			value:         "iek_valid",
+			authenticator: authenticator,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "unknown key",
+			header:        util.APIKeyHeader,
+			value:         "iek_invalid",
+			authenticator: authenticator,
+			expectedCode:  http.StatusUnauthorized,
+		},
+		{
+			name:         "route that does not accept API keys",
+			header:       util.APIKeyHeader,
+			value:        "iek_valid",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.GET("/",
+				middleware.JwtAuthMiddleware(nil, nil, nil, tc.authenticator, nil),
+				middleware.RequirePermission("profile:read"),
+				func(c *gin.Context) {
+					assert.Equal(t, principal.UserID, util.UserIDFromCTX(c))
+					assert.Equal(t, principal.Email, util.UserEmailFromCTX(c))
+					assert.Equal(t, principal.KeyID, util.APIKeyIDFromCTX(c))
+					wrapper.JSONOk(c, nil)
+				},
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(tc.header, tc.value)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
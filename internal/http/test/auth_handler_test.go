@@ -40,6 +40,19 @@ func (m *MockAuthService) Login(ctx context.Context, req request.UserLoginReques
 	return args.Get(0).(response.TokenResponse), args.Error(1)
 }
 
+func (m *MockAuthService) Refresh(ctx context.Context, req request.RefreshTokenRequest) (response.TokenResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return response.TokenResponse{}, args.Error(1)
+	}
+	return args.Get(0).(response.TokenResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, req request.LogoutRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
 // TestAuthHandler_Register tests the Register handler functionality
 func TestAuthHandler_Register(t *testing.T) {
 	t.Parallel()
@@ -287,3 +300,35 @@ func TestAuthHandler_Login(t *testing.T) {
 		})
 	}
 }
+
+// TestAuthHandler_LoginMFARequired tests that a login needing a second factor returns the MFA challenge
+// instead of failing for lack of a token
+func TestAuthHandler_LoginMFARequired(t *testing.T) {
+	t.Parallel()
+
+	mockAuthService := new(MockAuthService)
+	mockAuthService.On("Login", mock.Anything, mock.Anything).Return(
+		response.TokenResponse{MFARequired: true, MFAToken: "mfa_token_123"}, nil,
+	)
+	authHandler := handler.NewAuthHandler(mockAuthService)
+
+	reqBody, err := json.Marshal(request.UserLoginRequest{Email: "test@example.com", Password: "password123"})
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	authHandler.Login()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp wrapper.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	var tokenResp response.TokenResponse
+	dataBytes, _ := json.Marshal(resp.Data)
+	assert.NoError(t, json.Unmarshal(dataBytes, &tokenResp))
+	assert.True(t, tokenResp.MFARequired)
+	assert.Equal(t, "mfa_token_123", tokenResp.MFAToken)
+	assert.Empty(t, tokenResp.Token)
+	mockAuthService.AssertExpectations(t)
+}
@@ -0,0 +1,106 @@
+package handler_test
+
+import (
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDenyImpersonation tests that impersonation tokens act as the user on ordinary routes
+// but are refused on routes reserved for the account owner
+func TestDenyImpersonation(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT:    config.JWTConfig{Secret: "test-jwt-secret-of-at-least-32-bytes"},
+	}
+	keySet, err := util.NewJWTKeySet(cfg)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	actorID := uuid.New()
+	sign := func(actor *util.ActorClaim) string {
+		now := time.Now()
+		token, err := keySet.SignAccessToken(util.AccessClaims{
+			Email: "user@example.com",
+			Actor: actor,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   userID.String(),
+				ID:        uuid.NewString(),
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		})
+		require.NoError(t, err)
+		return token
+	}
+	impersonationToken := sign(&util.ActorClaim{Subject: actorID.String(), Email: "admin@example.com"})
+
+	testCases := []struct {
+		name          string
+		token         string
+		path          string
+		expectedCode  int
+		expectedActor uuid.UUID
+	}{
+		{
+			name:          "impersonation token on an ordinary route",
+			token:         impersonationToken,
+			path:          "/info",
+			expectedCode:  http.StatusOK,
+			expectedActor: actorID,
+		},
+		{
+			name:         "impersonation token on an owner-only route",
+			token:        impersonationToken,
+			path:         "/password",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "user token on an owner-only route",
+			token:        sign(nil),
+			path:         "/password",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.Use(middleware.JwtAuthMiddleware(keySet, nil, nil, nil, nil))
+			router.GET("/info", func(c *gin.Context) {
+				assert.Equal(t, userID, util.UserIDFromCTX(c))
+				assert.Equal(t, tc.expectedActor, util.ActorIDFromCTX(c))
+				wrapper.JSONOk(c, nil)
+			})
+			router.GET("/password", middleware.DenyImpersonation(), func(c *gin.Context) {
+				wrapper.JSONOk(c, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
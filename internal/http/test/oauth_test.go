@@ -0,0 +1,389 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOAuthClientRepo keeps OAuth clients in memory for the token endpoint tests
+type memoryOAuthClientRepo struct {
+	mu      sync.Mutex
+	clients map[string]entity.OAuthClient
+}
+
+func newMemoryOAuthClientRepo() *memoryOAuthClientRepo {
+	return &memoryOAuthClientRepo{clients: make(map[string]entity.OAuthClient)}
+}
+
+func (m *memoryOAuthClientRepo) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients[client.ClientID] = client
+	return nil
+}
+
+func (m *memoryOAuthClientRepo) GetOAuthClients(ctx context.Context) ([]entity.OAuthClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := make([]entity.OAuthClient, 0, len(m.clients))
+	for _, client := range m.clients {
+		if client.IsActive() {
+			resp = append(resp, client)
+		}
+	}
+	return resp, nil
+}
+
+func (m *memoryOAuthClientRepo) GetOAuthClientByClientID(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	client, ok := m.clients[clientID]
+	if !ok {
+		return entity.OAuthClient{}, errors.NewNotFoundError("OAuth client not found")
+	}
+	return client, nil
+}
+
+func (m *memoryOAuthClientRepo) RevokeOAuthClient(ctx context.Context, id uuid.UUID, revokedBy string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for clientID, client := range m.clients {
+		if client.ID == id && client.IsActive() {
+			now := time.Now()
+			client.RevokedAt = &now
+			m.clients[clientID] = client
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newOAuthTestEnvironment(t *testing.T) (*gin.Engine, *util.JWTKeySet, service.OAuthService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT:    config.JWTConfig{Secret: "test-jwt-secret-of-at-least-32-bytes"},
+		Auth:   config.AuthConfig{OAuthClientTokenTTL: time.Hour},
+	}
+	keySet, err := util.NewJWTKeySet(cfg)
+	require.NoError(t, err)
+	oauthService := service.NewOAuthService(
+		newMemoryOAuthClientRepo(), repository.NewMemoryTokenRevocationStore(), nil, nil, keySet, logger.NewLogger(cfg), cfg,
+	)
+	oauthHandler := handler.NewOAuthHandler(oauthService)
+
+	router := gin.New()
+	router.POST("/oauth/token", oauthHandler.Token())
+	router.GET("/service",
+		middleware.ClientAuthMiddleware(keySet, nil, oauthService),
+		middleware.RequireScope(constant.PermissionUserRead),
+		func(c *gin.Context) {
+			assert.NotEmpty(t, util.ClientIDFromCTX(c))
+			assert.Equal(t, uuid.Nil, util.UserIDFromCTX(c))
+			wrapper.JSONOk(c, nil)
+		},
+	)
+	router.POST("/oauth/introspect",
+		middleware.ClientAuthMiddleware(keySet, nil, oauthService),
+		middleware.RequireScope(constant.PermissionTokenIntrospect),
+		oauthHandler.Introspect(),
+	)
+	router.GET("/user", middleware.JwtAuthMiddleware(keySet, nil, nil, nil, nil), func(c *gin.Context) {
+		wrapper.JSONOk(c, nil)
+	})
+	return router, keySet, oauthService
+}
+
+func registerOAuthClient(t *testing.T, oauthService service.OAuthService, scopes ...string) response.CreatedOAuthClientResponse {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(util.UserIDCTX, uuid.NewString())
+	c.Set(util.PermissionsCTX, constant.DefaultRolePermissions[constant.RoleAdmin])
+	client, err := oauthService.CreateClient(c, request.CreateOAuthClientRequest{Name: "billing", Scopes: scopes})
+	require.NoError(t, err)
+	return client
+}
+
+// TestOAuthHandler_Token tests the client credentials grant and its RFC 6749 responses
+func TestOAuthHandler_Token(t *testing.T) {
+	t.Parallel()
+
+	router, _, oauthService := newOAuthTestEnvironment(t)
+	client := registerOAuthClient(t, oauthService, constant.PermissionUserRead, constant.PermissionUserWrite)
+
+	postToken := func(form url.Values, basicID, basicSecret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basicID != "" {
+			req.SetBasicAuth(url.QueryEscape(basicID), url.QueryEscape(basicSecret))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	testCases := []struct {
+		name          string
+		form          url.Values
+		basic         bool
+		expectedCode  int
+		expectedError string
+		expectedScope string
+	}{
+		{
+			name:          "basic authentication",
+			form:          url.Values{"grant_type": {"client_credentials"}},
+			basic:         true,
+			expectedCode:  http.StatusOK,
+			expectedScope: "users:read users:write",
+		},
+		{
+			name: "credentials in the form with a narrower scope",
+			form: url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {client.ClientID},
+				"client_secret": {client.ClientSecret},
+				"scope":         {"users:read"},
+			},
+			expectedCode:  http.StatusOK,
+			expectedScope: "users:read",
+		},
+		{
+			name:          "scope not allowed",
+			form:          url.Values{"grant_type": {"client_credentials"}, "scope": {"clients:manage"}},
+			basic:         true,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: constant.OAuthErrorInvalidScope,
+		},
+		{
+			name:          "wrong secret",
+			form:          url.Values{"grant_type": {"client_credentials"}, "client_id": {client.ClientID}, "client_secret": {"iecs_wrong"}},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: constant.OAuthErrorInvalidClient,
+		},
+		{
+			name:          "unsupported grant",
+			form:          url.Values{"grant_type": {"password"}},
+			basic:         true,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: constant.OAuthErrorUnsupportedGrantType,
+		},
+		{
+			name:          "two authentication methods",
+			form:          url.Values{"grant_type": {"client_credentials"}, "client_id": {client.ClientID}},
+			basic:         true,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: constant.OAuthErrorInvalidRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			basicID, basicSecret := "", ""
+			if tc.basic {
+				basicID, basicSecret = client.ClientID, client.ClientSecret
+			}
+			w := postToken(tc.form, basicID, basicSecret)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			if tc.expectedError != "" {
+				var resp response.OAuthErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tc.expectedError, resp.Error)
+				return
+			}
+			var resp response.OAuthTokenResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.NotEmpty(t, resp.AccessToken)
+			assert.Equal(t, "Bearer", resp.TokenType)
+			assert.Equal(t, int64(3600), resp.ExpiresIn)
+			assert.Equal(t, tc.expectedScope, resp.Scope)
+		})
+	}
+}
+
+// TestClientAuthMiddleware tests that client tokens reach service routes only, with the scopes they were granted
+func TestClientAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	router, keySet, oauthService := newOAuthTestEnvironment(t)
+	reader := registerOAuthClient(t, oauthService, constant.PermissionUserRead)
+	writer := registerOAuthClient(t, oauthService, constant.PermissionUserWrite)
+	revoked := registerOAuthClient(t, oauthService, constant.PermissionUserRead)
+
+	issue := func(client response.CreatedOAuthClientResponse) string {
+		resp, err := oauthService.ClientCredentialsToken(context.Background(), request.ClientCredentialsRequest{
+			GrantType:    constant.GrantTypeClientCredentials,
+			ClientID:     client.ClientID,
+			ClientSecret: client.ClientSecret,
+		})
+		require.NoError(t, err)
+		return resp.AccessToken
+	}
+	readerToken := issue(reader)
+	writerToken := issue(writer)
+	revokedToken := issue(revoked)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(util.UserIDCTX, uuid.NewString())
+	require.NoError(t, oauthService.RevokeClient(c, revoked.ID))
+
+	now := time.Now()
+	userToken, err := keySet.SignAccessToken(util.AccessClaims{
+		Email:       "test@example.com",
+		Permissions: []string{constant.PermissionUserRead},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		path         string
+		token        string
+		expectedCode int
+	}{
+		{name: "client with scope", path: "/service", token: readerToken, expectedCode: http.StatusOK},
+		{name: "client without scope", path: "/service", token: writerToken, expectedCode: http.StatusForbidden},
+		{name: "revoked client", path: "/service", token: revokedToken, expectedCode: http.StatusUnauthorized},
+		{name: "user token on service route", path: "/service", token: userToken, expectedCode: http.StatusUnauthorized},
+		{name: "client token on user route", path: "/user", token: readerToken, expectedCode: http.StatusUnauthorized},
+		{name: "user token on user route", path: "/user", token: userToken, expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
+
+// TestOAuthHandler_Introspect tests that only clients with the introspection scope can introspect tokens
+func TestOAuthHandler_Introspect(t *testing.T) {
+	t.Parallel()
+
+	router, _, oauthService := newOAuthTestEnvironment(t)
+	gateway := registerOAuthClient(t, oauthService, constant.PermissionTokenIntrospect)
+	reader := registerOAuthClient(t, oauthService, constant.PermissionUserRead)
+	issue := func(client response.CreatedOAuthClientResponse) string {
+		resp, err := oauthService.ClientCredentialsToken(context.Background(), request.ClientCredentialsRequest{
+			GrantType:    constant.GrantTypeClientCredentials,
+			ClientID:     client.ClientID,
+			ClientSecret: client.ClientSecret,
+		})
+		require.NoError(t, err)
+		return resp.AccessToken
+	}
+	gatewayToken := issue(gateway)
+	readerToken := issue(reader)
+
+	testCases := []struct {
+		name           string
+		callerToken    string
+		form           url.Values
+		expectedCode   int
+		expectedActive bool
+	}{
+		{
+			name:           "active token",
+			callerToken:    gatewayToken,
+			form:           url.Values{"token": {readerToken}},
+			expectedCode:   http.StatusOK,
+			expectedActive: true,
+		},
+		{
+			name:         "unknown token",
+			callerToken:  gatewayToken,
+			form:         url.Values{"token": {"garbage"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "missing token",
+			callerToken:  gatewayToken,
+			form:         url.Values{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "caller without the introspection scope",
+			callerToken:  readerToken,
+			form:         url.Values{"token": {gatewayToken}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "unauthenticated caller",
+			form:         url.Values{"token": {readerToken}},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.callerToken != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.callerToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var resp response.IntrospectionResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedActive, resp.Active)
+			if tc.expectedActive {
+				assert.Equal(t, reader.ClientID, resp.ClientID)
+				assert.Equal(t, constant.PermissionUserRead, resp.Scope)
+			}
+		})
+	}
+}
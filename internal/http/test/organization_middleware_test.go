@@ -0,0 +1,108 @@
+package handler_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMembershipChecker struct {
+	mock.Mock
+}
+
+func (m *MockMembershipChecker) OrganizationRole(ctx context.Context, organizationID, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, organizationID, userID)
+	return args.String(0), args.Error(1)
+}
+
+// TestRequireOrganization tests that only members whose role grants the permission act in the organization
+// of their token, and that it then becomes the tenant of the request
+func TestRequireOrganization(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	organizationID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		organizationID uuid.UUID
+		role           string
+		checkErr       error
+		expectedCode   int
+	}{
+		{
+			name:           "member with the permission",
+			organizationID: organizationID,
+			role:           constant.OrganizationRoleAdmin,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "member without the permission",
+			organizationID: organizationID,
+			role:           constant.OrganizationRoleMember,
+			expectedCode:   http.StatusForbidden,
+		},
+		{
+			name:           "not a member",
+			organizationID: organizationID,
+			checkErr:       errors.NewNotFoundError("Not a member of the organization"),
+			expectedCode:   http.StatusForbidden,
+		},
+		{
+			name:         "no active organization",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:           "membership can't be checked",
+			organizationID: organizationID,
+			checkErr:       errors.NewInternalServerError("Database error: connection refused"),
+			expectedCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			members := new(MockMembershipChecker)
+			members.On("OrganizationRole", mock.Anything, tc.organizationID, userID).Return(tc.role, tc.checkErr).Maybe()
+
+			var tenantID uuid.UUID
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(util.UserIDCTX, userID.String())
+				if tc.organizationID != uuid.Nil {
+					c.Set(util.OrganizationIDCTX, tc.organizationID.String())
+				}
+			})
+			router.Use(middleware.RequireOrganization(members, constant.PermissionMembersWrite))
+			router.POST("/organization/members", func(c *gin.Context) {
+				tenantID = util.TenantIDFromCTX(c)
+				wrapper.JSONOk(c, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/organization/members", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, organizationID, tenantID)
+			}
+			members.AssertExpectations(t)
+		})
+	}
+}
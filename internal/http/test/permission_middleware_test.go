@@ -0,0 +1,73 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"ienergy-template-go/internal/middleware"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequirePermission tests that routes are only reachable with every required permission
+func TestRequirePermission(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name         string
+		granted      []string
+		required     []string
+		expectedCode int
+	}{
+		{
+			name:         "all permissions granted",
+			granted:      []string{"profile:read", "users:read"},
+			required:     []string{"users:read"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "missing one permission",
+			granted:      []string{"profile:read"},
+			required:     []string{"profile:read", "users:write"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "no permissions in context",
+			required:     []string{"profile:read"},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.GET("/",
+				func(c *gin.Context) {
+					if tc.granted != nil {
+						c.Set(util.PermissionsCTX, tc.granted)
+					}
+				},
+				middleware.RequirePermission(tc.required...),
+				func(c *gin.Context) {
+					wrapper.JSONOk(c, nil)
+				},
+			)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			var resp wrapper.Response
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedCode, resp.StatusCode)
+		})
+	}
+}
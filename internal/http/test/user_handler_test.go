@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/model/response"
 	"ienergy-template-go/pkg/wrapper"
 	"net/http"
@@ -28,6 +29,14 @@ func (m *MockUserService) GetUserInfo(ctx context.Context) (response.UserInfoRes
 	return args.Get(0).(response.UserInfoResponse), args.Error(1)
 }
 
+func (m *MockUserService) UpdateUserInfo(
+	ctx context.Context,
+	req request.UpdateUserInfoRequest,
+) (response.UserInfoResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.UserInfoResponse), args.Error(1)
+}
+
 func TestUserHandler_Info(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientAuthMiddleware authenticates service-to-service calls with a bearer token from the
+// client credentials grant. User tokens are rejected, and so are tokens of a client that has since been revoked.
+func ClientAuthMiddleware(
+	keys *util.JWTKeySet,
+	revocation util.RevocationChecker,
+	clients util.ClientChecker,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := util.TokenValid(c, keys, revocation, nil)
+		if err == nil && util.ClientIDFromCTX(c) == "" {
+			err = errors.NewUnauthorizedError("A client token is required")
+		}
+		if err == nil {
+			active, checkErr := clients.IsClientActive(c, util.ClientIDFromCTX(c))
+			if checkErr == nil && !active {
+				checkErr = errors.NewUnauthorizedError("Client has been revoked")
+			}
+			err = checkErr
+		}
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, wrapper.NewErrorResponse(
+				errors.NewUnauthorizedError("Unauthorized"),
+			))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope only lets the request through when the client token grants every listed scope.
+// It reads the scopes ClientAuthMiddleware put in the context, so it must run after it.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := make(map[string]bool)
+		for _, scope := range util.ScopesFromCTX(c) {
+			granted[scope] = true
+		}
+
+		for _, scope := range scopes {
+			if !granted[scope] {
+				c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+					errors.NewForbiddenError("Missing scope: "+scope),
+				))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
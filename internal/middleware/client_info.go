@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"ienergy-template-go/pkg/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfoMiddleware puts the caller's IP address and user agent in the context so services
+// can record where a request came from without depending on gin
+func ClientInfoMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(util.ClientIPCTX, c.ClientIP())
+		c.Set(util.UserAgentCTX, c.Request.UserAgent())
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DenyImpersonation rejects requests made with an impersonation token, for actions only the account owner may take,
+// such as changing the password. It must run after JwtAuthMiddleware.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if util.ActorIDFromCTX(c) != uuid.Nil {
+			c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+				errors.NewForbiddenError("Not allowed while impersonating a user"),
+			))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -3,7 +3,6 @@ package middleware
 import (
 	"net/http"
 
-	"ienergy-template-go/config"
 	"ienergy-template-go/pkg/errors"
 	"ienergy-template-go/pkg/util"
 	"ienergy-template-go/pkg/wrapper"
@@ -11,9 +10,41 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func JwtAuthMiddleware(config *config.Config) gin.HandlerFunc {
+// JwtAuthMiddleware authenticates the request with a bearer JWT or, when apiKeys is not nil,
+// with an API key sent as "Authorization: ApiKey <key>" or in the X-API-Key header.
+// Both fill the same user, role and permission context values. JWTs bound to a revoked session are rejected,
+// and so are client credentials tokens, which have no user; service routes use ClientAuthMiddleware.
+// When accounts is not nil, users whose account is not active are refused with 403.
+func JwtAuthMiddleware(
+	keys *util.JWTKeySet,
+	revocation util.RevocationChecker,
+	sessions util.SessionChecker,
+	apiKeys util.APIKeyAuthenticator,
+	accounts util.AccountChecker,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		err := util.TokenValid(c, config.JWT)
+		if apiKeys != nil {
+			if key := util.ExtractAPIKey(c); key != "" {
+				principal, err := apiKeys.AuthenticateAPIKey(c, key)
+				if err != nil {
+					c.JSON(http.StatusUnauthorized, wrapper.NewErrorResponse(
+						errors.NewUnauthorizedError("Unauthorized"),
+					))
+					c.Abort()
+					return
+				}
+				util.SetAPIKeyPrincipal(c, principal)
+				if requireActiveAccount(c, accounts) {
+					c.Next()
+				}
+				return
+			}
+		}
+
+		err := util.TokenValid(c, keys, revocation, sessions)
+		if err == nil && util.ClientIDFromCTX(c) != "" {
+			err = errors.NewUnauthorizedError("Client tokens can't call user routes")
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, wrapper.NewErrorResponse(
 				errors.NewUnauthorizedError("Unauthorized"),
@@ -21,6 +52,31 @@ func JwtAuthMiddleware(config *config.Config) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		c.Next()
+		if requireActiveAccount(c, accounts) {
+			c.Next()
+		}
+	}
+}
+
+// requireActiveAccount aborts the request unless the authenticated user's account is active
+func requireActiveAccount(c *gin.Context, accounts util.AccountChecker) bool {
+	if accounts == nil {
+		return true
+	}
+	active, err := accounts.IsAccountActive(c, util.UserIDFromCTX(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, wrapper.NewErrorResponse(
+			errors.NewInternalServerError("Can't check the account status"),
+		))
+		c.Abort()
+		return false
+	}
+	if !active {
+		c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+			errors.NewForbiddenError("Account is not active"),
+		))
+		c.Abort()
+		return false
 	}
+	return true
 }
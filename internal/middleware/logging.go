@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,9 +24,17 @@ func LoggingMiddleware(logger *logger.StandardLogger) gin.HandlerFunc {
 		c.Next()
 
 		duration := time.Since(start).Seconds()
-		entry.WithFields(logrus.Fields{
+		entry = entry.WithFields(logrus.Fields{
 			"status":   c.Writer.Status(),
 			"duration": duration,
-		}).Info("request handled")
+		})
+		// Requests made with an impersonation token are traced back to the admin behind them
+		if actorID := util.ActorIDFromCTX(c); actorID != uuid.Nil {
+			entry = entry.WithFields(logrus.Fields{
+				"user_id":         util.UserIDFromCTX(c),
+				"impersonated_by": actorID,
+			})
+		}
+		entry.Info("request handled")
 	}
 }
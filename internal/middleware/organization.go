@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequireOrganization only lets the request through when the user is still a member of the organization in their
+// token's org claim, and their role there grants every listed permission. The organization then becomes the tenant
+// that repository queries are restricted to. It must run after JwtAuthMiddleware.
+func RequireOrganization(members util.MembershipChecker, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := util.OrganizationIDFromCTX(c)
+		if organizationID == uuid.Nil {
+			c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+				errors.NewForbiddenError("No active organization"),
+			))
+			c.Abort()
+			return
+		}
+
+		role, err := members.OrganizationRole(c, organizationID, util.UserIDFromCTX(c))
+		if err != nil {
+			var appErr *errors.AppError
+			if stderrors.As(err, &appErr) && appErr.Status == http.StatusNotFound {
+				c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+					errors.NewForbiddenError("Not a member of the organization"),
+				))
+			} else {
+				c.JSON(http.StatusInternalServerError, wrapper.NewErrorResponse(
+					errors.NewInternalServerError("Can't check the organization membership"),
+				))
+			}
+			c.Abort()
+			return
+		}
+
+		granted := make(map[string]bool)
+		for _, permission := range constant.OrganizationRolePermissions[role] {
+			granted[permission] = true
+		}
+		for _, permission := range permissions {
+			if !granted[permission] {
+				c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+					errors.NewForbiddenError("Missing permission: "+permission),
+				))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set(util.TenantIDCTX, organizationID.String())
+		c.Set(util.OrgRoleCTX, role)
+		c.Next()
+	}
+}
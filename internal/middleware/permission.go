@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"ienergy-template-go/pkg/wrapper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission only lets the request through when the token grants every listed permission.
+// It reads the permissions JwtAuthMiddleware put in the context, so it must run after it.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := make(map[string]bool)
+		for _, permission := range util.PermissionsFromCTX(c) {
+			granted[permission] = true
+		}
+
+		for _, permission := range permissions {
+			if !granted[permission] {
+				c.JSON(http.StatusForbidden, wrapper.NewErrorResponse(
+					errors.NewForbiddenError("Missing permission: "+permission),
+				))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
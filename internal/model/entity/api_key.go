@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential a user creates for scripts and integrations.
+// The public prefix identifies the key; only the SHA-256 of the secret part is stored.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `gorm:"column:user_id;type:uuid;index:api_key_user_idx"`
+	Name       string     `gorm:"column:name;type:varchar(100)"`
+	Prefix     string     `gorm:"column:prefix;type:varchar(16);index:api_key_prefix_idx,unique"`
+	SecretHash string     `gorm:"column:secret_hash;type:varchar(64)"`
+	Scopes     string     `gorm:"column:scopes;type:text"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	BaseEntity
+}
+
+func (e *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// ScopeList returns the permissions granted to the key, which are stored space-separated
+func (e *APIKey) ScopeList() []string {
+	return strings.Fields(e.Scopes)
+}
+
+// IsActive reports whether the key can still be used to authenticate
+func (e *APIKey) IsActive(now time.Time) bool {
+	if e.RevokedAt != nil {
+		return false
+	}
+	return e.ExpiresAt == nil || now.Before(*e.ExpiresAt)
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records an administrative action: who performed it, on which user, why and from where
+type AuditLog struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ActorID   uuid.UUID `gorm:"column:actor_id;type:uuid;index:audit_log_actor_idx"`
+	Action    string    `gorm:"column:action;type:varchar(50);index:audit_log_action_idx"`
+	TargetID  uuid.UUID `gorm:"column:target_id;type:uuid;index:audit_log_target_idx"`
+	Reason    string    `gorm:"column:reason;type:text"`
+	IPAddress string    `gorm:"column:ip_address;type:varchar(45)"`
+	UserAgent string    `gorm:"column:user_agent;type:varchar(512)"`
+	BaseEntity
+}
+
+func (e *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Data export statuses
+const (
+	DataExportPending = "PENDING"
+	DataExportReady   = "READY"
+	DataExportFailed  = "FAILED"
+)
+
+// DataExport is a copy of everything stored about a user, prepared in the background when they ask for it.
+// The archive is kept until ExpiresAt so the user can download it, then deleted.
+type DataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID  `gorm:"column:user_id;type:uuid;index:data_export_user_idx"`
+	Format      string     `gorm:"column:format;type:varchar(10)"` // constant.DataExportFormatJSON or DataExportFormatZIP
+	Status      string     `gorm:"column:status;type:varchar(20);index:data_export_status_idx"`
+	Archive     []byte     `gorm:"column:archive;type:bytea"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at;index:data_export_expires_idx"`
+	BaseEntity
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// IsDownloadable reports whether the archive is ready and has not expired yet
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
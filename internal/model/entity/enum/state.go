@@ -7,8 +7,11 @@ const (
 )
 
 const (
-	StateActive   = "ACTIVE"
-	StateInactive = "INACTIVE"
+	StateActive      = "ACTIVE"
+	StateInactive    = "INACTIVE"
+	StatePending     = "PENDING"
+	StateSuspended   = "SUSPENDED"
+	StateDeactivated = "DEACTIVATED"
 )
 
 // Convert state from int to string
@@ -18,6 +21,12 @@ func EnumState(state int) string {
 		return StateActive
 	case 1:
 		return StateInactive
+	case 2:
+		return StatePending
+	case 3:
+		return StateSuspended
+	case 4:
+		return StateDeactivated
 	default:
 		return ""
 	}
@@ -30,6 +39,12 @@ func EnumStateDB(state string) int {
 		return 0
 	case StateInactive:
 		return 1
+	case StatePending:
+		return 2
+	case StateSuspended:
+		return 3
+	case StateDeactivated:
+		return 4
 	default:
 		return -1
 	}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// LoginAttempt counts recent failed logins for a key, either an account or a client IP.
+// Rows can be dropped once the key is no longer locked and has been quiet for the attempt window.
+type LoginAttempt struct {
+	Key          string     `gorm:"type:varchar(320);primaryKey"`
+	Failures     int        `gorm:"column:failures"`
+	LastFailedAt time.Time  `gorm:"column:last_failed_at;index:login_attempt_last_failed_idx"`
+	LockedUntil  *time.Time `gorm:"column:locked_until"`
+}
+
+// IsLocked reports whether logins for the key are refused at the given time
+func (e *LoginAttempt) IsLocked(now time.Time) bool {
+	return e.LockedUntil != nil && now.Before(*e.LockedUntil)
+}
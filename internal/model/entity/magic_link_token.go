@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLinkToken is a single-use login link mailed to a user who signs in without a password.
+// Only the SHA-256 of the token is stored, like PasswordResetToken.
+type MagicLinkToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `gorm:"column:user_id;type:uuid;index:magic_link_token_user_idx"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);index:magic_link_token_hash_idx,unique"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	BaseEntity
+}
+
+func (e *MagicLinkToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
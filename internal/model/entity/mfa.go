@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MFAFactor is the TOTP authenticator enrolled by a user.
+// It only protects logins once ConfirmedAt is set, i.e. after the user proved the app produces valid codes.
+type MFAFactor struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID       uuid.UUID  `gorm:"column:user_id;type:uuid;index:mfa_factor_user_idx,unique"`
+	Secret       string     `gorm:"column:secret;type:varchar(64)"`
+	ConfirmedAt  *time.Time `gorm:"column:confirmed_at"`
+	LastUsedStep int64      `gorm:"column:last_used_step"`
+	BaseEntity
+}
+
+func (e *MFAFactor) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// IsConfirmed reports whether the factor has been confirmed and must be used at login
+func (e *MFAFactor) IsConfirmed() bool {
+	return e.ConfirmedAt != nil
+}
+
+// MFARecoveryCode is a single-use code letting a user log in without their authenticator.
+// Only the SHA-256 of the code is stored.
+type MFARecoveryCode struct {
+	ID       uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID   uuid.UUID  `gorm:"column:user_id;type:uuid;index:mfa_recovery_code_user_idx"`
+	CodeHash string     `gorm:"column:code_hash;type:varchar(64)"`
+	UsedAt   *time.Time `gorm:"column:used_at"`
+	BaseEntity
+}
+
+func (e *MFARecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthClient is another backend service allowed to call the API with the client credentials grant.
+// Only the SHA-256 of the client secret is stored.
+type OAuthClient struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	ClientID   string     `gorm:"column:client_id;type:varchar(64);index:oauth_client_client_id_idx,unique"`
+	Name       string     `gorm:"column:name;type:varchar(100)"`
+	SecretHash string     `gorm:"column:secret_hash;type:varchar(64)"`
+	Scopes     string     `gorm:"column:scopes;type:text"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	BaseEntity
+}
+
+func (e *OAuthClient) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// ScopeList returns the scopes the client may request, which are stored space-separated
+func (e *OAuthClient) ScopeList() []string {
+	return strings.Fields(e.Scopes)
+}
+
+// IsActive reports whether the client can still obtain and use tokens
+func (e *OAuthClient) IsActive() bool {
+	return e.RevokedAt == nil
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to their account at an external OpenID Connect provider.
+// The provider and its subject identifier together are the stable key, emails can change.
+type UserIdentity struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID   uuid.UUID `gorm:"column:user_id;type:uuid;index:user_identity_user_idx"`
+	Provider string    `gorm:"column:provider;type:varchar(50);index:user_identity_provider_subject_idx,unique"`
+	Subject  string    `gorm:"column:subject;type:varchar(255);index:user_identity_provider_subject_idx,unique"`
+	Email    string    `gorm:"column:email;type:varchar(320)"`
+	BaseEntity
+}
+
+func (e *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// OIDCLoginState is kept between redirecting a user to a provider and handling the callback.
+// It is looked up by the SHA-256 of the state parameter and deleted when consumed.
+type OIDCLoginState struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	StateHash    string     `gorm:"column:state_hash;type:varchar(64);index:oidc_login_state_hash_idx,unique"`
+	Provider     string     `gorm:"column:provider;type:varchar(50)"`
+	CodeVerifier string     `gorm:"column:code_verifier;type:varchar(128)"`
+	Nonce        string     `gorm:"column:nonce;type:varchar(128)"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at;index:oidc_login_state_expires_idx"`
+	CreatedAt    *time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (e *OIDCLoginState) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"ienergy-template-go/pkg/constant"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TenantScoped is implemented by entities that belong to one organization through their organization_id column.
+// Repository queries on them are restricted to the organization active in the request, see repository.RegisterTenantScope.
+type TenantScoped interface {
+	TenantScoped()
+}
+
+// Organization is a customer company. Users belong to it through OrganizationMember.
+type Organization struct {
+	ID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name string    `gorm:"column:name;type:varchar(100)"`
+	Slug string    `gorm:"column:slug;type:varchar(50);index:organization_slug_idx,unique"`
+	BaseEntity
+}
+
+func (e *Organization) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// OrganizationMember gives a user a role, such as constant.OrganizationRoleOwner, in an organization
+type OrganizationMember struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID    `gorm:"column:organization_id;type:uuid;index:organization_member_idx,unique"`
+	UserID         uuid.UUID    `gorm:"column:user_id;type:uuid;index:organization_member_idx,unique;index:organization_member_user_idx"`
+	Role           string       `gorm:"column:role;type:varchar(20)"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+	User           User         `gorm:"foreignKey:UserID"`
+	BaseEntity
+}
+
+func (e *OrganizationMember) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// TenantScoped implements TenantScoped
+func (OrganizationMember) TenantScoped() {}
+
+// OrganizationInvitation asks someone, by email, to join an organization with a role.
+// It is redeemed once through a signed link mailed to that address, see constant.PurposeInvitation.
+type OrganizationInvitation struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID    `gorm:"column:organization_id;type:uuid;index:organization_invitation_idx"`
+	Email          string       `gorm:"column:email;type:varchar(255);index:organization_invitation_idx"`
+	Role           string       `gorm:"column:role;type:varchar(20)"`
+	InvitedBy      uuid.UUID    `gorm:"column:invited_by;type:uuid"`
+	ExpiresAt      time.Time    `gorm:"column:expires_at"`
+	AcceptedAt     *time.Time   `gorm:"column:accepted_at"`
+	AcceptedBy     *uuid.UUID   `gorm:"column:accepted_by;type:uuid"`
+	RevokedAt      *time.Time   `gorm:"column:revoked_at"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+	BaseEntity
+}
+
+func (e *OrganizationInvitation) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// TenantScoped implements TenantScoped
+func (OrganizationInvitation) TenantScoped() {}
+
+// Status returns the constant.InvitationStatus* the invitation is in
+func (e OrganizationInvitation) Status() string {
+	switch {
+	case e.AcceptedAt != nil:
+		return constant.InvitationStatusAccepted
+	case e.RevokedAt != nil:
+		return constant.InvitationStatusRevoked
+	case !e.ExpiresAt.After(time.Now()):
+		return constant.InvitationStatusExpired
+	default:
+		return constant.InvitationStatusPending
+	}
+}
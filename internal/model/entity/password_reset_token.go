@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken is a single-use token mailed to a user who forgot their password.
+// Only the SHA-256 of the token is stored so a database leak cannot be used to reset passwords.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `gorm:"column:user_id;type:uuid;index:password_reset_token_user_idx"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);index:password_reset_token_hash_idx,unique"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	BaseEntity
+}
+
+func (e *PasswordResetToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is the server-side record of an issued refresh token.
+// Every token produced by rotating another one shares its FamilyID, so a
+// replayed token can revoke the whole chain at once.
+type RefreshToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `gorm:"column:user_id;type:uuid;index:refresh_token_user_idx"`
+	FamilyID   uuid.UUID  `gorm:"column:family_id;type:uuid;index:refresh_token_family_idx"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	ReplacedBy *uuid.UUID `gorm:"column:replaced_by;type:uuid"`
+	BaseEntity
+}
+
+func (e *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// IsRevoked reports whether the token has already been used or revoked
+func (e *RefreshToken) IsRevoked() bool {
+	return e.RevokedAt != nil
+}
+
+// IsExpired reports whether the token is past its expiry time
+func (e *RefreshToken) IsExpired(now time.Time) bool {
+	return now.After(e.ExpiresAt)
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// RevokedToken marks an access token ID (jti) as no longer accepted.
+// Rows only need to live until the token would have expired anyway.
+type RevokedToken struct {
+	ID        string     `gorm:"type:varchar(64);primaryKey"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;index:revoked_token_expires_idx"`
+	CreatedAt *time.Time `gorm:"column:created_at;autoCreateTime"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	Name        string       `gorm:"column:name;type:varchar(50);index:role_name_idx,unique"`
+	Description string       `gorm:"column:description;type:varchar(255)"`
+	Permissions []Permission `gorm:"many2many:role_permissions;"`
+	BaseEntity
+}
+
+func (e *Role) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"column:name;type:varchar(100);index:permission_name_idx,unique"`
+	Description string    `gorm:"column:description;type:varchar(255)"`
+	BaseEntity
+}
+
+func (e *Permission) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// RoleNames returns the names of the given roles
+func RoleNames(roles []Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// PermissionNames returns the distinct permission names granted by the given roles
+func PermissionNames(roles []Role) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if seen[permission.Name] {
+				continue
+			}
+			seen[permission.Name] = true
+			names = append(names, permission.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is one login of a user on one device.
+// Its ID is the FamilyID of the refresh tokens issued for the login and is carried in
+// every access token as the sid claim, so revoking the session rejects both.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `gorm:"column:user_id;type:uuid;index:session_user_idx"`
+	Device     string     `gorm:"column:device;type:varchar(100)"`
+	UserAgent  string     `gorm:"column:user_agent;type:varchar(512)"`
+	IPAddress  string     `gorm:"column:ip_address;type:varchar(45)"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	// OrganizationID is the organization the session is working in, carried in its access tokens as the org claim
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;type:uuid"`
+	BaseEntity
+}
+
+func (e *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// IsActive reports whether tokens of the session are still accepted
+func (e *Session) IsActive(now time.Time) bool {
+	return e.RevokedAt == nil && now.Before(e.ExpiresAt)
+}
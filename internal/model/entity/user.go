@@ -1,7 +1,10 @@
 package entity
 
 import (
+	"ienergy-template-go/internal/model/entity/enum"
 	"ienergy-template-go/internal/model/request"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -13,9 +16,72 @@ type User struct {
 	LastName  string    `gorm:"column:last_name;type:varchar(50)"`
 	Email     string    `gorm:"column:email;type:varchar(50);index:email_idx,unique"`
 	Password  string    `gorm:"column:password;type:varchar(150)"`
+	Roles     []Role    `gorm:"many2many:user_roles;"`
+
+	EmailVerifiedAt         *time.Time `gorm:"column:email_verified_at"`
+	EmailVerificationSentAt *time.Time `gorm:"column:email_verification_sent_at"`
+	// PendingEmail is the address the user asked to change to, until they confirm it
+	PendingEmail string `gorm:"column:pending_email;type:varchar(50)"`
+	// State is the account status stored with enum.EnumStateDB; existing rows default to active
+	State int `gorm:"column:state;type:smallint;not null;default:0"`
+	// ErasedAt is set once the personal data of the user was anonymised; the row is kept soft-deleted
+	ErasedAt *time.Time `gorm:"column:erased_at"`
 	BaseEntity
 }
 
+// IsEmailVerified reports whether the user has confirmed their email address
+func (e *User) IsEmailVerified() bool {
+	return e.EmailVerifiedAt != nil
+}
+
+// IsErased reports whether the personal data of the user was erased
+func (e *User) IsErased() bool {
+	return e.ErasedAt != nil
+}
+
+// ErasedEmail is the placeholder stored as the email of an erased user.
+// It keeps the unique email index satisfied and uses the reserved .invalid domain, so no mail is ever sent to it.
+func ErasedEmail(userID uuid.UUID) string {
+	return "erased-" + strings.ReplaceAll(userID.String(), "-", "") + "@invalid"
+}
+
+// userStateTransitions lists the account states a user can move to from each state
+var userStateTransitions = map[string][]string{
+	enum.StatePending:     {enum.StateActive, enum.StateDeactivated},
+	enum.StateActive:      {enum.StateSuspended, enum.StateDeactivated},
+	enum.StateSuspended:   {enum.StateActive, enum.StateDeactivated},
+	enum.StateDeactivated: {enum.StateActive},
+}
+
+// StateName returns the account status, such as enum.StateActive
+func (e *User) StateName() string {
+	return enum.EnumState(e.State)
+}
+
+// IsActive reports whether the user may log in and use their tokens
+func (e *User) IsActive() bool {
+	return e.StateName() == enum.StateActive
+}
+
+// CanTransitionTo reports whether the account may move from its current status to state
+func (e *User) CanTransitionTo(state string) bool {
+	for _, next := range userStateTransitions[e.StateName()] {
+		if next == state {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyEmail records that the user confirmed their email address, which activates a pending account.
+// It mirrors UserRepo.MarkEmailVerified for a user already loaded in memory.
+func (e *User) VerifyEmail(at time.Time) {
+	e.EmailVerifiedAt = &at
+	if e.StateName() == enum.StatePending {
+		e.State = enum.EnumStateDB(enum.StateActive)
+	}
+}
+
 func (e *User) BeforeCreate(tx *gorm.DB) (err error) {
 	if e.ID == uuid.Nil {
 		e.ID = uuid.New()
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// User import statuses
+const (
+	UserImportPending   = "PENDING"
+	UserImportCompleted = "COMPLETED"
+	UserImportFailed    = "FAILED"
+)
+
+// UserImport is a CSV of users uploaded by an admin, registered one row at a time in the background.
+// The CSV holds passwords, so Content is cleared once the import is processed.
+type UserImport struct {
+	ID          uuid.UUID         `gorm:"type:uuid;primaryKey"`
+	FileName    string            `gorm:"column:file_name;type:varchar(255)"`
+	Status      string            `gorm:"column:status;type:varchar(20);index:user_import_status_idx"`
+	Content     []byte            `gorm:"column:content;type:bytea"`
+	TotalRows   int               `gorm:"column:total_rows"`
+	CreatedRows int               `gorm:"column:created_rows"`
+	FailedRows  int               `gorm:"column:failed_rows"`
+	CompletedAt *time.Time        `gorm:"column:completed_at"`
+	Errors      []UserImportError `gorm:"foreignKey:ImportID"`
+	BaseEntity
+}
+
+func (e *UserImport) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// UserImportError tells why the row on Line of an import's CSV did not create a user
+type UserImportError struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ImportID uuid.UUID `gorm:"column:import_id;type:uuid;index:user_import_error_import_idx"`
+	Line     int       `gorm:"column:line"`
+	Email    string    `gorm:"column:email;type:varchar(255)"`
+	Message  string    `gorm:"column:message;type:varchar(500)"`
+}
+
+func (e *UserImportError) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
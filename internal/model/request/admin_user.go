@@ -0,0 +1,66 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+)
+
+// UserSortFields are the fields the user list can be sorted by
+var UserSortFields = []string{"created_at", "updated_at", "email", "first_name", "last_name"}
+
+// UserFilterRequest filters the admin user list. Names matches the full name or the email,
+// and FromDate and ToDate bound when the user registered.
+type UserFilterRequest struct {
+	BaseFilterRequest
+	Deleted bool `json:"deleted" form:"deleted"`
+}
+
+func (u *UserFilterRequest) Validate() error {
+	return u.BaseFilterRequest.Validate(UserSortFields...)
+}
+
+// UserExportRequest filters the exported users like the admin user list, without paging,
+// and picks the format: csv unless Format is json
+type UserExportRequest struct {
+	UserFilterRequest
+	Format string `json:"format" form:"format"`
+}
+
+func (u *UserExportRequest) Validate() error {
+	switch u.Format {
+	case "":
+		u.Format = constant.UserExportFormatCSV
+	case constant.UserExportFormatCSV, constant.UserExportFormatJSON:
+	default:
+		return errors.NewBadRequestError("format must be csv or json") //nolint
+	}
+
+	return u.UserFilterRequest.Validate()
+}
+
+// AdminUpdateUserRequest changes only the fields that are set
+type AdminUpdateUserRequest struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Email     *string `json:"email"`
+}
+
+func (a *AdminUpdateUserRequest) Validate() error {
+	return validateProfileFields(a.FirstName, a.LastName, a.Email)
+}
+
+// ChangeUserStateRequest explains why an admin suspends, reactivates or deactivates an account
+type ChangeUserStateRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (c *ChangeUserStateRequest) Validate() error {
+	if len(c.Reason) == 0 {
+		return errors.NewBadRequestError("reason is required!") //nolint
+	}
+	if len(c.Reason) > 500 {
+		return errors.NewBadRequestError("reason must be at most 500 characters") //nolint
+	}
+
+	return nil
+}
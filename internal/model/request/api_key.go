@@ -0,0 +1,29 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (a *CreateAPIKeyRequest) Validate() error {
+	if len(a.Name) == 0 {
+		return errors.NewBadRequestError("name is required!") //nolint
+	}
+	if len(a.Name) > 100 {
+		return errors.NewBadRequestError("name must be at most 100 characters") //nolint
+	}
+	if len(a.Scopes) == 0 {
+		return errors.NewBadRequestError("at least one scope is required!") //nolint
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(time.Now()) {
+		return errors.NewBadRequestError("expires_at must be in the future") //nolint
+	}
+
+	return nil
+}
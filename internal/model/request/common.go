@@ -1,20 +1,64 @@
 package request
 
+import (
+	"ienergy-template-go/pkg/errors"
+	"strings"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
 type BaseFilterRequest struct {
-	PageSize  int    `json:"page_size"`
-	PageIndex int    `json:"page_index"`
-	Names     string `json:"name"`
+	PageSize  int    `json:"page_size" form:"page_size"`
+	PageIndex int    `json:"page_index" form:"page_index"`
+	Names     string `json:"name" form:"name"`
 	IDs       []uint `json:"id_includes"`
-	FromDate  int64  `json:"from_date"`
-	ToDate    int64  `json:"to_date"`
-	Sort      string `json:"sort"`
+	FromDate  int64  `json:"from_date" form:"from_date"`
+	ToDate    int64  `json:"to_date" form:"to_date"`
+	Sort      string `json:"sort" form:"sort"`
 }
 
 func (b BaseFilterRequest) GetOffsetAndLimit() (limit, offset int) {
-	if b.PageSize == 0 {
-		return 20, 0
-	}
 	limit = b.PageSize
-	offset = b.PageIndex * b.PageSize
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	offset = b.PageIndex * limit
 	return
 }
+
+// SortField splits Sort into the field to order by and whether the order is descending,
+// which is written as a leading "-" (e.g. "-created_at")
+func (b BaseFilterRequest) SortField() (field string, desc bool) {
+	field = strings.TrimPrefix(b.Sort, "-")
+	return field, field != b.Sort
+}
+
+// Validate checks the paging and date range, and that Sort names one of sortFields
+func (b BaseFilterRequest) Validate(sortFields ...string) error {
+	if b.PageSize < 0 || b.PageSize > MaxPageSize {
+		return errors.NewBadRequestError("page_size must be between 1 and 100") //nolint
+	}
+	if b.PageIndex < 0 {
+		return errors.NewBadRequestError("page_index must not be negative") //nolint
+	}
+	if b.FromDate < 0 || b.ToDate < 0 {
+		return errors.NewBadRequestError("from_date and to_date must be unix timestamps") //nolint
+	}
+	if b.FromDate != 0 && b.ToDate != 0 && b.FromDate > b.ToDate {
+		return errors.NewBadRequestError("from_date must be before to_date") //nolint
+	}
+	if b.Sort != "" {
+		field, _ := b.SortField()
+		for _, sortField := range sortFields {
+			if field == sortField {
+				return nil
+			}
+		}
+		return errors.NewBadRequestError("sort must be one of " + strings.Join(sortFields, ", ")) //nolint
+	}
+
+	return nil
+}
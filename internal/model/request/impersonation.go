@@ -0,0 +1,20 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+)
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (i *ImpersonateRequest) Validate() error {
+	if len(i.Reason) == 0 {
+		return errors.NewBadRequestError("reason is required!") //nolint
+	}
+	if len(i.Reason) > 500 {
+		return errors.NewBadRequestError("reason must be at most 500 characters") //nolint
+	}
+
+	return nil
+}
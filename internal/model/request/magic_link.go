@@ -0,0 +1,34 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+	"strings"
+)
+
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+func (m *MagicLinkRequest) Validate() error {
+	if len(m.Email) == 0 {
+		return errors.NewBadRequestError("email is required!") //nolint
+	}
+	emailSplited := strings.Split(m.Email, "@")
+	if len(emailSplited) != 2 {
+		return errors.NewBadRequestError("Invalid email address!") //nolint
+	}
+
+	return nil
+}
+
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token"`
+}
+
+func (m *MagicLinkVerifyRequest) Validate() error {
+	if len(m.Token) == 0 {
+		return errors.NewBadRequestError("token is required!") //nolint
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+)
+
+type MFAConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+func (m *MFAConfirmRequest) Validate() error {
+	if len(m.Code) == 0 {
+		return errors.NewBadRequestError("code is required!") //nolint
+	}
+
+	return nil
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (m *MFAVerifyRequest) Validate() error {
+	if len(m.MFAToken) == 0 {
+		return errors.NewBadRequestError("mfa_token is required!") //nolint
+	}
+	if len(m.Code) == 0 && len(m.RecoveryCode) == 0 {
+		return errors.NewBadRequestError("code or recovery_code is required!") //nolint
+	}
+	if len(m.Code) != 0 && len(m.RecoveryCode) != 0 {
+		return errors.NewBadRequestError("Only one of code and recovery_code can be used!") //nolint
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+)
+
+type CreateOAuthClientRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func (o *CreateOAuthClientRequest) Validate() error {
+	if len(o.Name) == 0 {
+		return errors.NewBadRequestError("name is required!") //nolint
+	}
+	if len(o.Name) > 100 {
+		return errors.NewBadRequestError("name must be at most 100 characters") //nolint
+	}
+	if len(o.Scopes) == 0 {
+		return errors.NewBadRequestError("at least one scope is required!") //nolint
+	}
+
+	return nil
+}
+
+// ClientCredentialsRequest is the form posted to /oauth/token (RFC 6749 section 4.4.2).
+// The client may authenticate with HTTP Basic instead of client_id and client_secret.
+type ClientCredentialsRequest struct {
+	GrantType    string `form:"grant_type"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// IntrospectionRequest is the form posted to /oauth/introspect (RFC 7662 section 2.1)
+type IntrospectionRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
@@ -0,0 +1,21 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+)
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+func (o *OIDCCallbackRequest) Validate() error {
+	if len(o.Code) == 0 {
+		return errors.NewBadRequestError("code is required!") //nolint
+	}
+	if len(o.State) == 0 {
+		return errors.NewBadRequestError("state is required!") //nolint
+	}
+
+	return nil
+}
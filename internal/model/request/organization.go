@@ -0,0 +1,104 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// organizationSlugPattern allows lowercase words of letters and digits separated by single hyphens
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+func (o *CreateOrganizationRequest) Validate() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if len(o.Name) == 0 {
+		return errors.NewBadRequestError("name is required!") //nolint
+	}
+	if len(o.Name) > 100 {
+		return errors.NewBadRequestError("name must be at most 100 characters") //nolint
+	}
+	if len(o.Slug) == 0 {
+		return errors.NewBadRequestError("slug is required!") //nolint
+	}
+	if len(o.Slug) > 50 {
+		return errors.NewBadRequestError("slug must be at most 50 characters") //nolint
+	}
+	if !organizationSlugPattern.MatchString(o.Slug) {
+		return errors.NewBadRequestError("slug may only contain lowercase letters, digits and hyphens") //nolint
+	}
+
+	return nil
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+func (o *UpdateOrganizationRequest) Validate() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if len(o.Name) == 0 {
+		return errors.NewBadRequestError("name is required!") //nolint
+	}
+	if len(o.Name) > 100 {
+		return errors.NewBadRequestError("name must be at most 100 characters") //nolint
+	}
+
+	return nil
+}
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (m *UpdateMemberRoleRequest) Validate() error {
+	if !constant.IsOrganizationRole(m.Role) {
+		return errors.NewBadRequestError("role must be owner, admin or member") //nolint
+	}
+
+	return nil
+}
+
+// CreateInvitationRequest invites someone to the active organization by email
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func (i *CreateInvitationRequest) Validate() error {
+	if len(i.Email) == 0 {
+		return errors.NewBadRequestError("email is required!") //nolint
+	}
+	emailSplited := strings.Split(i.Email, "@")
+	if len(emailSplited) != 2 {
+		return errors.NewBadRequestError("Invalid email address!") //nolint
+	}
+	if !constant.IsOrganizationRole(i.Role) {
+		return errors.NewBadRequestError("role must be owner, admin or member") //nolint
+	}
+
+	return nil
+}
+
+// AcceptInvitationRequest redeems an invitation. The name and password are only used when no account
+// exists for the invited email, one is then registered with them.
+type AcceptInvitationRequest struct {
+	Token           string `json:"token"`
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+func (i *AcceptInvitationRequest) Validate() error {
+	if len(i.Token) == 0 {
+		return errors.NewBadRequestError("token is required!") //nolint
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+	"strings"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (f *ForgotPasswordRequest) Validate() error {
+	if len(f.Email) == 0 {
+		return errors.NewBadRequestError("email is required!") //nolint
+	}
+	emailSplited := strings.Split(f.Email, "@")
+	if len(emailSplited) != 2 {
+		return errors.NewBadRequestError("Invalid email address!") //nolint
+	}
+
+	return nil
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	if len(r.Token) == 0 {
+		return errors.NewBadRequestError("token is required!") //nolint
+	}
+	if r.Password != r.ConfirmPassword {
+		return errors.NewBadRequestError("Password and confirm password are not meet!")
+	}
+	if err := validatePasswordLength(r.Password); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+func (c *ChangePasswordRequest) Validate() error {
+	if len(c.CurrentPassword) == 0 {
+		return errors.NewBadRequestError("current_password is required!") //nolint
+	}
+	if c.Password != c.ConfirmPassword {
+		return errors.NewBadRequestError("Password and confirm password are not meet!")
+	}
+	if err := validatePasswordLength(c.Password); err != nil {
+		return err
+	}
+	if c.Password == c.CurrentPassword {
+		return errors.NewBadRequestError("New password must be different from the current password") //nolint
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+)
+
+// DataExportRequest asks for a copy of the caller's data, as a ZIP archive unless Format is json
+type DataExportRequest struct {
+	Format string `json:"format"`
+}
+
+func (d *DataExportRequest) Validate() error {
+	switch d.Format {
+	case "":
+		d.Format = constant.DataExportFormatZIP
+	case constant.DataExportFormatJSON, constant.DataExportFormatZIP:
+	default:
+		return errors.NewBadRequestError("format must be json or zip") //nolint
+	}
+
+	return nil
+}
+
+// EraseAccountRequest confirms with the password that the caller wants their account erased
+type EraseAccountRequest struct {
+	Password string `json:"password"`
+}
+
+func (e *EraseAccountRequest) Validate() error {
+	if len(e.Password) == 0 {
+		return errors.NewBadRequestError("password is required!") //nolint
+	}
+
+	return nil
+}
+
+// EraseUserRequest records why an admin erases a user, e.g. the reference of the erasure request
+type EraseUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (e *EraseUserRequest) Validate() error {
+	if len(e.Reason) == 0 {
+		return errors.NewBadRequestError("reason is required!") //nolint
+	}
+	if len(e.Reason) > 500 {
+		return errors.NewBadRequestError("reason must be at most 500 characters") //nolint
+	}
+
+	return nil
+}
@@ -1,10 +1,28 @@
 package request
 
 import (
+	"fmt"
 	"ienergy-template-go/pkg/errors"
 	"strings"
 )
 
+const (
+	minPasswordLength = 8
+	// maxPasswordLength is the size of the users.password column
+	maxPasswordLength = 150
+)
+
+// validatePasswordLength checks the bounds every password request shares, the password policy adds its own rules
+func validatePasswordLength(password string) error {
+	if len(password) < minPasswordLength {
+		return errors.NewBadRequestError(fmt.Sprintf("Password must be at least %d characters", minPasswordLength)) //nolint
+	}
+	if len(password) > maxPasswordLength {
+		return errors.NewBadRequestError(fmt.Sprintf("Password must be at most %d bytes", maxPasswordLength)) //nolint
+	}
+	return nil
+}
+
 type UserRegisterRequest struct {
 	FirstName       string `json:"first_name"`
 	LastName        string `json:"last_name"`
@@ -17,8 +35,8 @@ func (u *UserRegisterRequest) Validate() error {
 	if u.Password != u.ConfirmPassword {
 		return errors.NewBadRequestError("Password and confirm password are not meet!")
 	}
-	if len(u.Password) > 150 && len(u.Password) < 8 {
-		return errors.NewBadRequestError("Password must be at least 8 characters") //nolint
+	if err := validatePasswordLength(u.Password); err != nil {
+		return err
 	}
 	if len(u.Email) == 0 {
 		return errors.NewBadRequestError("email is required!") //nolint
@@ -40,6 +58,7 @@ func (u *UserRegisterRequest) Validate() error {
 type UserLoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	ClientIP string `json:"-"`
 }
 
 func (u *UserLoginRequest) Validate() error {
@@ -50,8 +69,66 @@ func (u *UserLoginRequest) Validate() error {
 	if len(emailSplited) != 2 {
 		return errors.NewBadRequestError("Invalid email address!") //nolint
 	}
-	if len(u.Password) > 150 || len(u.Password) < 8 {
-		return errors.NewBadRequestError("Password must be at least 8 characters") //nolint
+	if err := validatePasswordLength(u.Password); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r *RefreshTokenRequest) Validate() error {
+	if len(r.RefreshToken) == 0 {
+		return errors.NewBadRequestError("refresh token is required!") //nolint
+	}
+
+	return nil
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// UpdateUserInfoRequest changes only the fields that are set.
+// A new email address only replaces the current one once it is confirmed.
+type UpdateUserInfoRequest struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Email     *string `json:"email"`
+}
+
+func (u *UpdateUserInfoRequest) Validate() error {
+	return validateProfileFields(u.FirstName, u.LastName, u.Email)
+}
+
+// validateProfileFields trims and checks the profile fields of a partial update, at least one of which must be set
+func validateProfileFields(firstName, lastName, email *string) error {
+	if firstName == nil && lastName == nil && email == nil {
+		return errors.NewBadRequestError("Nothing to update!") //nolint
+	}
+	if firstName != nil {
+		*firstName = strings.TrimSpace(*firstName)
+		if len(*firstName) == 0 || len(*firstName) > 50 {
+			return errors.NewBadRequestError("First name must be between 1 and 50 characters") //nolint
+		}
+	}
+	if lastName != nil {
+		*lastName = strings.TrimSpace(*lastName)
+		if len(*lastName) == 0 || len(*lastName) > 50 {
+			return errors.NewBadRequestError("Last name must be between 1 and 50 characters") //nolint
+		}
+	}
+	if email != nil {
+		*email = strings.TrimSpace(*email)
+		if len(*email) == 0 || len(*email) > 50 {
+			return errors.NewBadRequestError("email must be between 1 and 50 characters") //nolint
+		}
+		if len(strings.Split(*email, "@")) != 2 {
+			return errors.NewBadRequestError("Invalid email address!") //nolint
+		}
 	}
 
 	return nil
@@ -0,0 +1,34 @@
+package request
+
+import (
+	"ienergy-template-go/pkg/errors"
+	"strings"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func (v *VerifyEmailRequest) Validate() error {
+	if len(v.Token) == 0 {
+		return errors.NewBadRequestError("token is required!") //nolint
+	}
+
+	return nil
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email"`
+}
+
+func (r *ResendVerificationEmailRequest) Validate() error {
+	if len(r.Email) == 0 {
+		return errors.NewBadRequestError("email is required!") //nolint
+	}
+	emailSplited := strings.Split(r.Email, "@")
+	if len(emailSplited) != 2 {
+		return errors.NewBadRequestError("Invalid email address!") //nolint
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AdminUserResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Email         string     `json:"email"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	EmailVerified bool       `json:"email_verified"`
+	Status        string     `json:"status"`
+	Roles         []string   `json:"roles"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy     string     `json:"deleted_by,omitempty"`
+	ErasedAt      *time.Time `json:"erased_at,omitempty"`
+}
+
+type UserImportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	FileName    string     `json:"file_name"`
+	Status      string     `json:"status"`
+	TotalRows   int        `json:"total_rows"`
+	CreatedRows int        `json:"created_rows"`
+	FailedRows  int        `json:"failed_rows"`
+	CreatedAt   *time.Time `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	// Errors is only listed when a single import is fetched
+	Errors []UserImportErrorResponse `json:"errors,omitempty"`
+}
+
+// UserImportErrorResponse tells why the row on Line of the CSV, counting the header as line 1, was not imported
+type UserImportErrorResponse struct {
+	Line    int    `json:"line"`
+	Email   string `json:"email"`
+	Message string `json:"message"`
+}
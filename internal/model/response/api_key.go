@@ -0,0 +1,23 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse carries the full key, which is only ever returned once
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -1 +1,24 @@
 package response
+
+// PaginatedResponse is one page of a list, with what clients need to fetch the other pages
+type PaginatedResponse struct {
+	Items      interface{} `json:"items"`
+	PageIndex  int         `json:"page_index"`
+	PageSize   int         `json:"page_size"`
+	Total      int64       `json:"total"`
+	TotalPages int64       `json:"total_pages"`
+}
+
+// NewPaginatedResponse wraps items, the page at offset of the given size out of total results
+func NewPaginatedResponse(items interface{}, limit, offset int, total int64) PaginatedResponse {
+	resp := PaginatedResponse{
+		Items:    items,
+		PageSize: limit,
+		Total:    total,
+	}
+	if limit > 0 {
+		resp.PageIndex = offset / limit
+		resp.TotalPages = (total + int64(limit) - 1) / int64(limit)
+	}
+	return resp
+}
@@ -0,0 +1,15 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationResponse carries a short-lived access token for the impersonated user; there is no refresh token
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+}
@@ -0,0 +1,53 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OAuthClientResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	ClientID  string     `json:"client_id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// CreatedOAuthClientResponse carries the client secret, which is only ever returned once
+type CreatedOAuthClientResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+// OAuthTokenResponse is the RFC 6749 token response; it is not wrapped like other responses
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// OAuthErrorResponse is the RFC 6749 error response of the token endpoint
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// IntrospectionResponse is the RFC 7662 introspection response. An inactive token only has active set to false.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Nbf       int64    `json:"nbf,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+}
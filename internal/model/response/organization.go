@@ -0,0 +1,58 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrganizationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// MembershipResponse is an organization the caller belongs to, with their role in it
+type MembershipResponse struct {
+	Organization OrganizationResponse `json:"organization"`
+	Role         string               `json:"role"`
+	// Active is set on the organization the caller's token works in
+	Active bool `json:"active"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Role      string     `json:"role"`
+	JoinedAt  *time.Time `json:"joined_at"`
+}
+
+// SwitchOrganizationResponse is an access token working in the organization switched to.
+// The refresh token of the session is unchanged and keeps the new organization.
+type SwitchOrganizationResponse struct {
+	Token          string    `json:"token"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Role           string    `json:"role"`
+}
+
+type InvitationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	Status    string     `json:"status"`
+	InvitedBy uuid.UUID  `json:"invited_by"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// AcceptInvitationResponse is the organization joined. AccountCreated is set when
+// the invitation registered a new account, which can log in right away.
+type AcceptInvitationResponse struct {
+	Organization   OrganizationResponse `json:"organization"`
+	Role           string               `json:"role"`
+	UserID         uuid.UUID            `json:"user_id"`
+	AccountCreated bool                 `json:"account_created"`
+}
@@ -0,0 +1,109 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	CreatedAt   *time.Time `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// DataExportFile is a generated export ready to be sent to the user
+type DataExportFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// UserDataExport is everything stored about a user, as written to their data export
+type UserDataExport struct {
+	ExportedAt    time.Time               `json:"exported_at"`
+	Profile       UserDataProfile         `json:"profile"`
+	Sessions      []UserDataSession       `json:"sessions"`
+	APIKeys       []UserDataAPIKey        `json:"api_keys"`
+	Identities    []UserDataIdentity      `json:"identities"`
+	Organizations []UserDataOrganization  `json:"organizations"`
+	Invitations   []UserDataInvitation    `json:"invitations"`
+	AuditLog      []UserDataAuditLogEntry `json:"audit_log"`
+}
+
+type UserDataProfile struct {
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	PendingEmail    string     `json:"pending_email,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Status          string     `json:"status"`
+	Roles           []string   `json:"roles"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+type UserDataSession struct {
+	ID         uuid.UUID  `json:"id"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type UserDataAPIKey struct {
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type UserDataIdentity struct {
+	Provider  string     `json:"provider"`
+	Subject   string     `json:"subject"`
+	Email     string     `json:"email"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type UserDataOrganization struct {
+	ID       uuid.UUID  `json:"id"`
+	Name     string     `json:"name"`
+	Slug     string     `json:"slug"`
+	Role     string     `json:"role"`
+	JoinedAt *time.Time `json:"joined_at"`
+}
+
+// UserDataInvitation is an invitation the user sent, or received by email
+type UserDataInvitation struct {
+	OrganizationID   uuid.UUID  `json:"organization_id"`
+	OrganizationName string     `json:"organization_name"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	InvitedBy        uuid.UUID  `json:"invited_by"`
+	Sent             bool       `json:"sent"`
+	Status           string     `json:"status"`
+	CreatedAt        *time.Time `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	AcceptedAt       *time.Time `json:"accepted_at"`
+}
+
+// UserDataAuditLogEntry is an administrative action the user performed or that was performed on them
+type UserDataAuditLogEntry struct {
+	Action    string     `json:"action"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	TargetID  uuid.UUID  `json:"target_id"`
+	Reason    string     `json:"reason"`
+	IPAddress string     `json:"ip_address,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	Current    bool       `json:"current"`
+}
@@ -3,12 +3,18 @@ package response
 import "github.com/google/uuid"
 
 type UserInfoResponse struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Email    string    `json:"email"`
-	FullName string    `json:"full_name"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	FirstName string    `json:"first_name,omitempty"`
+	LastName  string    `json:"last_name,omitempty"`
+	// PendingEmail is the new address waiting to be confirmed
+	PendingEmail string `json:"pending_email,omitempty"`
 }
 
 type TokenResponse struct {
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
 }
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepo interface {
+	CreateAPIKey(ctx context.Context, key entity.APIKey) error
+	GetAPIKeysByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.APIKey, error error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (resp entity.APIKey, error error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) (revoked bool, error error)
+	RevokeUserAPIKeys(ctx context.Context, userID uuid.UUID) error
+	TouchAPIKey(ctx context.Context, keyID uuid.UUID, usedBefore time.Time) error
+}
+
+type apiKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db database.Database) APIKeyRepo {
+	return &apiKeyRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateAPIKey implements APIKeyRepo.
+func (a *apiKeyRepo) CreateAPIKey(ctx context.Context, key entity.APIKey) error {
+	err := a.db.
+		WithContext(ctx).
+		Create(&key).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetAPIKeysByUserID implements APIKeyRepo.
+// Revoked keys are left out, newest keys come first.
+func (a *apiKeyRepo) GetAPIKeysByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.APIKey, error error) {
+	err := a.db.
+		WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetAPIKeyByPrefix implements APIKeyRepo.
+func (a *apiKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (resp entity.APIKey, error error) {
+	err := a.db.
+		WithContext(ctx).
+		Where("prefix = ?", prefix).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("API key not found")
+	}
+	return
+}
+
+// RevokeAPIKey implements APIKeyRepo.
+// Only an active key owned by the user matches, so users cannot revoke each other's keys.
+func (a *apiKeyRepo) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) (revoked bool, error error) {
+	dbExecute := a.db.
+		WithContext(ctx).
+		Model(&entity.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// RevokeUserAPIKeys implements APIKeyRepo.
+// Every active key of the user is revoked, keys revoked earlier keep their revocation time.
+func (a *apiKeyRepo) RevokeUserAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	err := a.db.
+		WithContext(ctx).
+		Model(&entity.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// TouchAPIKey implements APIKeyRepo.
+// The timestamp is only written when the stored one is older than usedBefore, so a busy key
+// does not cause a write on every request.
+func (a *apiKeyRepo) TouchAPIKey(ctx context.Context, keyID uuid.UUID, usedBefore time.Time) error {
+	err := a.db.
+		WithContext(ctx).
+		Model(&entity.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", keyID, usedBefore).
+		UpdateColumn("last_used_at", time.Now()).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepo interface {
+	CreateAuditLog(ctx context.Context, log entity.AuditLog) error
+	GetAuditLogsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.AuditLog, error error)
+}
+
+type auditLogRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepo(db database.Database) AuditLogRepo {
+	return &auditLogRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateAuditLog implements AuditLogRepo.
+func (a *auditLogRepo) CreateAuditLog(ctx context.Context, log entity.AuditLog) error {
+	err := a.db.
+		WithContext(ctx).
+		Create(&log).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetAuditLogsByUserID implements AuditLogRepo.
+// It returns the actions the user performed and those performed on them, newest first.
+func (a *auditLogRepo) GetAuditLogsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.AuditLog, error error) {
+	err := a.db.
+		WithContext(ctx).
+		Where("actor_id = ? OR target_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DataExportRepo interface {
+	CreateDataExport(ctx context.Context, export entity.DataExport) error
+	GetDataExportByID(ctx context.Context, exportID uuid.UUID) (resp entity.DataExport, error error)
+	GetDataExportsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.DataExport, error error)
+	GetPendingDataExports(ctx context.Context) (resp []entity.DataExport, error error)
+	HasPendingDataExport(ctx context.Context, userID uuid.UUID) (pending bool, error error)
+	CompleteDataExport(ctx context.Context, exportID uuid.UUID, archive []byte, expiresAt time.Time) (completed bool, error error)
+	FailDataExport(ctx context.Context, exportID uuid.UUID, expiresAt time.Time) error
+	DeleteExpiredDataExports(ctx context.Context, now time.Time) error
+}
+
+type dataExportRepo struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepo(db database.Database) DataExportRepo {
+	return &dataExportRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateDataExport implements DataExportRepo.
+func (d *dataExportRepo) CreateDataExport(ctx context.Context, export entity.DataExport) error {
+	err := d.db.
+		WithContext(ctx).
+		Create(&export).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetDataExportByID implements DataExportRepo.
+func (d *dataExportRepo) GetDataExportByID(ctx context.Context, exportID uuid.UUID) (resp entity.DataExport, error error) {
+	err := d.db.
+		WithContext(ctx).
+		Where("id = ?", exportID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Data export not found")
+	}
+	return
+}
+
+// GetDataExportsByUserID implements DataExportRepo.
+// The archives are not loaded, newest exports come first.
+func (d *dataExportRepo) GetDataExportsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.DataExport, error error) {
+	err := d.db.
+		WithContext(ctx).
+		Omit("archive").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetPendingDataExports implements DataExportRepo.
+// Oldest requests come first.
+func (d *dataExportRepo) GetPendingDataExports(ctx context.Context) (resp []entity.DataExport, error error) {
+	err := d.db.
+		WithContext(ctx).
+		Omit("archive").
+		Where("status = ?", entity.DataExportPending).
+		Order("created_at").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// HasPendingDataExport implements DataExportRepo.
+func (d *dataExportRepo) HasPendingDataExport(ctx context.Context, userID uuid.UUID) (pending bool, error error) {
+	var count int64
+	err := d.db.
+		WithContext(ctx).
+		Model(&entity.DataExport{}).
+		Where("user_id = ? AND status = ?", userID, entity.DataExportPending).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return count > 0, nil
+}
+
+// CompleteDataExport implements DataExportRepo.
+// Only a pending export is completed, so an export generated twice is stored once.
+func (d *dataExportRepo) CompleteDataExport(
+	ctx context.Context,
+	exportID uuid.UUID,
+	archive []byte,
+	expiresAt time.Time,
+) (completed bool, error error) {
+	dbExecute := d.db.
+		WithContext(ctx).
+		Model(&entity.DataExport{}).
+		Where("id = ? AND status = ?", exportID, entity.DataExportPending).
+		Updates(map[string]interface{}{
+			"status":       entity.DataExportReady,
+			"archive":      archive,
+			"completed_at": time.Now(),
+			"expires_at":   expiresAt,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// FailDataExport implements DataExportRepo.
+// The failed export is kept until expiresAt so the user can see what happened to their request.
+func (d *dataExportRepo) FailDataExport(ctx context.Context, exportID uuid.UUID, expiresAt time.Time) error {
+	err := d.db.
+		WithContext(ctx).
+		Model(&entity.DataExport{}).
+		Where("id = ? AND status = ?", exportID, entity.DataExportPending).
+		Updates(map[string]interface{}{
+			"status":       entity.DataExportFailed,
+			"completed_at": time.Now(),
+			"expires_at":   expiresAt,
+		}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// DeleteExpiredDataExports implements DataExportRepo.
+// Expired archives hold personal data nobody can download anymore, so the rows are removed for good.
+func (d *dataExportRepo) DeleteExpiredDataExports(ctx context.Context, now time.Time) error {
+	err := d.db.
+		WithContext(ctx).
+		Unscoped().
+		Where("expires_at <= ?", now).
+		Delete(&entity.DataExport{}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationRepo stores invitations to join an organization.
+// Methods act in the tenant of the context, except the ones used to redeem an invitation, which only knows its ID,
+// and GetUserInvitations.
+type InvitationRepo interface {
+	CreateInvitation(ctx context.Context, invitation entity.OrganizationInvitation) error
+	GetInvitations(ctx context.Context) (resp []entity.OrganizationInvitation, error error)
+	GetInvitation(ctx context.Context, invitationID uuid.UUID) (resp entity.OrganizationInvitation, error error)
+	RevokeInvitation(ctx context.Context, invitationID uuid.UUID, revokedBy string) (revoked bool, error error)
+	GetInvitationByID(ctx context.Context, invitationID uuid.UUID) (resp entity.OrganizationInvitation, error error)
+	GetUserInvitations(
+		ctx context.Context,
+		userID uuid.UUID,
+		email string,
+	) (resp []entity.OrganizationInvitation, error error)
+	AcceptInvitation(
+		ctx context.Context,
+		invitation entity.OrganizationInvitation,
+		member entity.OrganizationMember,
+	) (accepted bool, error error)
+}
+
+type invitationRepo struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepo(db database.Database) InvitationRepo {
+	return &invitationRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateInvitation implements InvitationRepo.
+// Pending invitations to the same email are revoked with it, so only the latest link can be redeemed.
+func (i *invitationRepo) CreateInvitation(ctx context.Context, invitation entity.OrganizationInvitation) error {
+	err := i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Model(&entity.OrganizationInvitation{}).
+			Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.Email).
+			Updates(map[string]interface{}{
+				"revoked_at": time.Now(),
+				"updated_by": invitation.CreatedBy,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Omit("Organization").Create(&invitation).Error
+	})
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetInvitations implements InvitationRepo.
+// Invitations of the tenant in every status, newest first.
+func (i *invitationRepo) GetInvitations(ctx context.Context) (resp []entity.OrganizationInvitation, error error) {
+	err := i.db.
+		WithContext(ctx).
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetInvitation implements InvitationRepo.
+func (i *invitationRepo) GetInvitation(
+	ctx context.Context,
+	invitationID uuid.UUID,
+) (resp entity.OrganizationInvitation, error error) {
+	err := i.db.
+		WithContext(ctx).
+		Where("id = ?", invitationID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Invitation not found")
+	}
+	return
+}
+
+// RevokeInvitation implements InvitationRepo.
+// Only an invitation that was neither accepted nor revoked matches.
+func (i *invitationRepo) RevokeInvitation(
+	ctx context.Context,
+	invitationID uuid.UUID,
+	revokedBy string,
+) (revoked bool, error error) {
+	dbExecute := i.db.
+		WithContext(ctx).
+		Model(&entity.OrganizationInvitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"updated_by": revokedBy,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// GetInvitationByID implements InvitationRepo.
+// It is used to redeem an invitation before its organization is known, so it is not tenant scoped.
+func (i *invitationRepo) GetInvitationByID(
+	ctx context.Context,
+	invitationID uuid.UUID,
+) (resp entity.OrganizationInvitation, error error) {
+	err := i.db.
+		WithContext(AllTenants(ctx)).
+		Preload("Organization").
+		Where("id = ?", invitationID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil || resp.Organization.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Invitation not found")
+	}
+	return
+}
+
+// GetUserInvitations implements InvitationRepo.
+// The invitations a user sent, or received at email or accepted, in every organization, oldest first.
+func (i *invitationRepo) GetUserInvitations(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+) (resp []entity.OrganizationInvitation, error error) {
+	err := i.db.
+		WithContext(AllTenants(ctx)).
+		Preload("Organization").
+		Where("invited_by = ? OR email = ? OR accepted_by = ?", userID, email, userID).
+		Order("created_at ASC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// AcceptInvitation implements InvitationRepo.
+// The invitation is marked accepted and the member added in its organization together.
+// Only a pending invitation matches, so it is redeemed exactly once even under concurrent requests.
+func (i *invitationRepo) AcceptInvitation(
+	ctx context.Context,
+	invitation entity.OrganizationInvitation,
+	member entity.OrganizationMember,
+) (accepted bool, err error) {
+	now := time.Now()
+	err = i.db.WithContext(util.WithTenant(ctx, invitation.OrganizationID)).Transaction(func(tx *gorm.DB) error {
+		dbExecute := tx.
+			Model(&entity.OrganizationInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invitation.ID, now).
+			Updates(map[string]interface{}{
+				"accepted_at": now,
+				"accepted_by": member.UserID,
+				"updated_by":  member.UserID.String(),
+			})
+		if dbExecute.Error != nil {
+			return dbExecute.Error
+		}
+		if dbExecute.RowsAffected != 1 {
+			return nil
+		}
+		accepted = true
+		return tx.Omit("Organization", "User").Create(&member).Error
+	})
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return accepted, nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+const (
+	LoginAttemptStoreMemory   = "memory"
+	LoginAttemptStorePostgres = "postgres"
+)
+
+// LoginAttemptStore keeps failed login counters and lockouts keyed by account or client IP
+type LoginAttemptStore interface {
+	GetLoginAttempt(ctx context.Context, key string) (entity.LoginAttempt, error)
+	// RecordLoginFailure adds a failure and returns the new count.
+	// The count restarts at one when the previous failure is older than window.
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration) (failures int, err error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	PruneLoginAttempts(ctx context.Context, before time.Time) error
+}
+
+// NewLoginAttemptStore picks the store configured by LOGIN_ATTEMPT_STORE and
+// prunes stale counters from it in the background while the app is running.
+func NewLoginAttemptStore(lc fx.Lifecycle, db database.Database, config *config.Config) LoginAttemptStore {
+	var store LoginAttemptStore
+	switch config.Auth.LoginAttemptStore {
+	case LoginAttemptStoreMemory:
+		store = NewMemoryLoginAttemptStore()
+	default:
+		store = NewPostgresLoginAttemptStore(db)
+	}
+
+	interval := config.Auth.LoginAttemptPruneInterval
+	if interval <= 0 {
+		return store
+	}
+
+	stop := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go pruneLoginAttempts(store, interval, config.Auth.LoginAttemptWindow, stop)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(stop)
+			return nil
+		},
+	})
+
+	return store
+}
+
+func pruneLoginAttempts(store LoginAttemptStore, interval, window time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := store.PruneLoginAttempts(context.Background(), time.Now().Add(-window)); err != nil {
+				logger.WithError(err).Error("Failed to prune login attempts")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]entity.LoginAttempt
+}
+
+// NewMemoryLoginAttemptStore creates a process-local store, suitable for a single instance or tests
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{
+		attempts: make(map[string]entity.LoginAttempt),
+	}
+}
+
+// GetLoginAttempt implements LoginAttemptStore.
+func (m *memoryLoginAttemptStore) GetLoginAttempt(ctx context.Context, key string) (entity.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return entity.LoginAttempt{Key: key}, nil
+	}
+	return attempt, nil
+}
+
+// RecordLoginFailure implements LoginAttemptStore.
+func (m *memoryLoginAttemptStore) RecordLoginFailure(
+	ctx context.Context,
+	key string,
+	window time.Duration,
+) (failures int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	attempt, ok := m.attempts[key]
+	if !ok || attempt.LastFailedAt.Before(now.Add(-window)) {
+		attempt.Key = key
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	m.attempts[key] = attempt
+	return attempt.Failures, nil
+}
+
+// LockLogin implements LoginAttemptStore.
+func (m *memoryLoginAttemptStore) LockLogin(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempt := m.attempts[key]
+	attempt.Key = key
+	attempt.LockedUntil = &until
+	m.attempts[key] = attempt
+	return nil
+}
+
+// ResetLoginAttempts implements LoginAttemptStore.
+func (m *memoryLoginAttemptStore) ResetLoginAttempts(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
+
+// PruneLoginAttempts implements LoginAttemptStore.
+func (m *memoryLoginAttemptStore) PruneLoginAttempts(ctx context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for key, attempt := range m.attempts {
+		if attempt.LastFailedAt.Before(before) && !attempt.IsLocked(now) {
+			delete(m.attempts, key)
+		}
+	}
+	return nil
+}
+
+type postgresLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresLoginAttemptStore creates a store shared by every instance using the same database
+func NewPostgresLoginAttemptStore(db database.Database) LoginAttemptStore {
+	return &postgresLoginAttemptStore{
+		db: db.GetDB(),
+	}
+}
+
+// GetLoginAttempt implements LoginAttemptStore.
+func (p *postgresLoginAttemptStore) GetLoginAttempt(ctx context.Context, key string) (resp entity.LoginAttempt, error error) {
+	err := p.db.
+		WithContext(ctx).
+		Where("key = ?", key).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	resp.Key = key
+	return
+}
+
+// RecordLoginFailure implements LoginAttemptStore.
+// The upsert increments atomically so concurrent failures are all counted.
+func (p *postgresLoginAttemptStore) RecordLoginFailure(
+	ctx context.Context,
+	key string,
+	window time.Duration,
+) (failures int, err error) {
+	now := time.Now()
+	err = p.db.
+		WithContext(ctx).
+		Raw(`
+			INSERT INTO login_attempts (key, failures, last_failed_at)
+			VALUES (?, 1, ?)
+			ON CONFLICT (key) DO UPDATE SET
+				failures = CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+				last_failed_at = EXCLUDED.last_failed_at
+			RETURNING failures`,
+			key, now, now.Add(-window),
+		).
+		Scan(&failures).Error
+	if err != nil {
+		return 0, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return failures, nil
+}
+
+// LockLogin implements LoginAttemptStore.
+func (p *postgresLoginAttemptStore) LockLogin(ctx context.Context, key string, until time.Time) error {
+	err := p.db.
+		WithContext(ctx).
+		Model(&entity.LoginAttempt{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// ResetLoginAttempts implements LoginAttemptStore.
+func (p *postgresLoginAttemptStore) ResetLoginAttempts(ctx context.Context, key string) error {
+	err := p.db.
+		WithContext(ctx).
+		Where("key = ?", key).
+		Delete(&entity.LoginAttempt{}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// PruneLoginAttempts implements LoginAttemptStore.
+func (p *postgresLoginAttemptStore) PruneLoginAttempts(ctx context.Context, before time.Time) error {
+	err := p.db.
+		WithContext(ctx).
+		Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until <= ?)", before, time.Now()).
+		Delete(&entity.LoginAttempt{}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MagicLinkTokenRepo interface {
+	CreateMagicLinkToken(ctx context.Context, token entity.MagicLinkToken) error
+	GetMagicLinkTokenByHash(ctx context.Context, tokenHash string) (resp entity.MagicLinkToken, error error)
+	MarkMagicLinkTokenUsed(ctx context.Context, tokenID uuid.UUID) (used bool, error error)
+	InvalidateUserMagicLinkTokens(ctx context.Context, userID uuid.UUID) error
+}
+
+type magicLinkTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkTokenRepo(db database.Database) MagicLinkTokenRepo {
+	return &magicLinkTokenRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateMagicLinkToken implements MagicLinkTokenRepo.
+func (m *magicLinkTokenRepo) CreateMagicLinkToken(ctx context.Context, token entity.MagicLinkToken) error {
+	err := m.db.
+		WithContext(ctx).
+		Create(&token).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetMagicLinkTokenByHash implements MagicLinkTokenRepo.
+func (m *magicLinkTokenRepo) GetMagicLinkTokenByHash(
+	ctx context.Context,
+	tokenHash string,
+) (resp entity.MagicLinkToken, error error) {
+	err := m.db.
+		WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Magic link not found")
+	}
+	return
+}
+
+// MarkMagicLinkTokenUsed implements MagicLinkTokenRepo.
+// Only an unused token matches, so a link logs in exactly once even under concurrent requests.
+func (m *magicLinkTokenRepo) MarkMagicLinkTokenUsed(ctx context.Context, tokenID uuid.UUID) (used bool, error error) {
+	dbExecute := m.db.
+		WithContext(ctx).
+		Model(&entity.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", time.Now())
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// InvalidateUserMagicLinkTokens implements MagicLinkTokenRepo.
+func (m *magicLinkTokenRepo) InvalidateUserMagicLinkTokens(ctx context.Context, userID uuid.UUID) error {
+	err := m.db.
+		WithContext(ctx).
+		Model(&entity.MagicLinkToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
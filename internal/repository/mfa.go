@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MFARepo interface {
+	GetMFAFactorByUserID(ctx context.Context, userID uuid.UUID) (resp entity.MFAFactor, error error)
+	ReplacePendingMFAFactor(ctx context.Context, factor entity.MFAFactor) error
+	ConfirmMFAFactor(
+		ctx context.Context,
+		factor entity.MFAFactor,
+		step int64,
+		recoveryCodes []entity.MFARecoveryCode,
+	) (confirmed bool, error error)
+	UseMFAStep(ctx context.Context, factorID uuid.UUID, step int64) (used bool, error error)
+	UseMFARecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (used bool, error error)
+}
+
+type mfaRepo struct {
+	db *gorm.DB
+}
+
+func NewMFARepo(db database.Database) MFARepo {
+	return &mfaRepo{
+		db: db.GetDB(),
+	}
+}
+
+// GetMFAFactorByUserID implements MFARepo.
+func (m *mfaRepo) GetMFAFactorByUserID(ctx context.Context, userID uuid.UUID) (resp entity.MFAFactor, error error) {
+	err := m.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("MFA factor not found")
+	}
+	return
+}
+
+// ReplacePendingMFAFactor implements MFARepo.
+// An unconfirmed factor left over from an abandoned enrolment is replaced; a confirmed one is never touched.
+func (m *mfaRepo) ReplacePendingMFAFactor(ctx context.Context, factor entity.MFAFactor) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Unscoped().
+			Where("user_id = ? AND confirmed_at IS NULL", factor.UserID).
+			Delete(&entity.MFAFactor{}).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		if err := tx.Create(&factor).Error; err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// ConfirmMFAFactor implements MFARepo.
+// The factor is confirmed and the recovery codes replaced in one transaction; the step used to
+// confirm is recorded so the same code cannot be replayed at login.
+func (m *mfaRepo) ConfirmMFAFactor(
+	ctx context.Context,
+	factor entity.MFAFactor,
+	step int64,
+	recoveryCodes []entity.MFARecoveryCode,
+) (confirmed bool, err error) {
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dbExecute := tx.
+			Model(&entity.MFAFactor{}).
+			Where("id = ? AND confirmed_at IS NULL", factor.ID).
+			Updates(map[string]interface{}{
+				"confirmed_at":   time.Now(),
+				"last_used_step": step,
+			})
+		if dbExecute.Error != nil {
+			return errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+		}
+		if dbExecute.RowsAffected != 1 {
+			return nil
+		}
+		confirmed = true
+
+		err := tx.
+			Unscoped().
+			Where("user_id = ?", factor.UserID).
+			Delete(&entity.MFARecoveryCode{}).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		if len(recoveryCodes) == 0 {
+			return nil
+		}
+		if err := tx.Create(&recoveryCodes).Error; err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return confirmed, nil
+}
+
+// UseMFAStep implements MFARepo.
+// A TOTP step is accepted at most once, which stops a code observed by an attacker from being replayed.
+func (m *mfaRepo) UseMFAStep(ctx context.Context, factorID uuid.UUID, step int64) (used bool, error error) {
+	dbExecute := m.db.
+		WithContext(ctx).
+		Model(&entity.MFAFactor{}).
+		Where("id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", factorID, step).
+		Update("last_used_step", step)
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// UseMFARecoveryCode implements MFARepo.
+func (m *mfaRepo) UseMFARecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (used bool, error error) {
+	dbExecute := m.db.
+		WithContext(ctx).
+		Model(&entity.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
@@ -4,4 +4,22 @@ import "go.uber.org/fx"
 
 var Module = fx.Options(
 	fx.Provide(NewUserRepo),
+	fx.Provide(NewRefreshTokenRepo),
+	fx.Provide(NewTokenRevocationStore),
+	fx.Provide(NewRoleRepo),
+	fx.Provide(NewPasswordResetTokenRepo),
+	fx.Provide(NewMFARepo),
+	fx.Provide(NewLoginAttemptStore),
+	fx.Provide(NewOIDCRepo),
+	fx.Provide(NewAPIKeyRepo),
+	fx.Provide(NewSessionRepo),
+	fx.Provide(NewMagicLinkTokenRepo),
+	fx.Provide(NewOAuthClientRepo),
+	fx.Provide(NewAuditLogRepo),
+	fx.Provide(NewDataExportRepo),
+	fx.Provide(NewUserImportRepo),
+	fx.Provide(NewOrganizationRepo),
+	fx.Provide(NewInvitationRepo),
+	fx.Invoke(RegisterTenantScope),
+	fx.Invoke(SeedDefaultRoles),
 )
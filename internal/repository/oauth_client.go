@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OAuthClientRepo interface {
+	CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error
+	GetOAuthClients(ctx context.Context) (resp []entity.OAuthClient, error error)
+	GetOAuthClientByClientID(ctx context.Context, clientID string) (resp entity.OAuthClient, error error)
+	RevokeOAuthClient(ctx context.Context, id uuid.UUID, revokedBy string) (revoked bool, error error)
+}
+
+type oauthClientRepo struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepo(db database.Database) OAuthClientRepo {
+	return &oauthClientRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateOAuthClient implements OAuthClientRepo.
+func (o *oauthClientRepo) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error {
+	err := o.db.
+		WithContext(ctx).
+		Create(&client).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetOAuthClients implements OAuthClientRepo.
+// Revoked clients are left out, newest clients come first.
+func (o *oauthClientRepo) GetOAuthClients(ctx context.Context) (resp []entity.OAuthClient, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Where("revoked_at IS NULL").
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetOAuthClientByClientID implements OAuthClientRepo.
+func (o *oauthClientRepo) GetOAuthClientByClientID(
+	ctx context.Context,
+	clientID string,
+) (resp entity.OAuthClient, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Where("client_id = ?", clientID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("OAuth client not found")
+	}
+	return
+}
+
+// RevokeOAuthClient implements OAuthClientRepo.
+func (o *oauthClientRepo) RevokeOAuthClient(
+	ctx context.Context,
+	id uuid.UUID,
+	revokedBy string,
+) (revoked bool, error error) {
+	dbExecute := o.db.
+		WithContext(ctx).
+		Model(&entity.OAuthClient{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"updated_by": revokedBy,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
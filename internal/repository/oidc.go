@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCRepo interface {
+	CreateOIDCLoginState(ctx context.Context, state entity.OIDCLoginState) error
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (resp entity.OIDCLoginState, error error)
+	GetUserIdentity(ctx context.Context, provider, subject string) (resp entity.UserIdentity, error error)
+	GetUserIdentitiesByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.UserIdentity, error error)
+	CreateUserIdentity(ctx context.Context, identity entity.UserIdentity) error
+}
+
+type oidcRepo struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepo(db database.Database) OIDCRepo {
+	return &oidcRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateOIDCLoginState implements OIDCRepo.
+// Expired states are removed at the same time so abandoned logins do not pile up.
+func (o *oidcRepo) CreateOIDCLoginState(ctx context.Context, state entity.OIDCLoginState) error {
+	err := o.db.
+		WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&entity.OIDCLoginState{}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	err = o.db.
+		WithContext(ctx).
+		Create(&state).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// ConsumeOIDCLoginState implements OIDCRepo.
+// The state is deleted as it is read, so a callback can only be completed once.
+func (o *oidcRepo) ConsumeOIDCLoginState(
+	ctx context.Context,
+	stateHash string,
+) (resp entity.OIDCLoginState, error error) {
+	var states []entity.OIDCLoginState
+	err := o.db.
+		WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state_hash = ?", stateHash).
+		Delete(&states).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if len(states) == 0 {
+		return resp, errors.NewNotFoundError("Login state not found")
+	}
+	return states[0], nil
+}
+
+// GetUserIdentity implements OIDCRepo.
+func (o *oidcRepo) GetUserIdentity(ctx context.Context, provider, subject string) (resp entity.UserIdentity, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("User identity not found")
+	}
+	return
+}
+
+// GetUserIdentitiesByUserID implements OIDCRepo.
+func (o *oidcRepo) GetUserIdentitiesByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.UserIdentity, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// CreateUserIdentity implements OIDCRepo.
+func (o *oidcRepo) CreateUserIdentity(ctx context.Context, identity entity.UserIdentity) error {
+	err := o.db.
+		WithContext(ctx).
+		Create(&identity).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
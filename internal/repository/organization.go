@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepo stores organizations and their members.
+// Member methods act in the tenant of the context, except the GetUserMembership* ones that look across organizations.
+type OrganizationRepo interface {
+	CreateOrganization(ctx context.Context, organization entity.Organization, owner entity.OrganizationMember) error
+	GetOrganizationByID(ctx context.Context, organizationID uuid.UUID) (resp entity.Organization, error error)
+	IsSlugTaken(ctx context.Context, slug string) (taken bool, error error)
+	UpdateOrganization(ctx context.Context, organizationID uuid.UUID, name, updatedBy string) error
+	GetUserMembership(ctx context.Context, organizationID, userID uuid.UUID) (resp entity.OrganizationMember, error error)
+	GetUserMemberships(ctx context.Context, userID uuid.UUID) (resp []entity.OrganizationMember, error error)
+	GetMembers(ctx context.Context) (resp []entity.OrganizationMember, error error)
+	GetMember(ctx context.Context, userID uuid.UUID) (resp entity.OrganizationMember, error error)
+	UpdateMemberRole(ctx context.Context, userID uuid.UUID, role, updatedBy string) (updated bool, error error)
+	RemoveMember(ctx context.Context, userID uuid.UUID) (removed bool, error error)
+	CountOwners(ctx context.Context) (count int64, error error)
+}
+
+type organizationRepo struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepo(db database.Database) OrganizationRepo {
+	return &organizationRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateOrganization implements OrganizationRepo.
+// The organization and the membership of its owner are created together.
+func (o *organizationRepo) CreateOrganization(
+	ctx context.Context,
+	organization entity.Organization,
+	owner entity.OrganizationMember,
+) error {
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&organization).Error; err != nil {
+			return err
+		}
+		owner.OrganizationID = organization.ID
+		return tx.
+			WithContext(util.WithTenant(ctx, organization.ID)).
+			Omit("Organization", "User").
+			Create(&owner).Error
+	})
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetOrganizationByID implements OrganizationRepo.
+func (o *organizationRepo) GetOrganizationByID(
+	ctx context.Context,
+	organizationID uuid.UUID,
+) (resp entity.Organization, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Where("id = ?", organizationID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Organization not found")
+	}
+	return
+}
+
+// IsSlugTaken implements OrganizationRepo.
+// Slugs of deleted organizations stay taken, as they are still in the unique index.
+func (o *organizationRepo) IsSlugTaken(ctx context.Context, slug string) (taken bool, error error) {
+	var count int64
+	err := o.db.
+		WithContext(ctx).
+		Unscoped().
+		Model(&entity.Organization{}).
+		Where("slug = ?", slug).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return count > 0, nil
+}
+
+// UpdateOrganization implements OrganizationRepo.
+func (o *organizationRepo) UpdateOrganization(
+	ctx context.Context,
+	organizationID uuid.UUID,
+	name, updatedBy string,
+) error {
+	err := o.db.
+		WithContext(ctx).
+		Model(&entity.Organization{}).
+		Where("id = ?", organizationID).
+		Updates(map[string]interface{}{
+			"name":       name,
+			"updated_by": updatedBy,
+		}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetUserMembership implements OrganizationRepo.
+// It is used to check a user belongs to an organization before acting in it, so it is not tenant scoped.
+func (o *organizationRepo) GetUserMembership(
+	ctx context.Context,
+	organizationID, userID uuid.UUID,
+) (resp entity.OrganizationMember, error error) {
+	err := o.db.
+		WithContext(AllTenants(ctx)).
+		Preload("Organization").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil || resp.Organization.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Not a member of the organization")
+	}
+	return
+}
+
+// GetUserMemberships implements OrganizationRepo.
+// The memberships of a user in every organization, oldest first, with their organization.
+func (o *organizationRepo) GetUserMemberships(
+	ctx context.Context,
+	userID uuid.UUID,
+) (resp []entity.OrganizationMember, error error) {
+	var memberships []entity.OrganizationMember
+	err := o.db.
+		WithContext(AllTenants(ctx)).
+		Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&memberships).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+
+	resp = make([]entity.OrganizationMember, 0, len(memberships))
+	for _, membership := range memberships {
+		// The organization was deleted
+		if membership.Organization.ID == uuid.Nil {
+			continue
+		}
+		resp = append(resp, membership)
+	}
+	return
+}
+
+// GetMembers implements OrganizationRepo.
+// Members of the tenant with their user, oldest first.
+func (o *organizationRepo) GetMembers(ctx context.Context) (resp []entity.OrganizationMember, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Preload("User").
+		Order("created_at ASC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetMember implements OrganizationRepo.
+func (o *organizationRepo) GetMember(ctx context.Context, userID uuid.UUID) (resp entity.OrganizationMember, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Preload("User").
+		Where("user_id = ?", userID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Member not found")
+	}
+	return
+}
+
+// UpdateMemberRole implements OrganizationRepo.
+func (o *organizationRepo) UpdateMemberRole(
+	ctx context.Context,
+	userID uuid.UUID,
+	role, updatedBy string,
+) (updated bool, error error) {
+	dbExecute := o.db.
+		WithContext(ctx).
+		Model(&entity.OrganizationMember{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"role":       role,
+			"updated_by": updatedBy,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// RemoveMember implements OrganizationRepo.
+// The membership is deleted for good, so the user can be added again later.
+func (o *organizationRepo) RemoveMember(ctx context.Context, userID uuid.UUID) (removed bool, error error) {
+	dbExecute := o.db.
+		WithContext(ctx).
+		Unscoped().
+		Where("user_id = ?", userID).
+		Delete(&entity.OrganizationMember{})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// CountOwners implements OrganizationRepo.
+func (o *organizationRepo) CountOwners(ctx context.Context) (count int64, error error) {
+	err := o.db.
+		WithContext(ctx).
+		Model(&entity.OrganizationMember{}).
+		Where("role = ?", constant.OrganizationRoleOwner).
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
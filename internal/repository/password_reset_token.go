@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepo interface {
+	CreatePasswordResetToken(ctx context.Context, token entity.PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (resp entity.PasswordResetToken, error error)
+	MarkPasswordResetTokenUsed(ctx context.Context, tokenID uuid.UUID) (used bool, error error)
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error
+}
+
+type passwordResetTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepo(db database.Database) PasswordResetTokenRepo {
+	return &passwordResetTokenRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreatePasswordResetToken implements PasswordResetTokenRepo.
+func (p *passwordResetTokenRepo) CreatePasswordResetToken(ctx context.Context, token entity.PasswordResetToken) error {
+	err := p.db.
+		WithContext(ctx).
+		Create(&token).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetPasswordResetTokenByHash implements PasswordResetTokenRepo.
+func (p *passwordResetTokenRepo) GetPasswordResetTokenByHash(
+	ctx context.Context,
+	tokenHash string,
+) (resp entity.PasswordResetToken, error error) {
+	err := p.db.
+		WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Password reset token not found")
+	}
+	return
+}
+
+// MarkPasswordResetTokenUsed implements PasswordResetTokenRepo.
+// Only an unused token matches, so a token can be redeemed exactly once even under concurrent requests.
+func (p *passwordResetTokenRepo) MarkPasswordResetTokenUsed(ctx context.Context, tokenID uuid.UUID) (used bool, error error) {
+	dbExecute := p.db.
+		WithContext(ctx).
+		Model(&entity.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", time.Now())
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// InvalidateUserPasswordResetTokens implements PasswordResetTokenRepo.
+func (p *passwordResetTokenRepo) InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	err := p.db.
+		WithContext(ctx).
+		Model(&entity.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
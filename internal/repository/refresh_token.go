@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepo interface {
+	CreateRefreshToken(ctx context.Context, token entity.RefreshToken) error
+	GetRefreshTokenByID(ctx context.Context, tokenID uuid.UUID) (resp entity.RefreshToken, error error)
+	RevokeRefreshToken(ctx context.Context, tokenID uuid.UUID, replacedBy *uuid.UUID) (revoked bool, error error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	RevokeOtherUserRefreshTokens(ctx context.Context, userID, keepFamilyID uuid.UUID) error
+}
+
+type refreshTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepo(db database.Database) RefreshTokenRepo {
+	return &refreshTokenRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateRefreshToken implements RefreshTokenRepo.
+func (r *refreshTokenRepo) CreateRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	err := r.db.
+		WithContext(ctx).
+		Create(&token).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetRefreshTokenByID implements RefreshTokenRepo.
+func (r *refreshTokenRepo) GetRefreshTokenByID(ctx context.Context, tokenID uuid.UUID) (resp entity.RefreshToken, error error) {
+	err := r.db.
+		WithContext(ctx).
+		Where("id = ?", tokenID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Refresh token not found")
+	}
+	return
+}
+
+// RevokeRefreshToken implements RefreshTokenRepo.
+// The update only matches a token that is still active, so when two requests
+// race to rotate the same token exactly one of them sees revoked == true.
+func (r *refreshTokenRepo) RevokeRefreshToken(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	replacedBy *uuid.UUID,
+) (revoked bool, error error) {
+	dbExecute := r.db.
+		WithContext(ctx).
+		Model(&entity.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", tokenID).
+		Updates(map[string]interface{}{
+			"revoked_at":  time.Now(),
+			"replaced_by": replacedBy,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// RevokeRefreshTokenFamily implements RefreshTokenRepo.
+// The session the family belongs to is revoked with it, which also rejects its access tokens.
+func (r *refreshTokenRepo) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.
+			Model(&entity.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		err = tx.
+			Model(&entity.Session{}).
+			Where("id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// RevokeUserRefreshTokens implements RefreshTokenRepo.
+// Every session of the user is revoked with the tokens.
+func (r *refreshTokenRepo) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.revokeUserRefreshTokens(ctx, userID, uuid.Nil)
+}
+
+// RevokeOtherUserRefreshTokens implements RefreshTokenRepo.
+// It revokes every session of the user except keepFamilyID, which is the one making the request.
+func (r *refreshTokenRepo) RevokeOtherUserRefreshTokens(ctx context.Context, userID, keepFamilyID uuid.UUID) error {
+	return r.revokeUserRefreshTokens(ctx, userID, keepFamilyID)
+}
+
+func (r *refreshTokenRepo) revokeUserRefreshTokens(ctx context.Context, userID, keepFamilyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.
+			Model(&entity.RefreshToken{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		err = tx.
+			Model(&entity.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		return nil
+	})
+}
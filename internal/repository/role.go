@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+type RoleRepo interface {
+	GetRoleByName(ctx context.Context, name string) (resp entity.Role, error error)
+	GetRolesByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.Role, error error)
+	AssignRole(ctx context.Context, userID uuid.UUID, roleName string) error
+	SeedRoles(ctx context.Context, rolePermissions map[string][]string) error
+}
+
+type roleRepo struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db database.Database) RoleRepo {
+	return &roleRepo{
+		db: db.GetDB(),
+	}
+}
+
+// SeedDefaultRoles makes sure the built-in roles and permissions exist when the app starts
+func SeedDefaultRoles(lc fx.Lifecycle, roleRepo RoleRepo) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return roleRepo.SeedRoles(ctx, constant.DefaultRolePermissions)
+		},
+	})
+}
+
+// GetRoleByName implements RoleRepo.
+func (r *roleRepo) GetRoleByName(ctx context.Context, name string) (resp entity.Role, error error) {
+	err := r.db.
+		WithContext(ctx).
+		Preload("Permissions").
+		Where("name = ?", name).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Role not found")
+	}
+	return
+}
+
+// GetRolesByUserID implements RoleRepo.
+func (r *roleRepo) GetRolesByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.Role, error error) {
+	err := r.db.
+		WithContext(ctx).
+		Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// AssignRole implements RoleRepo.
+func (r *roleRepo) AssignRole(ctx context.Context, userID uuid.UUID, roleName string) error {
+	role, err := r.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	err = r.db.
+		WithContext(ctx).
+		Model(&entity.User{ID: userID}).
+		Association("Roles").
+		Append(&role)
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// SeedRoles implements RoleRepo.
+// Missing roles and permissions are created and missing grants are added; nothing is removed.
+// Users that have no role at all are given the default user role so existing accounts keep working.
+func (r *roleRepo) SeedRoles(ctx context.Context, rolePermissions map[string][]string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for roleName, permissionNames := range rolePermissions {
+			role := entity.Role{Name: roleName}
+			if err := tx.Where("name = ?", roleName).FirstOrCreate(&role).Error; err != nil {
+				return errors.NewInternalServerError("Database error: " + err.Error())
+			}
+
+			permissions := make([]entity.Permission, 0, len(permissionNames))
+			for _, permissionName := range permissionNames {
+				permission := entity.Permission{Name: permissionName}
+				if err := tx.Where("name = ?", permissionName).FirstOrCreate(&permission).Error; err != nil {
+					return errors.NewInternalServerError("Database error: " + err.Error())
+				}
+				permissions = append(permissions, permission)
+			}
+
+			if len(permissions) == 0 {
+				continue
+			}
+			if err := tx.Model(&role).Association("Permissions").Append(&permissions); err != nil {
+				return errors.NewInternalServerError("Database error: " + err.Error())
+			}
+		}
+
+		err := tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT users.id, roles.id FROM users, roles
+			WHERE roles.name = ?
+			AND NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)`,
+			constant.RoleUser,
+		).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		return nil
+	})
+}
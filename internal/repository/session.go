@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepo interface {
+	CreateSession(ctx context.Context, session entity.Session) error
+	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (resp entity.Session, error error)
+	GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.Session, error error)
+	GetSessionsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.Session, error error)
+	RotateSession(ctx context.Context, sessionID uuid.UUID, ipAddress, userAgent string, expiresAt time.Time) error
+	TouchSession(ctx context.Context, sessionID uuid.UUID, seenBefore time.Time) error
+	SetSessionOrganization(ctx context.Context, sessionID uuid.UUID, organizationID *uuid.UUID) error
+}
+
+type sessionRepo struct {
+	db *gorm.DB
+}
+
+func NewSessionRepo(db database.Database) SessionRepo {
+	return &sessionRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateSession implements SessionRepo.
+func (s *sessionRepo) CreateSession(ctx context.Context, session entity.Session) error {
+	err := s.db.
+		WithContext(ctx).
+		Create(&session).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetSessionByID implements SessionRepo.
+func (s *sessionRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (resp entity.Session, error error) {
+	err := s.db.
+		WithContext(ctx).
+		Where("id = ?", sessionID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("Session not found")
+	}
+	return
+}
+
+// GetActiveSessionsByUserID implements SessionRepo.
+// Sessions that were revoked or whose refresh token has expired are left out, most recently used first.
+func (s *sessionRepo) GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.Session, error error) {
+	err := s.db.
+		WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetSessionsByUserID implements SessionRepo.
+// Unlike GetActiveSessionsByUserID it includes revoked and expired sessions, newest first.
+func (s *sessionRepo) GetSessionsByUserID(ctx context.Context, userID uuid.UUID) (resp []entity.Session, error error) {
+	err := s.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// RotateSession implements SessionRepo.
+// It is called when the session's refresh token is rotated and records where the client is now.
+func (s *sessionRepo) RotateSession(
+	ctx context.Context,
+	sessionID uuid.UUID,
+	ipAddress, userAgent string,
+	expiresAt time.Time,
+) error {
+	err := s.db.
+		WithContext(ctx).
+		Model(&entity.Session{}).
+		Where("id = ?", sessionID).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"user_agent":   userAgent,
+			"last_seen_at": time.Now(),
+			"expires_at":   expiresAt,
+		}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// TouchSession implements SessionRepo.
+// The timestamp is only written when the stored one is older than seenBefore, so an active
+// session does not cause a write on every request.
+func (s *sessionRepo) TouchSession(ctx context.Context, sessionID uuid.UUID, seenBefore time.Time) error {
+	err := s.db.
+		WithContext(ctx).
+		Model(&entity.Session{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, seenBefore).
+		UpdateColumn("last_seen_at", time.Now()).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// SetSessionOrganization implements SessionRepo.
+// Access tokens issued for the session from now on carry the organization.
+func (s *sessionRepo) SetSessionOrganization(ctx context.Context, sessionID uuid.UUID, organizationID *uuid.UUID) error {
+	err := s.db.
+		WithContext(ctx).
+		Model(&entity.Session{}).
+		Where("id = ?", sessionID).
+		UpdateColumn("organization_id", organizationID).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
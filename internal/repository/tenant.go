@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/util"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tenantColumn is the column holding the organization of a tenant scoped row
+const tenantColumn = "organization_id"
+
+type allTenantsKey struct{}
+
+var (
+	errNoTenant    = fmt.Errorf("query on a tenant scoped table without a tenant")
+	errOtherTenant = fmt.Errorf("row belongs to another tenant")
+)
+
+// AllTenants returns a context whose queries are not restricted to a tenant.
+// It is for queries that span organizations on purpose, such as listing the memberships of a user.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// RegisterTenantScope installs callbacks that restrict every query on an entity.TenantScoped table to the tenant
+// in the query's context (util.TenantIDFromCTX), and stamp that tenant on the rows created.
+// A query on such a table without a tenant fails instead of returning every tenant's rows, unless it runs with AllTenants.
+func RegisterTenantScope(db database.Database) error {
+	callbacks := db.GetDB().Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeToTenant); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenant:row", scopeToTenant)
+}
+
+// tenantOf returns the tenant the statement must be restricted to, and false when it needs no restriction
+func tenantOf(db *gorm.DB) (uuid.UUID, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return uuid.Nil, false
+	}
+	if _, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(entity.TenantScoped); !ok {
+		return uuid.Nil, false
+	}
+	if allTenants, _ := db.Statement.Context.Value(allTenantsKey{}).(bool); allTenants {
+		return uuid.Nil, false
+	}
+
+	tenantID := util.TenantIDFromCTX(db.Statement.Context)
+	if tenantID == uuid.Nil {
+		_ = db.AddError(errNoTenant)
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
+
+// scopeToTenant adds the tenant condition to queries, updates and deletes
+func scopeToTenant(db *gorm.DB) {
+	tenantID, ok := tenantOf(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: tenantColumn}, Value: tenantID},
+	}})
+}
+
+// assignTenant sets the tenant on created rows that have none and refuses rows of another tenant
+func assignTenant(db *gorm.DB) {
+	tenantID, ok := tenantOf(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantColumn)
+	if field == nil {
+		_ = db.AddError(fmt.Errorf("%s has no %s column", db.Statement.Schema.Name, tenantColumn))
+		return
+	}
+
+	assign := func(row reflect.Value) {
+		value, zero := field.ValueOf(db.Statement.Context, row)
+		if zero {
+			if err := field.Set(db.Statement.Context, row, tenantID); err != nil {
+				_ = db.AddError(err)
+			}
+			return
+		}
+		if value != tenantID {
+			_ = db.AddError(errOtherTenant)
+		}
+	}
+
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			assign(reflect.Indirect(db.Statement.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		assign(db.Statement.ReflectValue)
+	}
+}
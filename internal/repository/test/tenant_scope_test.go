@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/util"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDatabase builds statements without running them, so the SQL a query would send can be inspected
+type dryRunDatabase struct {
+	db *gorm.DB
+}
+
+func (d dryRunDatabase) GetDB() *gorm.DB                           { return d.db }
+func (d dryRunDatabase) BeginTransaction() (*gorm.DB, error)       { return d.db, nil }
+func (d dryRunDatabase) ReleaseTransaction(tx *gorm.DB, err error) {}
+func (d dryRunDatabase) CommitTransaction(tx *gorm.DB) error       { return nil }
+func (d dryRunDatabase) RollbackTransaction(tx *gorm.DB) error     { return nil }
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, repository.RegisterTenantScope(dryRunDatabase{db: db}))
+	return db
+}
+
+// TestTenantScope tests that queries on tenant scoped tables only see the tenant of their context
+func TestTenantScope(t *testing.T) {
+	t.Parallel()
+
+	db := newDryRunDB(t)
+	tenantID := uuid.New()
+	ctx := util.WithTenant(context.Background(), tenantID)
+	tenantCondition := `"organization_members"."organization_id" = `
+
+	t.Run("queries are restricted to the tenant", func(t *testing.T) {
+		var members []entity.OrganizationMember
+		result := db.WithContext(ctx).Where("role = ?", "owner").Find(&members)
+		require.NoError(t, result.Error)
+		assert.Contains(t, result.Statement.SQL.String(), tenantCondition)
+		assert.Contains(t, result.Statement.Vars, tenantID)
+
+		var count int64
+		result = db.WithContext(ctx).Model(&entity.OrganizationMember{}).Count(&count)
+		require.NoError(t, result.Error)
+		assert.Contains(t, result.Statement.SQL.String(), tenantCondition)
+	})
+
+	t.Run("updates and deletes are restricted to the tenant", func(t *testing.T) {
+		result := db.WithContext(ctx).
+			Model(&entity.OrganizationMember{}).
+			Where("user_id = ?", uuid.New()).
+			Updates(map[string]interface{}{"role": "admin"})
+		require.NoError(t, result.Error)
+		assert.Contains(t, result.Statement.SQL.String(), tenantCondition)
+
+		result = db.WithContext(ctx).Unscoped().Where("user_id = ?", uuid.New()).Delete(&entity.OrganizationMember{})
+		require.NoError(t, result.Error)
+		assert.Contains(t, result.Statement.SQL.String(), tenantCondition)
+	})
+
+	t.Run("queries without a tenant fail", func(t *testing.T) {
+		var members []entity.OrganizationMember
+		err := db.WithContext(context.Background()).Find(&members).Error
+		assert.Error(t, err)
+
+		err = db.WithContext(context.Background()).Where("user_id = ?", uuid.New()).Delete(&entity.OrganizationMember{}).Error
+		assert.Error(t, err)
+	})
+
+	t.Run("all tenants queries are not restricted", func(t *testing.T) {
+		var members []entity.OrganizationMember
+		result := db.WithContext(repository.AllTenants(ctx)).Find(&members)
+		require.NoError(t, result.Error)
+		assert.NotContains(t, result.Statement.SQL.String(), "organization_id")
+	})
+
+	t.Run("created rows get the tenant", func(t *testing.T) {
+		member := entity.OrganizationMember{UserID: uuid.New(), Role: "member"}
+		err := db.WithContext(ctx).Omit("Organization", "User").Create(&member).Error
+		require.NoError(t, err)
+		assert.Equal(t, tenantID, member.OrganizationID)
+	})
+
+	t.Run("rows of another tenant can't be created", func(t *testing.T) {
+		member := entity.OrganizationMember{OrganizationID: uuid.New(), UserID: uuid.New(), Role: "member"}
+		err := db.WithContext(ctx).Omit("Organization", "User").Create(&member).Error
+		assert.Error(t, err)
+	})
+
+	t.Run("invitations are restricted to the tenant", func(t *testing.T) {
+		var invitations []entity.OrganizationInvitation
+		result := db.WithContext(ctx).Find(&invitations)
+		require.NoError(t, result.Error)
+		assert.Contains(t, result.Statement.SQL.String(), `"organization_invitations"."organization_id" = `)
+	})
+
+	t.Run("other tables are not scoped", func(t *testing.T) {
+		var sessions []entity.Session
+		result := db.WithContext(context.Background()).Find(&sessions)
+		require.NoError(t, result.Error)
+		assert.NotContains(t, result.Statement.SQL.String(), "organization_id")
+	})
+}
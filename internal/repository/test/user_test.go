@@ -0,0 +1,30 @@
+package repository_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestUserRepo_VerifyUserEmail tests that soft-deleted users count when checking whether an email is taken,
+// as the unique index on the email column includes them
+func TestUserRepo_VerifyUserEmail(t *testing.T) {
+	t.Parallel()
+
+	db := newDryRunDB(t)
+	var statements []string
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}))
+
+	userRepo := repository.NewUserRepo(dryRunDatabase{db: db})
+	require.NoError(t, userRepo.VerifyUserEmail(context.Background(), "test@example.com"))
+
+	require.Len(t, statements, 1)
+	assert.Contains(t, statements[0], "email = $1")
+	assert.NotContains(t, statements[0], "deleted_at")
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	RevocationStoreMemory   = "memory"
+	RevocationStorePostgres = "postgres"
+)
+
+// TokenRevocationStore keeps the IDs of access tokens that must be rejected before they expire
+type TokenRevocationStore interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	PruneExpired(ctx context.Context) error
+}
+
+// NewTokenRevocationStore picks the store configured by JWT_REVOCATION_STORE and
+// prunes expired entries from it in the background while the app is running.
+func NewTokenRevocationStore(lc fx.Lifecycle, db database.Database, config *config.Config) TokenRevocationStore {
+	var store TokenRevocationStore
+	switch config.JWT.RevocationStore {
+	case RevocationStoreMemory:
+		store = NewMemoryTokenRevocationStore()
+	default:
+		store = NewPostgresTokenRevocationStore(db)
+	}
+
+	interval := config.JWT.RevocationPruneInterval
+	if interval <= 0 {
+		return store
+	}
+
+	stop := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go pruneRevokedTokens(store, interval, stop)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(stop)
+			return nil
+		},
+	})
+
+	return store
+}
+
+func pruneRevokedTokens(store TokenRevocationStore, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := store.PruneExpired(context.Background()); err != nil {
+				logger.WithError(err).Error("Failed to prune revoked tokens")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+type memoryTokenRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryTokenRevocationStore creates a process-local store, suitable for a single instance or tests
+func NewMemoryTokenRevocationStore() TokenRevocationStore {
+	return &memoryTokenRevocationStore{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Revoke implements TokenRevocationStore.
+func (m *memoryTokenRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revoked[tokenID] = expiresAt
+	return nil
+}
+
+// IsRevoked implements TokenRevocationStore.
+func (m *memoryTokenRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	expiresAt, ok := m.revoked[tokenID]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// PruneExpired implements TokenRevocationStore.
+func (m *memoryTokenRevocationStore) PruneExpired(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for tokenID, expiresAt := range m.revoked {
+		if !now.Before(expiresAt) {
+			delete(m.revoked, tokenID)
+		}
+	}
+	return nil
+}
+
+type postgresTokenRevocationStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresTokenRevocationStore creates a store shared by every instance using the same database
+func NewPostgresTokenRevocationStore(db database.Database) TokenRevocationStore {
+	return &postgresTokenRevocationStore{
+		db: db.GetDB(),
+	}
+}
+
+// Revoke implements TokenRevocationStore.
+func (p *postgresTokenRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	err := p.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.RevokedToken{
+			ID:        tokenID,
+			ExpiresAt: expiresAt,
+		}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// IsRevoked implements TokenRevocationStore.
+func (p *postgresTokenRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	err := p.db.
+		WithContext(ctx).
+		Model(&entity.RevokedToken{}).
+		Where("id = ? AND expires_at > ?", tokenID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return count > 0, nil
+}
+
+// PruneExpired implements TokenRevocationStore.
+func (p *postgresTokenRevocationStore) PruneExpired(ctx context.Context) error {
+	err := p.db.
+		WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&entity.RevokedToken{}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
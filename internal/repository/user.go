@@ -3,24 +3,46 @@ package repository
 import (
 	"context"
 	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
+	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/pkg/database"
 	"ienergy-template-go/pkg/errors"
+	"strings"
+	"time"
 
 	logger "github.com/sirupsen/logrus"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepo interface {
 	GetUserByID(ctx context.Context, userID uuid.UUID) (resp entity.User, error error)
+	GetUserByIDWithDeleted(ctx context.Context, userID uuid.UUID) (resp entity.User, error error)
 	GetUserByEmail(ctx context.Context, email string) (resp entity.User, error error)
 	UserRegister(ctx context.Context, userInfo entity.User) (resp entity.User, error error)
 	ValidateUser(userInfo entity.User) (userID uuid.UUID, error error)
 	UpdateUser(ctx context.Context, userInfo entity.User) error
+	UpdateUserProfile(ctx context.Context, userInfo entity.User) error
 	DeleteUser(ctx context.Context, userInfo entity.User) error
+	RestoreUser(ctx context.Context, userID uuid.UUID, restoredBy string) (restored bool, error error)
+	GetUsers(ctx context.Context, filter request.UserFilterRequest) (resp []entity.User, total int64, error error)
+	GetUsersInBatches(
+		ctx context.Context,
+		filter request.UserFilterRequest,
+		batchSize int,
+		fn func(users []entity.User) error,
+	) error
 	VerifyUserEmail(ctx context.Context, email string) error
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) (verified bool, error error)
+	ClaimUnverifiedAccount(ctx context.Context, userID uuid.UUID, email string) (claimed bool, error error)
+	MarkEmailVerificationSent(ctx context.Context, userID uuid.UUID, sentBefore time.Time) (marked bool, error error)
+	SetPendingEmail(ctx context.Context, userID uuid.UUID, email string) error
+	ConfirmPendingEmail(ctx context.Context, userID uuid.UUID, email string) (changed bool, error error)
+	UpdateUserState(ctx context.Context, userID uuid.UUID, from, to, updatedBy string) (changed bool, error error)
+	EraseUser(ctx context.Context, userID uuid.UUID, erasedBy string) (erased bool, error error)
 }
 
 type userRepo struct {
@@ -34,21 +56,168 @@ func NewUserRepo(db database.Database) UserRepo {
 }
 
 // DeleteUser implements IUserRepo.
+// The user is soft-deleted, recording userInfo.DeletedBy, and can be brought back with RestoreUser.
 func (u *userRepo) DeleteUser(ctx context.Context, userInfo entity.User) error {
-	err := u.db.Delete(&entity.User{
-		ID: userInfo.ID,
-	}).Error
-	if err != nil {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ?", userInfo.ID).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now().Unix(),
+			"deleted_by": userInfo.DeletedBy,
+		})
+	if dbExecute.Error != nil {
+		return errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	if dbExecute.RowsAffected == 0 {
 		return errors.NewNotFoundError("User not found")
 	}
 	return nil
 }
 
+// RestoreUser implements IUserRepo.
+// Erased users stay deleted.
+func (u *userRepo) RestoreUser(ctx context.Context, userID uuid.UUID, restoredBy string) (restored bool, error error) {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Unscoped().
+		Model(&entity.User{}).
+		Where("id = ? AND deleted_at <> 0 AND erased_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"deleted_at": 0,
+			"deleted_by": "",
+			"updated_by": restoredBy,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// GetUsers implements IUserRepo.
+// It returns one page of the users matching filter, with their roles, and how many match in total.
+func (u *userRepo) GetUsers(
+	ctx context.Context,
+	filter request.UserFilterRequest,
+) (resp []entity.User, total int64, error error) {
+	query := u.filterUsers(ctx, filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+
+	limit, offset := filter.GetOffsetAndLimit()
+	err := query.
+		Preload("Roles").
+		Order(userOrder(filter)).
+		Order("id").
+		Limit(limit).
+		Offset(offset).
+		Find(&resp).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return resp, total, nil
+}
+
+// GetUsersInBatches implements IUserRepo.
+// It passes every user matching filter, with their roles, to fn batchSize users at a time in the order
+// of the filter, so all of them can be processed without holding them in memory. Paging is ignored.
+// Each batch starts after the last user of the previous one on (sort column, id) rather than at an offset,
+// so users added or deleted meanwhile are neither skipped nor repeated and no batch rescans the ones before it.
+func (u *userRepo) GetUsersInBatches(
+	ctx context.Context,
+	filter request.UserFilterRequest,
+	batchSize int,
+	fn func(users []entity.User) error,
+) error {
+	query := u.filterUsers(ctx, filter)
+	order := userOrder(filter)
+	after := "(?, id) > (?, ?)"
+	if order.Desc {
+		after = "(?, id) < (?, ?)"
+	}
+
+	var last *entity.User
+	for {
+		batch := query
+		if last != nil {
+			batch = batch.Where(after, order.Column, userSortValue(*last, order.Column.Name), last.ID)
+		}
+		var users []entity.User
+		err := batch.
+			Preload("Roles").
+			Order(order).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: order.Desc}).
+			Limit(batchSize).
+			Find(&users).Error
+		if err != nil {
+			return errors.NewInternalServerError("Database error: " + err.Error())
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		if err := fn(users); err != nil {
+			return err
+		}
+		if len(users) < batchSize {
+			return nil
+		}
+		last = &users[len(users)-1]
+	}
+}
+
+// userSortValue returns the value of user in the column the users are ordered by, see userOrder
+func userSortValue(user entity.User, field string) interface{} {
+	switch field {
+	case "updated_at":
+		return user.UpdatedAt
+	case "email":
+		return user.Email
+	case "first_name":
+		return user.FirstName
+	case "last_name":
+		return user.LastName
+	default:
+		return user.CreatedAt
+	}
+}
+
+// filterUsers returns a query for the users matching filter, which can be reused for several statements
+func (u *userRepo) filterUsers(ctx context.Context, filter request.UserFilterRequest) *gorm.DB {
+	query := u.db.
+		WithContext(ctx).
+		Model(&entity.User{})
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at <> 0")
+	}
+	if name := strings.TrimSpace(filter.Names); name != "" {
+		pattern := "%" + escapeLike(name) + "%"
+		query = query.Where("(first_name || ' ' || last_name ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+	if filter.FromDate != 0 {
+		query = query.Where("created_at >= ?", time.Unix(filter.FromDate, 0))
+	}
+	if filter.ToDate != 0 {
+		query = query.Where("created_at <= ?", time.Unix(filter.ToDate, 0))
+	}
+	return query.Session(&gorm.Session{})
+}
+
+// userOrder is the order asked for by filter, newest users first by default
+func userOrder(filter request.UserFilterRequest) clause.OrderByColumn {
+	field, desc := "created_at", true
+	if filter.Sort != "" {
+		field, desc = filter.SortField()
+	}
+	return clause.OrderByColumn{Column: clause.Column{Name: field}, Desc: desc}
+}
+
 // GetUserByEmail implements IUserRepo.
 func (u *userRepo) GetUserByEmail(ctx context.Context, email string) (resp entity.User, error error) {
 	err := u.db.
+		WithContext(ctx).
 		Where("email = ?", email).
-		First(&resp).Error
+		Find(&resp).Error
 	if err != nil {
 		return resp, errors.NewInternalServerError("Database error: " + err.Error())
 	}
@@ -72,6 +241,23 @@ func (u *userRepo) GetUserByID(ctx context.Context, userID uuid.UUID) (resp enti
 	return
 }
 
+// GetUserByIDWithDeleted implements IUserRepo.
+// Unlike GetUserByID it also finds soft-deleted users.
+func (u *userRepo) GetUserByIDWithDeleted(ctx context.Context, userID uuid.UUID) (resp entity.User, error error) {
+	err := u.db.
+		WithContext(ctx).
+		Unscoped().
+		Where("id = ?", userID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("User not found")
+	}
+	return
+}
+
 // UpdateUser implements IUserRepo.
 func (u *userRepo) UpdateUser(ctx context.Context, userInfo entity.User) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userInfo.Password), bcrypt.DefaultCost)
@@ -86,6 +272,29 @@ func (u *userRepo) UpdateUser(ctx context.Context, userInfo entity.User) error {
 	return nil
 }
 
+// UpdateUserProfile implements IUserRepo.
+// Unlike UpdateUser it leaves the password alone, and it stores email_verified_at as given so a changed email can be unverified.
+func (u *userRepo) UpdateUserProfile(ctx context.Context, userInfo entity.User) error {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ?", userInfo.ID).
+		Updates(map[string]interface{}{
+			"first_name":        userInfo.FirstName,
+			"last_name":         userInfo.LastName,
+			"email":             userInfo.Email,
+			"email_verified_at": userInfo.EmailVerifiedAt,
+			"updated_by":        userInfo.UpdatedBy,
+		})
+	if dbExecute.Error != nil {
+		return errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	if dbExecute.RowsAffected == 0 {
+		return errors.NewNotFoundError("User not found")
+	}
+	return nil
+}
+
 // UserRegister implements IUserRepo.
 func (u *userRepo) UserRegister(ctx context.Context, userInfo entity.User) (resp entity.User, error error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userInfo.Password), bcrypt.DefaultCost)
@@ -124,10 +333,12 @@ func (u *userRepo) ValidateUser(userInfo entity.User) (userID uuid.UUID, error e
 }
 
 // VerifyUserEmail implements IUserRepo.
+// Soft-deleted users keep their address in the unique index until erased, so they count as well.
 func (u *userRepo) VerifyUserEmail(ctx context.Context, email string) error {
 	var resp []entity.User
 	err := u.db.
 		WithContext(ctx).
+		Unscoped().
 		Where("email = ?", email).
 		Find(&resp).Error
 	if err != nil {
@@ -141,3 +352,250 @@ func (u *userRepo) VerifyUserEmail(ctx context.Context, email string) error {
 	}
 	return nil
 }
+
+// MarkEmailVerified implements IUserRepo.
+// The email must still match so a token mailed before an address change cannot verify the new address.
+func (u *userRepo) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) (verified bool, error error) {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", userID, email).
+		Updates(map[string]interface{}{
+			"email_verified_at": time.Now(),
+			"state":             activatePendingState(),
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// ClaimUnverifiedAccount implements IUserRepo.
+// Like MarkEmailVerified it verifies an unverified email, for someone who proved control of the mailbox
+// without the password. Whoever registered the account may not own the address, so in the same transaction
+// the credentials they set are dropped: the password is cleared and the sessions, API keys and MFA factor
+// are revoked or deleted. The owner sets a password again through the reset flow.
+func (u *userRepo) ClaimUnverifiedAccount(ctx context.Context, userID uuid.UUID, email string) (claimed bool, err error) {
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		dbExecute := tx.
+			Model(&entity.User{}).
+			Where("id = ? AND email = ? AND email_verified_at IS NULL", userID, email).
+			Updates(map[string]interface{}{
+				"email_verified_at": now,
+				"state":             activatePendingState(),
+				"password":          "",
+			})
+		if dbExecute.Error != nil {
+			return dbExecute.Error
+		}
+		if dbExecute.RowsAffected != 1 {
+			return nil
+		}
+		claimed = true
+
+		revoked := []interface{}{
+			&entity.RefreshToken{},
+			&entity.Session{},
+			&entity.APIKey{},
+		}
+		for _, model := range revoked {
+			err := tx.
+				Model(model).
+				Where("user_id = ? AND revoked_at IS NULL", userID).
+				Update("revoked_at", now).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, model := range []interface{}{&entity.MFAFactor{}, &entity.MFARecoveryCode{}} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return claimed, nil
+}
+
+// MarkEmailVerificationSent implements IUserRepo.
+// The send time is only recorded when the previous email went out before sentBefore,
+// which lets concurrent resend requests agree on a single winner.
+func (u *userRepo) MarkEmailVerificationSent(
+	ctx context.Context,
+	userID uuid.UUID,
+	sentBefore time.Time,
+) (marked bool, error error) {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ? AND (email_verification_sent_at IS NULL OR email_verification_sent_at <= ?)", userID, sentBefore).
+		Update("email_verification_sent_at", time.Now())
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// escapeLike escapes the LIKE wildcards in value so it is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// SetPendingEmail implements IUserRepo.
+// An empty email cancels the pending change.
+func (u *userRepo) SetPendingEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	err := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ?", userID).
+		Update("pending_email", email).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// ConfirmPendingEmail implements IUserRepo.
+// The email replaces the current one, already verified, only while it is still the pending one,
+// so a link mailed for an earlier change request cannot be used.
+func (u *userRepo) ConfirmPendingEmail(ctx context.Context, userID uuid.UUID, email string) (changed bool, error error) {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ? AND pending_email = ?", userID, email).
+		Updates(map[string]interface{}{
+			"email":             email,
+			"pending_email":     "",
+			"email_verified_at": time.Now(),
+			"state":             activatePendingState(),
+			"updated_by":        userID.String(),
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// UpdateUserState implements IUserRepo.
+// The status only changes while it is still from, so concurrent changes can't skip a transition check.
+func (u *userRepo) UpdateUserState(
+	ctx context.Context,
+	userID uuid.UUID,
+	from, to, updatedBy string,
+) (changed bool, error error) {
+	dbExecute := u.db.
+		WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ? AND state = ?", userID, enum.EnumStateDB(from)).
+		Updates(map[string]interface{}{
+			"state":      enum.EnumStateDB(to),
+			"updated_by": updatedBy,
+		})
+	if dbExecute.Error != nil {
+		return false, errors.NewInternalServerError("Database error: " + dbExecute.Error.Error())
+	}
+	return dbExecute.RowsAffected == 1, nil
+}
+
+// activatePendingState is the state update for a verified email: a pending account becomes active
+func activatePendingState() clause.Expr {
+	return gorm.Expr(
+		"CASE WHEN state = ? THEN ? ELSE state END",
+		enum.EnumStateDB(enum.StatePending),
+		enum.EnumStateDB(enum.StateActive),
+	)
+}
+
+// EraseUser implements IUserRepo.
+// In one transaction the user row is anonymised and left soft-deleted, so audit records and other
+// references to the ID stay valid, while everything else the user owns is deleted: sessions, tokens,
+// MFA factors, API keys, linked identities, data exports, memberships, invitations and user import errors
+// for their email. Audit records of the user's own actions lose the IP address and user agent. It reports
+// false if the user doesn't exist or was already erased.
+func (u *userRepo) EraseUser(ctx context.Context, userID uuid.UUID, erasedBy string) (erased bool, err error) {
+	now := time.Now()
+	// The user's memberships and invitations are deleted in every organization
+	err = u.db.WithContext(AllTenants(ctx)).Transaction(func(tx *gorm.DB) error {
+		// Invitations are addressed by email, which is about to be overwritten
+		var user entity.User
+		if err := tx.Unscoped().Select("email").Where("id = ?", userID).Find(&user).Error; err != nil {
+			return err
+		}
+
+		dbExecute := tx.
+			Unscoped().
+			Model(&entity.User{}).
+			Where("id = ? AND erased_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"first_name":                 "",
+				"last_name":                  "",
+				"email":                      entity.ErasedEmail(userID),
+				"password":                   "",
+				"pending_email":              "",
+				"email_verified_at":          nil,
+				"email_verification_sent_at": nil,
+				"state":                      enum.EnumStateDB(enum.StateDeactivated),
+				"erased_at":                  now,
+				// created_by holds the email of users who registered themselves
+				"created_by": gorm.Expr("CASE WHEN created_by = email THEN ? ELSE created_by END", userID.String()),
+				"updated_by": erasedBy,
+				"deleted_at": gorm.Expr("CASE WHEN deleted_at = 0 THEN ? ELSE deleted_at END", now.Unix()),
+				"deleted_by": gorm.Expr("CASE WHEN deleted_at = 0 THEN ? ELSE deleted_by END", erasedBy),
+			})
+		if dbExecute.Error != nil {
+			return dbExecute.Error
+		}
+		if dbExecute.RowsAffected != 1 {
+			return nil
+		}
+		erased = true
+
+		owned := []interface{}{
+			&entity.Session{},
+			&entity.RefreshToken{},
+			&entity.PasswordResetToken{},
+			&entity.MagicLinkToken{},
+			&entity.MFAFactor{},
+			&entity.MFARecoveryCode{},
+			&entity.APIKey{},
+			&entity.UserIdentity{},
+			&entity.DataExport{},
+			&entity.OrganizationMember{},
+		}
+		for _, model := range owned {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.
+			Unscoped().
+			Where("email = ? OR accepted_by = ?", user.Email, userID).
+			Delete(&entity.OrganizationInvitation{}).Error
+		if err != nil {
+			return err
+		}
+
+		// Rows of user imports that were not imported keep the email they were for
+		err = tx.Where("email = ?", user.Email).Delete(&entity.UserImportError{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.
+			Model(&entity.AuditLog{}).
+			Where("actor_id = ?", userID).
+			Updates(map[string]interface{}{
+				"ip_address": "",
+				"user_agent": "",
+			}).Error
+	})
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return erased, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/pkg/database"
+	"ienergy-template-go/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserImportRepo interface {
+	CreateUserImport(ctx context.Context, userImport entity.UserImport) error
+	GetUserImports(ctx context.Context) (resp []entity.UserImport, error error)
+	GetUserImportByID(ctx context.Context, importID uuid.UUID) (resp entity.UserImport, error error)
+	GetPendingUserImports(ctx context.Context) (resp []entity.UserImport, error error)
+	CompleteUserImport(
+		ctx context.Context,
+		importID uuid.UUID,
+		createdRows int,
+		rowErrors []entity.UserImportError,
+	) (completed bool, error error)
+	FailUserImport(ctx context.Context, importID uuid.UUID) error
+}
+
+type userImportRepo struct {
+	db *gorm.DB
+}
+
+func NewUserImportRepo(db database.Database) UserImportRepo {
+	return &userImportRepo{
+		db: db.GetDB(),
+	}
+}
+
+// CreateUserImport implements UserImportRepo.
+func (u *userImportRepo) CreateUserImport(ctx context.Context, userImport entity.UserImport) error {
+	err := u.db.
+		WithContext(ctx).
+		Omit("Errors").
+		Create(&userImport).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
+
+// GetUserImports implements UserImportRepo.
+// Neither the CSV nor the row errors are loaded, newest imports come first.
+func (u *userImportRepo) GetUserImports(ctx context.Context) (resp []entity.UserImport, error error) {
+	err := u.db.
+		WithContext(ctx).
+		Omit("content").
+		Order("created_at DESC").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// GetUserImportByID implements UserImportRepo.
+// The row errors are loaded in the order of the CSV, the CSV itself is not.
+func (u *userImportRepo) GetUserImportByID(ctx context.Context, importID uuid.UUID) (resp entity.UserImport, error error) {
+	err := u.db.
+		WithContext(ctx).
+		Omit("content").
+		Preload("Errors", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line")
+		}).
+		Where("id = ?", importID).
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	if resp.ID == uuid.Nil {
+		return resp, errors.NewNotFoundError("User import not found")
+	}
+	return
+}
+
+// GetPendingUserImports implements UserImportRepo.
+// Oldest uploads come first.
+func (u *userImportRepo) GetPendingUserImports(ctx context.Context) (resp []entity.UserImport, error error) {
+	err := u.db.
+		WithContext(ctx).
+		Where("status = ?", entity.UserImportPending).
+		Order("created_at").
+		Find(&resp).Error
+	if err != nil {
+		return resp, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return
+}
+
+// CompleteUserImport implements UserImportRepo.
+// The counts and row errors are stored and the CSV cleared together. Only a pending import is completed,
+// so an import processed twice is reported once.
+func (u *userImportRepo) CompleteUserImport(
+	ctx context.Context,
+	importID uuid.UUID,
+	createdRows int,
+	rowErrors []entity.UserImportError,
+) (completed bool, err error) {
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dbExecute := tx.
+			Model(&entity.UserImport{}).
+			Where("id = ? AND status = ?", importID, entity.UserImportPending).
+			Updates(map[string]interface{}{
+				"status":       entity.UserImportCompleted,
+				"content":      nil,
+				"created_rows": createdRows,
+				"failed_rows":  len(rowErrors),
+				"completed_at": time.Now(),
+			})
+		if dbExecute.Error != nil {
+			return dbExecute.Error
+		}
+		if dbExecute.RowsAffected != 1 {
+			return nil
+		}
+		completed = true
+
+		if len(rowErrors) == 0 {
+			return nil
+		}
+		for i := range rowErrors {
+			rowErrors[i].ImportID = importID
+		}
+		return tx.CreateInBatches(rowErrors, 100).Error
+	})
+	if err != nil {
+		return false, errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return completed, nil
+}
+
+// FailUserImport implements UserImportRepo.
+// The CSV is cleared, it is not tried again.
+func (u *userImportRepo) FailUserImport(ctx context.Context, importID uuid.UUID) error {
+	err := u.db.
+		WithContext(ctx).
+		Model(&entity.UserImport{}).
+		Where("id = ? AND status = ?", importID, entity.UserImportPending).
+		Updates(map[string]interface{}{
+			"status":       entity.UserImportFailed,
+			"content":      nil,
+			"completed_at": time.Now(),
+		}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Database error: " + err.Error())
+	}
+	return nil
+}
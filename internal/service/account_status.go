@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// AccountStatusService defines the interface for moving accounts between statuses
+type AccountStatusService interface {
+	SuspendUser(ctx context.Context, userID uuid.UUID, req request.ChangeUserStateRequest) (response.AdminUserResponse, error)
+	ReactivateUser(ctx context.Context, userID uuid.UUID, req request.ChangeUserStateRequest) (response.AdminUserResponse, error)
+	DeactivateUser(ctx context.Context, userID uuid.UUID, req request.ChangeUserStateRequest) (response.AdminUserResponse, error)
+	IsAccountActive(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// accountStatusService implements AccountStatusService
+type accountStatusService struct {
+	userRepo         repository.UserRepo
+	roleRepo         repository.RoleRepo
+	refreshTokenRepo repository.RefreshTokenRepo
+	auditLogRepo     repository.AuditLogRepo
+	logger           *logger.StandardLogger
+}
+
+// NewAccountStatusService creates a new account status service
+func NewAccountStatusService(
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	refreshTokenRepo repository.RefreshTokenRepo,
+	auditLogRepo repository.AuditLogRepo,
+	logger *logger.StandardLogger,
+) AccountStatusService {
+	return &accountStatusService{
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		auditLogRepo:     auditLogRepo,
+		logger:           logger,
+	}
+}
+
+// SuspendUser blocks an account until it is reactivated
+func (s *accountStatusService) SuspendUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	req request.ChangeUserStateRequest,
+) (response.AdminUserResponse, error) {
+	return s.changeState(ctx, userID, enum.StateSuspended, constant.AuditActionUserSuspend, req.Reason)
+}
+
+// ReactivateUser lets a suspended or deactivated account log in again
+func (s *accountStatusService) ReactivateUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	req request.ChangeUserStateRequest,
+) (response.AdminUserResponse, error) {
+	return s.changeState(ctx, userID, enum.StateActive, constant.AuditActionUserReactivate, req.Reason)
+}
+
+// DeactivateUser closes an account, which only an admin can reactivate
+func (s *accountStatusService) DeactivateUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	req request.ChangeUserStateRequest,
+) (response.AdminUserResponse, error) {
+	return s.changeState(ctx, userID, enum.StateDeactivated, constant.AuditActionUserDeactivate, req.Reason)
+}
+
+// IsAccountActive implements util.AccountChecker. Deleted users are reported as inactive.
+func (s *accountStatusService) IsAccountActive(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.IsActive(), nil
+}
+
+// changeState moves the account to state after recording who did it and why.
+// Leaving the active state ends every session of the user.
+func (s *accountStatusService) changeState(
+	ctx context.Context,
+	userID uuid.UUID,
+	state string,
+	action string,
+	reason string,
+) (response.AdminUserResponse, error) {
+	adminID := util.UserIDFromCTX(ctx)
+	if adminID == uuid.Nil {
+		return response.AdminUserResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+	if userID == adminID {
+		return response.AdminUserResponse{}, errors.NewBadRequestError("You can't change the status of your own account")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	from := user.StateName()
+	if !user.CanTransitionTo(state) {
+		return response.AdminUserResponse{}, errors.NewConflictError(
+			fmt.Sprintf("Account is %s and can't become %s", strings.ToLower(from), strings.ToLower(state)),
+		)
+	}
+
+	// The status is not changed unless the audit record was stored
+	err = s.auditLogRepo.CreateAuditLog(ctx, entity.AuditLog{
+		ActorID:   adminID,
+		Action:    action,
+		TargetID:  user.ID,
+		Reason:    reason,
+		IPAddress: util.ClientIPFromCTX(ctx),
+		UserAgent: truncateString(util.UserAgentFromCTX(ctx), maxUserAgentLength),
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: adminID.String(),
+		},
+	})
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+
+	changed, err := s.userRepo.UpdateUserState(ctx, user.ID, from, state, adminID.String())
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	if !changed {
+		return response.AdminUserResponse{}, errors.NewConflictError("The account status has just changed, please try again")
+	}
+	user.State = enum.EnumStateDB(state)
+
+	if state != enum.StateActive {
+		if err := s.refreshTokenRepo.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+			s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to revoke sessions of inactive user")
+			return response.AdminUserResponse{}, err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+		"from":     from,
+		"to":       state,
+		"reason":   reason,
+	}).Warn("Account status changed")
+
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, user.ID)
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	return toAdminUserResponse(user, roles), nil
+}
+
+// checkAccountActive refuses to log in a user whose account is not active
+func checkAccountActive(user entity.User) error {
+	switch user.StateName() {
+	case enum.StateActive:
+		return nil
+	case enum.StateSuspended:
+		return errors.NewForbiddenError("Account is suspended")
+	case enum.StateDeactivated:
+		return errors.NewForbiddenError("Account is deactivated")
+	default:
+		return errors.NewForbiddenError("Account is not active")
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userExportBatchSize is how many users are read from the database at a time while exporting
+const userExportBatchSize = 500
+
+// userExportColumns is the header of the CSV user export
+var userExportColumns = []string{
+	"id", "email", "first_name", "last_name", "email_verified", "status", "roles", "created_at", "deleted_at",
+}
+
+// AdminUserService defines the interface for admins managing user accounts
+type AdminUserService interface {
+	ListUsers(ctx context.Context, req request.UserFilterRequest) (response.PaginatedResponse, error)
+	ExportUsers(ctx context.Context, req request.UserExportRequest, w io.Writer) error
+	GetUser(ctx context.Context, userID uuid.UUID) (response.AdminUserResponse, error)
+	UpdateUser(ctx context.Context, userID uuid.UUID, req request.AdminUpdateUserRequest) (response.AdminUserResponse, error)
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	RestoreUser(ctx context.Context, userID uuid.UUID) (response.AdminUserResponse, error)
+}
+
+// adminUserService implements AdminUserService
+type adminUserService struct {
+	userRepo         repository.UserRepo
+	roleRepo         repository.RoleRepo
+	refreshTokenRepo repository.RefreshTokenRepo
+	logger           *logger.StandardLogger
+}
+
+// NewAdminUserService creates a new admin user service
+func NewAdminUserService(
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	refreshTokenRepo repository.RefreshTokenRepo,
+	logger *logger.StandardLogger,
+) AdminUserService {
+	return &adminUserService{
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		logger:           logger,
+	}
+}
+
+// ListUsers returns one page of the users matching the filter
+func (s *adminUserService) ListUsers(
+	ctx context.Context,
+	req request.UserFilterRequest,
+) (response.PaginatedResponse, error) {
+	users, total, err := s.userRepo.GetUsers(ctx, req)
+	if err != nil {
+		return response.PaginatedResponse{}, err
+	}
+
+	items := make([]response.AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		items = append(items, toAdminUserResponse(user, user.Roles))
+	}
+	limit, offset := req.GetOffsetAndLimit()
+	return response.NewPaginatedResponse(items, limit, offset, total), nil
+}
+
+// ExportUsers writes every user matching the filter to w, as CSV or as a JSON array of AdminUserResponse,
+// oldest first unless sorted otherwise. Users are read and written a batch at a time, so nothing is written
+// before the first batch is read; after that an error can only cut the output short.
+func (s *adminUserService) ExportUsers(ctx context.Context, req request.UserExportRequest, w io.Writer) error {
+	filter := req.UserFilterRequest
+	if filter.Sort == "" {
+		// Users registering during the export then come after the batches still to be read
+		filter.Sort = "created_at"
+	}
+
+	buf := bufio.NewWriter(w)
+	var err error
+	if req.Format == constant.UserExportFormatJSON {
+		err = s.exportUsersJSON(ctx, filter, buf)
+	} else {
+		err = s.exportUsersCSV(ctx, filter, buf)
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("User export failed")
+		return err
+	}
+	s.logger.
+		WithContext(ctx).
+		WithField("admin_id", util.UserIDFromCTX(ctx)).
+		WithField("format", req.Format).
+		Info("Users exported")
+	return nil
+}
+
+func (s *adminUserService) exportUsersCSV(ctx context.Context, filter request.UserFilterRequest, buf *bufio.Writer) error {
+	writer := csv.NewWriter(buf)
+	if err := writer.Write(userExportColumns); err != nil {
+		return err
+	}
+	err := s.userRepo.GetUsersInBatches(ctx, filter, userExportBatchSize, func(users []entity.User) error {
+		for _, user := range users {
+			resp := toAdminUserResponse(user, user.Roles)
+			err := writer.Write([]string{
+				resp.ID.String(),
+				csvCell(resp.Email),
+				csvCell(resp.FirstName),
+				csvCell(resp.LastName),
+				strconv.FormatBool(resp.EmailVerified),
+				resp.Status,
+				strings.Join(resp.Roles, ";"),
+				formatExportTime(resp.CreatedAt),
+				formatExportTime(resp.DeletedAt),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		return buf.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *adminUserService) exportUsersJSON(ctx context.Context, filter request.UserFilterRequest, buf *bufio.Writer) error {
+	if _, err := buf.WriteString("["); err != nil {
+		return err
+	}
+	first := true
+	err := s.userRepo.GetUsersInBatches(ctx, filter, userExportBatchSize, func(users []entity.User) error {
+		for _, user := range users {
+			item, err := json.Marshal(toAdminUserResponse(user, user.Roles))
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := buf.WriteString(","); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := buf.Write(item); err != nil {
+				return err
+			}
+		}
+		return buf.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteString("]\n")
+	return err
+}
+
+// csvCell keeps spreadsheet programs from running a user-supplied value as a formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// GetUser returns a user with their roles
+func (s *adminUserService) GetUser(ctx context.Context, userID uuid.UUID) (response.AdminUserResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	return s.withRoles(ctx, user)
+}
+
+// UpdateUser changes the fields set in req. A new email address has to be verified again.
+func (s *adminUserService) UpdateUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	req request.AdminUpdateUserRequest,
+) (response.AdminUserResponse, error) {
+	adminID := util.UserIDFromCTX(ctx)
+	if adminID == uuid.Nil {
+		return response.AdminUserResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		user.LastName = *req.LastName
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		if err := s.userRepo.VerifyUserEmail(ctx, *req.Email); err != nil {
+			return response.AdminUserResponse{}, err
+		}
+		user.Email = *req.Email
+		user.EmailVerifiedAt = nil
+	}
+	user.UpdatedBy = adminID.String()
+
+	if err := s.userRepo.UpdateUserProfile(ctx, user); err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	s.logger.
+		WithContext(ctx).
+		WithField("admin_id", adminID).
+		WithField("user_id", user.ID).
+		Info("User updated by admin")
+	return s.withRoles(ctx, user)
+}
+
+// DeleteUser soft-deletes a user and logs them out everywhere
+func (s *adminUserService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	adminID := util.UserIDFromCTX(ctx)
+	if adminID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+	if userID == adminID {
+		return errors.NewBadRequestError("You can't delete your own account")
+	}
+
+	err := s.userRepo.DeleteUser(ctx, entity.User{
+		ID: userID,
+		BaseEntity: entity.BaseEntity{
+			DeletedBy: adminID.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	// Revoking the sessions also stops the user's access tokens, which carry the session ID
+	if err := s.refreshTokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		s.logger.WithField("user_id", userID).WithError(err).Error("Failed to revoke sessions of deleted user")
+		return err
+	}
+	s.logger.
+		WithContext(ctx).
+		WithField("admin_id", adminID).
+		WithField("user_id", userID).
+		Info("User deleted by admin")
+	return nil
+}
+
+// RestoreUser brings back a soft-deleted user
+func (s *adminUserService) RestoreUser(ctx context.Context, userID uuid.UUID) (response.AdminUserResponse, error) {
+	adminID := util.UserIDFromCTX(ctx)
+	if adminID == uuid.Nil {
+		return response.AdminUserResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	restored, err := s.userRepo.RestoreUser(ctx, userID, adminID.String())
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	if !restored {
+		return response.AdminUserResponse{}, errors.NewNotFoundError("Deleted user not found")
+	}
+	s.logger.
+		WithContext(ctx).
+		WithField("admin_id", adminID).
+		WithField("user_id", userID).
+		Info("User restored by admin")
+	return s.GetUser(ctx, userID)
+}
+
+func (s *adminUserService) withRoles(ctx context.Context, user entity.User) (response.AdminUserResponse, error) {
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, user.ID)
+	if err != nil {
+		return response.AdminUserResponse{}, err
+	}
+	return toAdminUserResponse(user, roles), nil
+}
+
+func toAdminUserResponse(user entity.User, roles []entity.Role) response.AdminUserResponse {
+	resp := response.AdminUserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		EmailVerified: user.IsEmailVerified(),
+		Status:        user.StateName(),
+		Roles:         entity.RoleNames(roles),
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		DeletedBy:     user.DeletedBy,
+		ErasedAt:      user.ErasedAt,
+	}
+	if user.DeletedAt != 0 {
+		deletedAt := time.Unix(int64(user.DeletedAt), 0)
+		resp.DeletedAt = &deletedAt
+	}
+	return resp
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyMarker starts every key so leaked keys are easy to recognise and scan for
+	apiKeyMarker = "iek_"
+	// apiKeyPrefixBytes gives an 8 character public prefix used to look the key up
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+	// apiKeyLastUsedResolution limits how often last_used_at is written for a busy key
+	apiKeyLastUsedResolution = time.Minute
+)
+
+// APIKeyService defines the interface for managing and authenticating personal API keys
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req request.CreateAPIKeyRequest) (response.CreatedAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context) ([]response.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, keyID uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (util.APIKeyPrincipal, error)
+}
+
+// apiKeyService implements APIKeyService
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepo
+	userRepo   repository.UserRepo
+	roleRepo   repository.RoleRepo
+	logger     *logger.StandardLogger
+	config     *config.Config
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepo,
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+		logger:     logger,
+		config:     config,
+	}
+}
+
+// CreateAPIKey creates a key for the caller. Scopes must be permissions the caller currently holds.
+func (s *apiKeyService) CreateAPIKey(
+	ctx context.Context,
+	req request.CreateAPIKeyRequest,
+) (response.CreatedAPIKeyResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.CreatedAPIKeyResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, userID)
+	if err != nil {
+		return response.CreatedAPIKeyResponse{}, err
+	}
+	granted := make(map[string]bool)
+	for _, permission := range entity.PermissionNames(roles) {
+		granted[permission] = true
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !granted[scope] {
+			return response.CreatedAPIKeyResponse{}, errors.NewForbiddenError("Scope not granted to user: " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	prefix, err := util.GenerateSecureToken(apiKeyPrefixBytes)
+	if err != nil {
+		return response.CreatedAPIKeyResponse{}, errors.NewInternalServerError("Failed to generate API key")
+	}
+	secret, err := util.GenerateSecureToken(apiKeySecretBytes)
+	if err != nil {
+		return response.CreatedAPIKeyResponse{}, errors.NewInternalServerError("Failed to generate API key")
+	}
+
+	key := entity.APIKey{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: util.HashToken(secret),
+		Scopes:     strings.Join(scopes, " "),
+		ExpiresAt:  req.ExpiresAt,
+	}
+	now := time.Now()
+	key.CreatedAt = &now
+	key.CreatedBy = userID.String()
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return response.CreatedAPIKeyResponse{}, err
+	}
+
+	return response.CreatedAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(key),
+		Key:            apiKeyMarker + prefix + "_" + secret,
+	}, nil
+}
+
+// ListAPIKeys returns the caller's keys that have not been revoked, without their secrets
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]response.APIKeyResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return nil, errors.NewBadRequestError("User ID is not found")
+	}
+
+	keys, err := s.apiKeyRepo.GetAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]response.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, toAPIKeyResponse(key))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey revokes one of the caller's keys; it stops working immediately
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+
+	revoked, err := s.apiKeyRepo.RevokeAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.NewNotFoundError("API key not found")
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves a presented key to its owner.
+// The key only carries the scopes that the owner still holds, so removing a role also narrows their keys.
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (util.APIKeyPrincipal, error) {
+	prefix, secret, ok := parseAPIKey(key)
+	if !ok {
+		return util.APIKeyPrincipal{}, errors.NewUnauthorizedError("Invalid API key")
+	}
+
+	stored, err := s.apiKeyRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if isNotFound(err) {
+		return util.APIKeyPrincipal{}, errors.NewUnauthorizedError("Invalid API key")
+	}
+	if err != nil {
+		return util.APIKeyPrincipal{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(secret)), []byte(stored.SecretHash)) != 1 {
+		return util.APIKeyPrincipal{}, errors.NewUnauthorizedError("Invalid API key")
+	}
+	now := time.Now()
+	if !stored.IsActive(now) {
+		return util.APIKeyPrincipal{}, errors.NewUnauthorizedError("API key is revoked or expired")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, stored.UserID)
+	if isNotFound(err) {
+		return util.APIKeyPrincipal{}, errors.NewUnauthorizedError("Invalid API key")
+	}
+	if err != nil {
+		return util.APIKeyPrincipal{}, err
+	}
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, user.ID)
+	if err != nil {
+		return util.APIKeyPrincipal{}, err
+	}
+	granted := make(map[string]bool)
+	for _, permission := range entity.PermissionNames(roles) {
+		granted[permission] = true
+	}
+	permissions := make([]string, 0)
+	for _, scope := range stored.ScopeList() {
+		if granted[scope] {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKey(ctx, stored.ID, now.Add(-apiKeyLastUsedResolution)); err != nil {
+		s.logger.
+			WithContext(ctx).
+			WithError(err).
+			WithField("api_key_id", stored.ID).
+			Warn("Failed to record API key usage")
+	}
+
+	return util.APIKeyPrincipal{
+		KeyID:       stored.ID,
+		UserID:      user.ID,
+		Email:       user.Email,
+		Roles:       entity.RoleNames(roles),
+		Permissions: permissions,
+	}, nil
+}
+
+// parseAPIKey splits "iek_<prefix>_<secret>"; the prefix has a fixed length because both parts may contain "_"
+func parseAPIKey(key string) (prefix, secret string, ok bool) {
+	rest, found := strings.CutPrefix(key, apiKeyMarker)
+	prefixLen := base64.RawURLEncoding.EncodedLen(apiKeyPrefixBytes)
+	if !found || len(rest) < prefixLen+2 || rest[prefixLen] != '_' {
+		return "", "", false
+	}
+	return rest[:prefixLen], rest[prefixLen+1:], true
+}
+
+func toAPIKeyResponse(key entity.APIKey) response.APIKeyResponse {
+	return response.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     apiKeyMarker + key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -5,16 +5,17 @@ import (
 	"fmt"
 	"ienergy-template-go/config"
 	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
 	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/model/response"
 	"ienergy-template-go/internal/repository"
 	"ienergy-template-go/pkg/constant"
 	"ienergy-template-go/pkg/errors"
 	"ienergy-template-go/pkg/logger"
-	"strconv"
-	"time"
+	"ienergy-template-go/pkg/password"
+	"ienergy-template-go/pkg/util"
+	"net/http"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -22,58 +23,124 @@ import (
 type AuthService interface {
 	Login(ctx context.Context, req request.UserLoginRequest) (response.TokenResponse, error)
 	Register(ctx context.Context, req request.UserRegisterRequest) (response.UserInfoResponse, error)
+	Refresh(ctx context.Context, req request.RefreshTokenRequest) (response.TokenResponse, error)
+	Logout(ctx context.Context, req request.LogoutRequest) error
 }
 
 // authService implements AuthService
 type authService struct {
-	userRepo repository.UserRepo
-	logger   *logger.StandardLogger
-	config   *config.Config
+	userRepo            repository.UserRepo
+	roleRepo            repository.RoleRepo
+	tokenService        TokenService
+	verificationService VerificationService
+	mfaService          MFAService
+	loginAttemptService LoginAttemptService
+	passwordPolicy      *password.Policy
+	logger              *logger.StandardLogger
+	config              *config.Config
 }
 
 // NewAuthService creates a new auth service
 func NewAuthService(
 	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	tokenService TokenService,
+	verificationService VerificationService,
+	mfaService MFAService,
+	loginAttemptService LoginAttemptService,
+	passwordPolicy *password.Policy,
 	logger *logger.StandardLogger,
 	config *config.Config,
 ) AuthService {
 	return &authService{
-		userRepo: userRepo,
-		logger:   logger,
-		config:   config,
+		userRepo:            userRepo,
+		roleRepo:            roleRepo,
+		tokenService:        tokenService,
+		verificationService: verificationService,
+		mfaService:          mfaService,
+		loginAttemptService: loginAttemptService,
+		passwordPolicy:      passwordPolicy,
+		logger:              logger,
+		config:              config,
 	}
 }
 
 // Login handles user login
 func (s *authService) Login(ctx context.Context, req request.UserLoginRequest) (response.TokenResponse, error) {
+	// Locked accounts and IPs are refused before the password is even checked
+	err := s.loginAttemptService.CheckLogin(ctx, req.Email, req.ClientIP)
+	if err != nil {
+		s.logger.WithField("email", req.Email).WithField("ip", req.ClientIP).Info("Login refused, locked out")
+		return response.TokenResponse{}, err
+	}
+
 	userID, err := s.userRepo.ValidateUser(entity.User{
 		Email:    req.Email,
 		Password: req.Password,
 	})
+	if err == nil && userID == uuid.Nil {
+		err = errors.NewUnauthorizedError("Invalid email or password")
+	}
 	if err != nil {
 		s.logger.WithField("err", err.Error()).Info("Login failed")
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Status == http.StatusUnauthorized {
+			if recordErr := s.loginAttemptService.RecordFailedLogin(ctx, req.Email, req.ClientIP); recordErr != nil {
+				s.logger.WithError(recordErr).Error("Failed to record failed login")
+			}
+		}
 		return response.TokenResponse{}, err
 	}
 
-	if userID == uuid.Nil {
-		s.logger.Info("Invalid credentials")
-		return response.TokenResponse{}, errors.NewUnauthorizedError("Invalid email or password")
+	if err := s.loginAttemptService.ResetLogin(ctx, req.Email); err != nil {
+		s.logger.WithError(err).Error("Failed to reset failed login counters")
 	}
 
-	token, tokenErr := s.generateToken(userID, req.Email)
-	if tokenErr != nil {
-		s.logger.WithError(tokenErr).Error("Failed to generate token")
-		return response.TokenResponse{}, errors.NewInternalServerError("Failed to generate token: " + tokenErr.Error())
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if s.config.Auth.RequireEmailVerification && !user.IsEmailVerified() {
+		s.logger.WithField("email", req.Email).Info("Login refused, email is not verified")
+		return response.TokenResponse{}, errors.NewEmailNotVerifiedError("Email address has not been verified")
+	}
+	if err := checkAccountActive(user); err != nil {
+		s.logger.WithField("email", req.Email).WithField("state", user.StateName()).Info("Login refused, account is not active")
+		return response.TokenResponse{}, err
 	}
 
-	return response.TokenResponse{
-		Token: token,
-	}, nil
+	user = entity.User{
+		ID:    userID,
+		Email: user.Email,
+	}
+
+	// With MFA enabled the password only earns a challenge, tokens are issued by /auth/mfa/verify
+	mfaToken, err := s.mfaService.ChallengeLogin(ctx, user)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if mfaToken != "" {
+		return response.TokenResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return s.tokenService.IssueTokens(ctx, user)
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (s *authService) Refresh(ctx context.Context, req request.RefreshTokenRequest) (response.TokenResponse, error) {
+	return s.tokenService.RefreshTokens(ctx, req.RefreshToken)
 }
 
 // Register handles user registration
 func (s *authService) Register(ctx context.Context, req request.UserRegisterRequest) (response.UserInfoResponse, error) {
-	err := s.userRepo.VerifyUserEmail(ctx, req.Email)
+	err := s.passwordPolicy.Validate("password", req.Password, req.Email, req.FirstName, req.LastName)
+	if err != nil {
+		return response.UserInfoResponse{}, err
+	}
+
+	err = s.userRepo.VerifyUserEmail(ctx, req.Email)
 	if err != nil {
 		s.logger.
 			WithContext(ctx).
@@ -83,7 +150,12 @@ func (s *authService) Register(ctx context.Context, req request.UserRegisterRequ
 		return response.UserInfoResponse{}, errors.NewConflictError("email already exists")
 	}
 
-	user, err := s.userRepo.UserRegister(ctx, entity.ToEntityModel(req))
+	newUser := entity.ToEntityModel(req)
+	if s.config.Auth.RequireEmailVerification {
+		// The account is activated when the email address is verified
+		newUser.State = enum.EnumStateDB(enum.StatePending)
+	}
+	user, err := s.userRepo.UserRegister(ctx, newUser)
 	if err != nil {
 		s.logger.
 			WithContext(ctx).
@@ -101,6 +173,26 @@ func (s *authService) Register(ctx context.Context, req request.UserRegisterRequ
 		return response.UserInfoResponse{}, errors.NewBadRequestError("Failed to create user")
 	}
 
+	err = s.roleRepo.AssignRole(ctx, user.ID, constant.RoleUser)
+	if err != nil {
+		s.logger.
+			WithContext(ctx).
+			WithField("email", req.Email).
+			WithError(err).
+			Error("Assigning default role failed")
+		return response.UserInfoResponse{}, errors.NewInternalServerError("failed to assign default role")
+	}
+
+	// The account exists at this point, a failed email can be retried through the resend endpoint
+	err = s.verificationService.SendVerificationEmail(ctx, user)
+	if err != nil {
+		s.logger.
+			WithContext(ctx).
+			WithField("email", req.Email).
+			WithError(err).
+			Error("Sending verification email failed")
+	}
+
 	return response.UserInfoResponse{
 		UserID:   user.ID,
 		Email:    user.Email,
@@ -108,24 +200,24 @@ func (s *authService) Register(ctx context.Context, req request.UserRegisterRequ
 	}, nil
 }
 
-// generateToken generates JWT token
-func (s *authService) generateToken(userID uuid.UUID, email string) (string, *errors.AppError) {
-	lifespan, err := strconv.Atoi(s.config.JWT.ExpirationTime)
-	if err != nil {
-		return "", errors.NewUnauthorizedError("Invalid token expiration time: " + err.Error())
+// Logout revokes the caller's access token and, when supplied, its refresh token family
+func (s *authService) Logout(ctx context.Context, req request.LogoutRequest) error {
+	tokenID := util.TokenIDFromCTX(ctx)
+	if tokenID == "" {
+		return errors.NewBadRequestError("Token ID is not found")
 	}
 
-	claims := jwt.MapClaims{
-		constant.UserID:     userID,
-		constant.Email:      email,
-		constant.ExpireDate: time.Now().Add(time.Hour * time.Duration(lifespan)).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.config.JWT.Secret))
+	err := s.tokenService.RevokeAccessToken(ctx, tokenID, util.TokenExpiresAtFromCTX(ctx))
 	if err != nil {
-		return "", errors.NewUnauthorizedError("Failed to sign token: " + err.Error())
+		s.logger.
+			WithContext(ctx).
+			WithError(err).
+			Error("Failed to revoke access token")
+		return err
 	}
 
-	return tokenString, nil
+	if len(req.RefreshToken) != 0 {
+		return s.tokenService.RevokeRefreshToken(ctx, util.UserIDFromCTX(ctx), req.RefreshToken)
+	}
+	return nil
 }
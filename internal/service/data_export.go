@@ -0,0 +1,491 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+// DataExportService defines the interface for users downloading the data stored about them
+type DataExportService interface {
+	RequestExport(ctx context.Context, req request.DataExportRequest) (response.DataExportResponse, error)
+	ListExports(ctx context.Context) ([]response.DataExportResponse, error)
+	GetExport(ctx context.Context, exportID uuid.UUID) (response.DataExportResponse, error)
+	DownloadExport(ctx context.Context, exportID uuid.UUID) (response.DataExportFile, error)
+}
+
+// dataExportService implements DataExportService.
+// Exports are generated by a background worker, which is woken up by new requests and also
+// picks up requests left pending by a restart.
+type dataExportService struct {
+	dataExportRepo repository.DataExportRepo
+	userRepo       repository.UserRepo
+	roleRepo       repository.RoleRepo
+	sessionRepo    repository.SessionRepo
+	apiKeyRepo     repository.APIKeyRepo
+	oidcRepo       repository.OIDCRepo
+	mfaRepo        repository.MFARepo
+	orgRepo        repository.OrganizationRepo
+	invitationRepo repository.InvitationRepo
+	auditLogRepo   repository.AuditLogRepo
+	notifier       notifier.Notifier
+	logger         *logger.StandardLogger
+	config         *config.Config
+	wake           chan struct{}
+}
+
+// NewDataExportService creates a new data export service and runs its worker while the app is running
+func NewDataExportService(
+	lc fx.Lifecycle,
+	dataExportRepo repository.DataExportRepo,
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	sessionRepo repository.SessionRepo,
+	apiKeyRepo repository.APIKeyRepo,
+	oidcRepo repository.OIDCRepo,
+	mfaRepo repository.MFARepo,
+	orgRepo repository.OrganizationRepo,
+	invitationRepo repository.InvitationRepo,
+	auditLogRepo repository.AuditLogRepo,
+	notifier notifier.Notifier,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) DataExportService {
+	s := &dataExportService{
+		dataExportRepo: dataExportRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		sessionRepo:    sessionRepo,
+		apiKeyRepo:     apiKeyRepo,
+		oidcRepo:       oidcRepo,
+		mfaRepo:        mfaRepo,
+		orgRepo:        orgRepo,
+		invitationRepo: invitationRepo,
+		auditLogRepo:   auditLogRepo,
+		notifier:       notifier,
+		logger:         logger,
+		config:         config,
+		wake:           make(chan struct{}, 1),
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.run(stop, done)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	return s
+}
+
+// RequestExport queues an export of the caller's data. Only one export per user is prepared at a time.
+func (s *dataExportService) RequestExport(
+	ctx context.Context,
+	req request.DataExportRequest,
+) (response.DataExportResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.DataExportResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	pending, err := s.dataExportRepo.HasPendingDataExport(ctx, userID)
+	if err != nil {
+		return response.DataExportResponse{}, err
+	}
+	if pending {
+		return response.DataExportResponse{}, errors.NewConflictError("An export of your data is already being prepared")
+	}
+
+	now := time.Now()
+	export := entity.DataExport{
+		ID:     uuid.New(),
+		UserID: userID,
+		Format: req.Format,
+		Status: entity.DataExportPending,
+		BaseEntity: entity.BaseEntity{
+			CreatedAt: &now,
+			CreatedBy: userID.String(),
+		},
+	}
+	if err := s.dataExportRepo.CreateDataExport(ctx, export); err != nil {
+		return response.DataExportResponse{}, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// The worker is already due to look for pending exports
+	}
+
+	s.logger.WithField("user_id", userID).WithField("export_id", export.ID).Info("Data export requested")
+	return toDataExportResponse(export), nil
+}
+
+// ListExports returns the caller's exports that can still be downloaded or are being prepared
+func (s *dataExportService) ListExports(ctx context.Context) ([]response.DataExportResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return nil, errors.NewBadRequestError("User ID is not found")
+	}
+
+	exports, err := s.dataExportRepo.GetDataExportsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]response.DataExportResponse, 0, len(exports))
+	for _, export := range exports {
+		resp = append(resp, toDataExportResponse(export))
+	}
+	return resp, nil
+}
+
+// GetExport returns the status of one of the caller's exports
+func (s *dataExportService) GetExport(ctx context.Context, exportID uuid.UUID) (response.DataExportResponse, error) {
+	export, err := s.getOwnExport(ctx, exportID)
+	if err != nil {
+		return response.DataExportResponse{}, err
+	}
+	return toDataExportResponse(export), nil
+}
+
+// DownloadExport returns the archive of a finished export until it expires
+func (s *dataExportService) DownloadExport(ctx context.Context, exportID uuid.UUID) (response.DataExportFile, error) {
+	export, err := s.getOwnExport(ctx, exportID)
+	if err != nil {
+		return response.DataExportFile{}, err
+	}
+	if export.Status == entity.DataExportPending {
+		return response.DataExportFile{}, errors.NewConflictError("The export is still being prepared")
+	}
+	if !export.IsDownloadable(time.Now()) {
+		return response.DataExportFile{}, errors.NewNotFoundError("Data export not found")
+	}
+
+	file := response.DataExportFile{
+		FileName:    fmt.Sprintf("data-export-%s.%s", export.CreatedAt.Format("20060102"), export.Format),
+		ContentType: "application/zip",
+		Data:        export.Archive,
+	}
+	if export.Format == constant.DataExportFormatJSON {
+		file.ContentType = "application/json"
+	}
+	return file, nil
+}
+
+// getOwnExport loads an export of the caller; exports of other users are reported as not found
+func (s *dataExportService) getOwnExport(ctx context.Context, exportID uuid.UUID) (entity.DataExport, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return entity.DataExport{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	export, err := s.dataExportRepo.GetDataExportByID(ctx, exportID)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+	if export.UserID != userID {
+		return entity.DataExport{}, errors.NewNotFoundError("Data export not found")
+	}
+	return export, nil
+}
+
+// run generates pending exports whenever it is woken up and on every prune interval,
+// when it also deletes the expired ones
+func (s *dataExportService) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	interval := s.config.Privacy.DataExportPruneInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.processPendingExports(stop)
+	for {
+		select {
+		case <-s.wake:
+			s.processPendingExports(stop)
+		case <-ticker.C:
+			if err := s.dataExportRepo.DeleteExpiredDataExports(context.Background(), time.Now()); err != nil {
+				s.logger.WithError(err).Error("Failed to delete expired data exports")
+			}
+			s.processPendingExports(stop)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *dataExportService) processPendingExports(stop <-chan struct{}) {
+	exports, err := s.dataExportRepo.GetPendingDataExports(context.Background())
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load pending data exports")
+		return
+	}
+	for _, export := range exports {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		s.processExport(context.Background(), export)
+	}
+}
+
+// processExport builds the archive of one export and tells the user it can be downloaded
+func (s *dataExportService) processExport(ctx context.Context, export entity.DataExport) {
+	log := s.logger.WithField("export_id", export.ID).WithField("user_id", export.UserID)
+	expiresAt := time.Now().Add(s.config.Privacy.DataExportTTL)
+
+	user, data, err := s.collectUserData(ctx, export.UserID)
+	var archive []byte
+	if err == nil {
+		archive, err = buildDataExportArchive(export.Format, data)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to generate data export")
+		if err := s.dataExportRepo.FailDataExport(ctx, export.ID, expiresAt); err != nil {
+			log.WithError(err).Error("Failed to mark data export as failed")
+		}
+		return
+	}
+
+	completed, err := s.dataExportRepo.CompleteDataExport(ctx, export.ID, archive, expiresAt)
+	if err != nil {
+		log.WithError(err).Error("Failed to store data export")
+		return
+	}
+	if !completed {
+		return
+	}
+	log.Info("Data export ready")
+
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf(
+			"The copy of your data you asked for is ready. You can download it from your account until %s.",
+			expiresAt.UTC().Format(time.RFC1123),
+		),
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to send data export notification")
+	}
+}
+
+// collectUserData gathers the profile, sessions, API keys, linked identities, organization memberships,
+// invitations and audit entries of a user
+func (s *dataExportService) collectUserData(
+	ctx context.Context,
+	userID uuid.UUID,
+) (entity.User, response.UserDataExport, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	factor, err := s.mfaRepo.GetMFAFactorByUserID(ctx, userID)
+	if err != nil && !isNotFound(err) {
+		return user, response.UserDataExport{}, err
+	}
+	sessions, err := s.sessionRepo.GetSessionsByUserID(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	apiKeys, err := s.apiKeyRepo.GetAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	identities, err := s.oidcRepo.GetUserIdentitiesByUserID(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	memberships, err := s.orgRepo.GetUserMemberships(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	invitations, err := s.invitationRepo.GetUserInvitations(ctx, userID, user.Email)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+	auditLogs, err := s.auditLogRepo.GetAuditLogsByUserID(ctx, userID)
+	if err != nil {
+		return user, response.UserDataExport{}, err
+	}
+
+	data := response.UserDataExport{
+		ExportedAt: time.Now(),
+		Profile: response.UserDataProfile{
+			ID:              user.ID,
+			Email:           user.Email,
+			FirstName:       user.FirstName,
+			LastName:        user.LastName,
+			PendingEmail:    user.PendingEmail,
+			EmailVerifiedAt: user.EmailVerifiedAt,
+			Status:          user.StateName(),
+			Roles:           entity.RoleNames(roles),
+			MFAEnabled:      factor.IsConfirmed(),
+			CreatedAt:       user.CreatedAt,
+			UpdatedAt:       user.UpdatedAt,
+		},
+		Sessions:      make([]response.UserDataSession, 0, len(sessions)),
+		APIKeys:       make([]response.UserDataAPIKey, 0, len(apiKeys)),
+		Identities:    make([]response.UserDataIdentity, 0, len(identities)),
+		Organizations: make([]response.UserDataOrganization, 0, len(memberships)),
+		Invitations:   make([]response.UserDataInvitation, 0, len(invitations)),
+		AuditLog:      make([]response.UserDataAuditLogEntry, 0, len(auditLogs)),
+	}
+	for _, session := range sessions {
+		data.Sessions = append(data.Sessions, response.UserDataSession{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+	for _, key := range apiKeys {
+		data.APIKeys = append(data.APIKeys, response.UserDataAPIKey{
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.ScopeList(),
+			CreatedAt:  key.CreatedAt,
+			LastUsedAt: key.LastUsedAt,
+			ExpiresAt:  key.ExpiresAt,
+		})
+	}
+	for _, identity := range identities {
+		data.Identities = append(data.Identities, response.UserDataIdentity{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	for _, membership := range memberships {
+		data.Organizations = append(data.Organizations, response.UserDataOrganization{
+			ID:       membership.OrganizationID,
+			Name:     membership.Organization.Name,
+			Slug:     membership.Organization.Slug,
+			Role:     membership.Role,
+			JoinedAt: membership.CreatedAt,
+		})
+	}
+	for _, invitation := range invitations {
+		data.Invitations = append(data.Invitations, response.UserDataInvitation{
+			OrganizationID:   invitation.OrganizationID,
+			OrganizationName: invitation.Organization.Name,
+			Email:            invitation.Email,
+			Role:             invitation.Role,
+			InvitedBy:        invitation.InvitedBy,
+			Sent:             invitation.InvitedBy == userID,
+			Status:           invitation.Status(),
+			CreatedAt:        invitation.CreatedAt,
+			ExpiresAt:        invitation.ExpiresAt,
+			AcceptedAt:       invitation.AcceptedAt,
+		})
+	}
+	for _, log := range auditLogs {
+		entry := response.UserDataAuditLogEntry{
+			Action:    log.Action,
+			ActorID:   log.ActorID,
+			TargetID:  log.TargetID,
+			Reason:    log.Reason,
+			CreatedAt: log.CreatedAt,
+		}
+		// Where the user was only the target, the IP address and user agent are the admin's
+		if log.ActorID == userID {
+			entry.IPAddress = log.IPAddress
+			entry.UserAgent = log.UserAgent
+		}
+		data.AuditLog = append(data.AuditLog, entry)
+	}
+	return user, data, nil
+}
+
+// buildDataExportArchive writes the export as one JSON document, or as a ZIP archive with a JSON file per section
+func buildDataExportArchive(format string, data response.UserDataExport) ([]byte, error) {
+	if format == constant.DataExportFormatJSON {
+		return json.MarshalIndent(data, "", "  ")
+	}
+
+	sections := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"sessions.json", data.Sessions},
+		{"api_keys.json", data.APIKeys},
+		{"identities.json", data.Identities},
+		{"organizations.json", data.Organizations},
+		{"invitations.json", data.Invitations},
+		{"audit_log.json", data.AuditLog},
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, section := range sections {
+		content, err := json.MarshalIndent(section.content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: data.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toDataExportResponse(export entity.DataExport) response.DataExportResponse {
+	return response.DataExportResponse{
+		ID:          export.ID,
+		Format:      export.Format,
+		Status:      export.Status,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
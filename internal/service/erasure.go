@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErasureService defines the interface for erasing the personal data of users
+type ErasureService interface {
+	EraseAccount(ctx context.Context, req request.EraseAccountRequest) error
+	EraseUser(ctx context.Context, userID uuid.UUID, req request.EraseUserRequest) error
+}
+
+// erasureService implements ErasureService
+type erasureService struct {
+	userRepo         repository.UserRepo
+	organizationRepo repository.OrganizationRepo
+	auditLogRepo     repository.AuditLogRepo
+	notifier         notifier.Notifier
+	logger           *logger.StandardLogger
+}
+
+// NewErasureService creates a new erasure service
+func NewErasureService(
+	userRepo repository.UserRepo,
+	organizationRepo repository.OrganizationRepo,
+	auditLogRepo repository.AuditLogRepo,
+	notifier notifier.Notifier,
+	logger *logger.StandardLogger,
+) ErasureService {
+	return &erasureService{
+		userRepo:         userRepo,
+		organizationRepo: organizationRepo,
+		auditLogRepo:     auditLogRepo,
+		notifier:         notifier,
+		logger:           logger,
+	}
+}
+
+// EraseAccount erases the caller's own account once they confirmed it with their password
+func (s *erasureService) EraseAccount(ctx context.Context, req request.EraseAccountRequest) error {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+	if util.ActorIDFromCTX(ctx) != uuid.Nil {
+		return errors.NewForbiddenError("Not allowed while impersonating a user")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	validatedID, err := s.userRepo.ValidateUser(entity.User{Email: user.Email, Password: req.Password})
+	if appErr, ok := err.(*errors.AppError); err != nil && (!ok || appErr.Status != http.StatusUnauthorized) {
+		return err
+	}
+	if validatedID != user.ID {
+		return errors.NewForbiddenError("Password is incorrect")
+	}
+
+	return s.erase(ctx, userID, user, "Requested by the user")
+}
+
+// EraseUser lets an admin erase a user, for example one who asked for it by email.
+// Deleted users can be erased too.
+func (s *erasureService) EraseUser(ctx context.Context, userID uuid.UUID, req request.EraseUserRequest) error {
+	adminID := util.UserIDFromCTX(ctx)
+	if adminID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+	if userID == adminID {
+		return errors.NewBadRequestError("You can't erase your own account here")
+	}
+
+	user, err := s.userRepo.GetUserByIDWithDeleted(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.erase(ctx, adminID, user, req.Reason)
+}
+
+// erase anonymises the user after recording who asked for it and why.
+// The user is told at their old address, which is the last time it is used.
+func (s *erasureService) erase(ctx context.Context, actorID uuid.UUID, user entity.User, reason string) error {
+	if user.IsErased() {
+		return errors.NewConflictError("User has already been erased")
+	}
+	if err := s.checkNotSoleOwner(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// The user is not erased unless the audit record was stored
+	err := s.auditLogRepo.CreateAuditLog(ctx, entity.AuditLog{
+		ActorID:   actorID,
+		Action:    constant.AuditActionUserErase,
+		TargetID:  user.ID,
+		Reason:    reason,
+		IPAddress: util.ClientIPFromCTX(ctx),
+		UserAgent: truncateString(util.UserAgentFromCTX(ctx), maxUserAgentLength),
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: actorID.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	erased, err := s.userRepo.EraseUser(ctx, user.ID, actorID.String())
+	if err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to erase user")
+		return err
+	}
+	if !erased {
+		return errors.NewConflictError("User has already been erased")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"actor_id": actorID,
+		"user_id":  user.ID,
+	}).Warn("User erased")
+
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Your account has been erased",
+		Body:    "As requested, your account and the personal data stored with it have been erased.",
+	})
+	if err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to send erasure confirmation")
+	}
+	return nil
+}
+
+// checkNotSoleOwner refuses to erase the only owner of an organization, which would be left without one.
+// Another member has to be made an owner first.
+func (s *erasureService) checkNotSoleOwner(ctx context.Context, userID uuid.UUID) error {
+	memberships, err := s.organizationRepo.GetUserMemberships(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var soleOwned []string
+	for _, membership := range memberships {
+		if membership.Role != constant.OrganizationRoleOwner {
+			continue
+		}
+		owners, err := s.organizationRepo.CountOwners(util.WithTenant(ctx, membership.OrganizationID))
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			soleOwned = append(soleOwned, membership.Organization.Name)
+		}
+	}
+	if len(soleOwned) > 0 {
+		return errors.NewConflictError(
+			"The user is the only owner of " + strings.Join(soleOwned, ", ") + ". Make another member an owner first",
+		)
+	}
+	return nil
+}
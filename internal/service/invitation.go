@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvitationService defines the interface for inviting people to an organization by email.
+// Invitations are managed in the tenant set by middleware.RequireOrganization and accepted without logging in.
+type InvitationService interface {
+	CreateInvitation(ctx context.Context, req request.CreateInvitationRequest) (response.InvitationResponse, error)
+	ListInvitations(ctx context.Context) ([]response.InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, invitationID uuid.UUID) error
+	AcceptInvitation(ctx context.Context, req request.AcceptInvitationRequest) (response.AcceptInvitationResponse, error)
+}
+
+// invitationService implements InvitationService
+type invitationService struct {
+	invitationRepo   repository.InvitationRepo
+	organizationRepo repository.OrganizationRepo
+	userRepo         repository.UserRepo
+	authService      AuthService
+	notifier         notifier.Notifier
+	logger           *logger.StandardLogger
+	config           *config.Config
+}
+
+// NewInvitationService creates a new invitation service
+func NewInvitationService(
+	invitationRepo repository.InvitationRepo,
+	organizationRepo repository.OrganizationRepo,
+	userRepo repository.UserRepo,
+	authService AuthService,
+	notifier notifier.Notifier,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) InvitationService {
+	return &invitationService{
+		invitationRepo:   invitationRepo,
+		organizationRepo: organizationRepo,
+		userRepo:         userRepo,
+		authService:      authService,
+		notifier:         notifier,
+		logger:           logger,
+		config:           config,
+	}
+}
+
+// CreateInvitation mails a signed invitation link to join the active organization. Only owners can invite owners.
+// A pending invitation to the same email is replaced, which is also how a lost or undelivered link is resent.
+func (s *invitationService) CreateInvitation(
+	ctx context.Context,
+	req request.CreateInvitationRequest,
+) (response.InvitationResponse, error) {
+	if err := checkCanAssign(ctx, req.Role); err != nil {
+		return response.InvitationResponse{}, err
+	}
+	callerID := util.UserIDFromCTX(ctx)
+	if callerID == uuid.Nil {
+		return response.InvitationResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	if err == nil {
+		_, err = s.organizationRepo.GetMember(ctx, user.ID)
+		if err == nil {
+			return response.InvitationResponse{}, errors.NewConflictError("User is already a member of the organization")
+		}
+	}
+	if err != nil && !isNotFound(err) {
+		return response.InvitationResponse{}, err
+	}
+
+	organization, err := s.organizationRepo.GetOrganizationByID(ctx, util.TenantIDFromCTX(ctx))
+	if err != nil {
+		return response.InvitationResponse{}, err
+	}
+	inviter, err := s.userRepo.GetUserByID(ctx, callerID)
+	if err != nil {
+		return response.InvitationResponse{}, err
+	}
+
+	invitation := entity.OrganizationInvitation{
+		ID:             uuid.New(),
+		OrganizationID: organization.ID,
+		Email:          req.Email,
+		Role:           req.Role,
+		InvitedBy:      callerID,
+		ExpiresAt:      time.Now().Add(s.config.Auth.InvitationTokenTTL),
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: callerID.String(),
+		},
+	}
+	token, err := util.SignActionToken(
+		s.config.Auth.ActionTokenSecret,
+		constant.PurposeInvitation,
+		invitation.ID.String(),
+		invitation.Email,
+		s.config.Auth.InvitationTokenTTL,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to sign invitation token")
+		return response.InvitationResponse{}, errors.NewInternalServerError("Failed to generate invitation token")
+	}
+
+	if err := s.invitationRepo.CreateInvitation(ctx, invitation); err != nil {
+		s.logger.WithError(err).Error("Failed to create invitation")
+		return response.InvitationResponse{}, err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.config.Auth.InvitationURL, url.QueryEscape(token))
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You are invited to join %s", organization.Name),
+		Body: fmt.Sprintf(
+			"Hi,\n\n%s %s invited you to join %s as %s. Open the link below to accept. It expires in %s.\n\n%s",
+			inviter.FirstName, inviter.LastName, organization.Name, invitation.Role, s.config.Auth.InvitationTokenTTL, link,
+		),
+	})
+	if err != nil {
+		s.logger.WithField("email", invitation.Email).WithError(err).Error("Failed to send invitation email")
+		return response.InvitationResponse{}, errors.NewInternalServerError("Failed to send invitation email")
+	}
+
+	s.logger.
+		WithField("organization_id", organization.ID).
+		WithField("invitation_id", invitation.ID).
+		WithField("role", invitation.Role).
+		Info("Organization invitation sent")
+
+	return toInvitationResponse(invitation), nil
+}
+
+// ListInvitations lists the invitations of the active organization in every status, newest first
+func (s *invitationService) ListInvitations(ctx context.Context) ([]response.InvitationResponse, error) {
+	invitations, err := s.invitationRepo.GetInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]response.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		resp = append(resp, toInvitationResponse(invitation))
+	}
+	return resp, nil
+}
+
+// RevokeInvitation makes a pending invitation of the active organization unusable.
+// Only owners can revoke an invitation to become owner.
+func (s *invitationService) RevokeInvitation(ctx context.Context, invitationID uuid.UUID) error {
+	invitation, err := s.invitationRepo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if err := checkCanAssign(ctx, invitation.Role); err != nil {
+		return err
+	}
+
+	revoked, err := s.invitationRepo.RevokeInvitation(ctx, invitationID, util.UserIDFromCTX(ctx).String())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.NewConflictError("Invitation is no longer pending")
+	}
+
+	s.logger.
+		WithField("organization_id", invitation.OrganizationID).
+		WithField("invitation_id", invitationID).
+		Info("Organization invitation revoked")
+	return nil
+}
+
+// AcceptInvitation redeems an invitation token, adding the account of the invited email to the organization.
+// When there is no such account one is registered like through /auth/register, from the name and password
+// of the request. Its email is verified right away since the token was mailed to it.
+func (s *invitationService) AcceptInvitation(
+	ctx context.Context,
+	req request.AcceptInvitationRequest,
+) (response.AcceptInvitationResponse, error) {
+	invalidToken := errors.NewBadRequestError("Invalid or expired invitation")
+
+	claims, err := util.ParseActionToken(s.config.Auth.ActionTokenSecret, constant.PurposeInvitation, req.Token)
+	if err != nil {
+		s.logger.WithError(err).Info("Invalid invitation token")
+		return response.AcceptInvitationResponse{}, invalidToken
+	}
+	invitationID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return response.AcceptInvitationResponse{}, invalidToken
+	}
+
+	invitation, err := s.invitationRepo.GetInvitationByID(ctx, invitationID)
+	if isNotFound(err) {
+		return response.AcceptInvitationResponse{}, invalidToken
+	}
+	if err != nil {
+		return response.AcceptInvitationResponse{}, err
+	}
+	if invitation.Email != claims.Email || invitation.Status() != constant.InvitationStatusPending {
+		return response.AcceptInvitationResponse{}, invalidToken
+	}
+
+	var userID uuid.UUID
+	accountCreated := false
+	user, err := s.userRepo.GetUserByEmail(ctx, invitation.Email)
+	switch {
+	case err == nil:
+		userID = user.ID
+		_, err = s.organizationRepo.GetUserMembership(ctx, invitation.OrganizationID, userID)
+		if err == nil {
+			return response.AcceptInvitationResponse{}, errors.NewConflictError("User is already a member of the organization")
+		}
+		if !isNotFound(err) {
+			return response.AcceptInvitationResponse{}, err
+		}
+	case isNotFound(err):
+		userID, err = s.registerInvitee(ctx, invitation, req)
+		if err != nil {
+			return response.AcceptInvitationResponse{}, err
+		}
+		accountCreated = true
+	default:
+		return response.AcceptInvitationResponse{}, err
+	}
+
+	member := entity.OrganizationMember{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: invitation.InvitedBy.String(),
+		},
+	}
+	accepted, err := s.invitationRepo.AcceptInvitation(ctx, invitation, member)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to accept invitation")
+		return response.AcceptInvitationResponse{}, err
+	}
+	if !accepted {
+		return response.AcceptInvitationResponse{}, invalidToken
+	}
+
+	s.logger.
+		WithField("organization_id", invitation.OrganizationID).
+		WithField("invitation_id", invitation.ID).
+		WithField("user_id", userID).
+		WithField("account_created", accountCreated).
+		Info("Organization invitation accepted")
+
+	return response.AcceptInvitationResponse{
+		Organization:   toOrganizationResponse(invitation.Organization),
+		Role:           invitation.Role,
+		UserID:         userID,
+		AccountCreated: accountCreated,
+	}, nil
+}
+
+// registerInvitee registers an account for the invited email through AuthService.Register
+func (s *invitationService) registerInvitee(
+	ctx context.Context,
+	invitation entity.OrganizationInvitation,
+	req request.AcceptInvitationRequest,
+) (uuid.UUID, error) {
+	registerReq := request.UserRegisterRequest{
+		FirstName:       req.FirstName,
+		LastName:        req.LastName,
+		Email:           invitation.Email,
+		Password:        req.Password,
+		ConfirmPassword: req.ConfirmPassword,
+	}
+	if err := registerReq.Validate(); err != nil {
+		return uuid.Nil, err
+	}
+
+	user, err := s.authService.Register(ctx, registerReq)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// The account works without the verification email Register sent, it can still be followed harmlessly
+	_, err = s.userRepo.MarkEmailVerified(ctx, user.UserID, invitation.Email)
+	if err != nil {
+		s.logger.WithField("user_id", user.UserID).WithError(err).Warn("Failed to verify the email of an invited user")
+	}
+	return user.UserID, nil
+}
+
+func toInvitationResponse(invitation entity.OrganizationInvitation) response.InvitationResponse {
+	return response.InvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		Status:    invitation.Status(),
+		InvitedBy: invitation.InvitedBy,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttemptService defines the interface for throttling failed logins.
+// Wrong MFA codes are counted per user apart from passwords, so a correct password doesn't reset them.
+type LoginAttemptService interface {
+	CheckLogin(ctx context.Context, email, clientIP string) error
+	RecordFailedLogin(ctx context.Context, email, clientIP string) error
+	ResetLogin(ctx context.Context, email string) error
+	CheckMFA(ctx context.Context, userID uuid.UUID) error
+	RecordFailedMFA(ctx context.Context, userID uuid.UUID) error
+	ResetMFA(ctx context.Context, userID uuid.UUID) error
+}
+
+// loginAttemptService implements LoginAttemptService
+type loginAttemptService struct {
+	store  repository.LoginAttemptStore
+	logger *logger.StandardLogger
+	config *config.Config
+}
+
+// NewLoginAttemptService creates a new login attempt service
+func NewLoginAttemptService(
+	store repository.LoginAttemptStore,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) LoginAttemptService {
+	return &loginAttemptService{
+		store:  store,
+		logger: logger,
+		config: config,
+	}
+}
+
+// CheckLogin refuses the attempt while the account or the client IP is locked out
+func (s *loginAttemptService) CheckLogin(ctx context.Context, email, clientIP string) error {
+	return s.checkLocked(ctx, loginAttemptKeys(email, clientIP))
+}
+
+// RecordFailedLogin counts a failure for the account and the client IP and locks whichever reached its threshold
+func (s *loginAttemptService) RecordFailedLogin(ctx context.Context, email, clientIP string) error {
+	accountKey, ipKey := accountAttemptKey(email), ipAttemptKey(clientIP)
+	thresholds := map[string]int{
+		accountKey: s.config.Auth.LoginLockoutThreshold,
+	}
+	if ipKey != "" {
+		thresholds[ipKey] = s.config.Auth.LoginIPLockoutThreshold
+	}
+	return s.recordFailure(ctx, thresholds)
+}
+
+// ResetLogin clears the account counter after a successful login. The client IP counter is left to expire,
+// so logging into an account of one's own doesn't reset the failures made against others from the same IP.
+func (s *loginAttemptService) ResetLogin(ctx context.Context, email string) error {
+	return s.reset(ctx, []string{accountAttemptKey(email)})
+}
+
+// CheckMFA refuses the attempt while MFA verification is locked out for the user
+func (s *loginAttemptService) CheckMFA(ctx context.Context, userID uuid.UUID) error {
+	return s.checkLocked(ctx, []string{mfaAttemptKey(userID)})
+}
+
+// RecordFailedMFA counts a wrong MFA code for the user, with the same threshold as passwords
+func (s *loginAttemptService) RecordFailedMFA(ctx context.Context, userID uuid.UUID) error {
+	return s.recordFailure(ctx, map[string]int{mfaAttemptKey(userID): s.config.Auth.LoginLockoutThreshold})
+}
+
+// ResetMFA clears the counter after a successful MFA verification
+func (s *loginAttemptService) ResetMFA(ctx context.Context, userID uuid.UUID) error {
+	return s.reset(ctx, []string{mfaAttemptKey(userID)})
+}
+
+func (s *loginAttemptService) checkLocked(ctx context.Context, keys []string) error {
+	now := time.Now()
+	for _, key := range keys {
+		attempt, err := s.store.GetLoginAttempt(ctx, key)
+		if err != nil {
+			return err
+		}
+		if attempt.IsLocked(now) {
+			retryIn := attempt.LockedUntil.Sub(now).Round(time.Second)
+			return errors.NewAccountLockedError(
+				fmt.Sprintf("Too many failed login attempts, try again in %s", retryIn),
+			)
+		}
+	}
+	return nil
+}
+
+// recordFailure counts a failure for each key and locks the keys that reached their threshold
+func (s *loginAttemptService) recordFailure(ctx context.Context, thresholds map[string]int) error {
+	for key, threshold := range thresholds {
+		if threshold <= 0 {
+			continue
+		}
+		failures, err := s.store.RecordLoginFailure(ctx, key, s.config.Auth.LoginAttemptWindow)
+		if err != nil {
+			return err
+		}
+		if failures < threshold {
+			continue
+		}
+
+		lockFor := s.lockoutDuration(failures - threshold)
+		s.logger.WithField("key", key).WithField("failures", failures).Warn("Locking logins for " + lockFor.String())
+		if err := s.store.LockLogin(ctx, key, time.Now().Add(lockFor)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *loginAttemptService) reset(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := s.store.ResetLoginAttempts(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockoutDuration doubles the base lockout for every failure past the threshold, up to the configured maximum
+func (s *loginAttemptService) lockoutDuration(overThreshold int) time.Duration {
+	duration := s.config.Auth.LoginLockoutDuration
+	maxDuration := s.config.Auth.LoginLockoutMaxDuration
+	for i := 0; i < overThreshold && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if maxDuration > 0 && duration > maxDuration {
+		return maxDuration
+	}
+	return duration
+}
+
+func loginAttemptKeys(email, clientIP string) []string {
+	keys := []string{accountAttemptKey(email)}
+	if ipKey := ipAttemptKey(clientIP); ipKey != "" {
+		keys = append(keys, ipKey)
+	}
+	return keys
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(clientIP string) string {
+	if clientIP == "" {
+		return ""
+	}
+	return "ip:" + clientIP
+}
+
+func mfaAttemptKey(userID uuid.UUID) string {
+	return "mfa:" + userID.String()
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/url"
+	"time"
+)
+
+// MagicLinkService defines the interface for passwordless login with a mailed link
+type MagicLinkService interface {
+	RequestMagicLink(ctx context.Context, req request.MagicLinkRequest) error
+	VerifyMagicLink(ctx context.Context, req request.MagicLinkVerifyRequest) (response.TokenResponse, error)
+}
+
+// magicLinkService implements MagicLinkService
+type magicLinkService struct {
+	magicLinkTokenRepo repository.MagicLinkTokenRepo
+	userRepo           repository.UserRepo
+	tokenService       TokenService
+	mfaService         MFAService
+	notifier           notifier.Notifier
+	logger             *logger.StandardLogger
+	config             *config.Config
+}
+
+// NewMagicLinkService creates a new magic link service
+func NewMagicLinkService(
+	magicLinkTokenRepo repository.MagicLinkTokenRepo,
+	userRepo repository.UserRepo,
+	tokenService TokenService,
+	mfaService MFAService,
+	notifier notifier.Notifier,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) MagicLinkService {
+	return &magicLinkService{
+		magicLinkTokenRepo: magicLinkTokenRepo,
+		userRepo:           userRepo,
+		tokenService:       tokenService,
+		mfaService:         mfaService,
+		notifier:           notifier,
+		logger:             logger,
+		config:             config,
+	}
+}
+
+// RequestMagicLink mails a single-use login link to the account owner.
+// It succeeds whether or not the email is registered so callers cannot probe for accounts.
+func (s *magicLinkService) RequestMagicLink(ctx context.Context, req request.MagicLinkRequest) error {
+	if !s.config.Auth.MagicLinkEnabled {
+		return errors.NewForbiddenError("Magic link login is disabled")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.WithField("email", req.Email).WithError(err).Info("Magic link requested for unknown email")
+		return nil
+	}
+
+	// Only the most recent link should work
+	if err := s.magicLinkTokenRepo.InvalidateUserMagicLinkTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := util.GenerateSecureToken(32)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to generate magic link token")
+		return errors.NewInternalServerError("Failed to generate magic link token")
+	}
+
+	err = s.magicLinkTokenRepo.CreateMagicLinkToken(ctx, entity.MagicLinkToken{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.Auth.MagicLinkTokenTTL),
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: user.Email,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.config.Auth.MagicLinkURL, url.QueryEscape(token))
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to sign in. It expires in %s and can only be used once.\n\n%s\n\n"+
+				"If you did not ask to sign in you can ignore this message.",
+			user.FirstName, s.config.Auth.MagicLinkTokenTTL, link,
+		),
+	})
+	if err != nil {
+		s.logger.WithField("email", user.Email).WithError(err).Error("Failed to send magic link message")
+	}
+	return nil
+}
+
+// VerifyMagicLink redeems a magic link token and logs the user in as Login would.
+// Following the link proves control of the mailbox, so the email is marked verified.
+func (s *magicLinkService) VerifyMagicLink(
+	ctx context.Context,
+	req request.MagicLinkVerifyRequest,
+) (response.TokenResponse, error) {
+	if !s.config.Auth.MagicLinkEnabled {
+		return response.TokenResponse{}, errors.NewForbiddenError("Magic link login is disabled")
+	}
+	invalidToken := errors.NewUnauthorizedError("Invalid or expired magic link")
+
+	magicLinkToken, err := s.magicLinkTokenRepo.GetMagicLinkTokenByHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		return response.TokenResponse{}, invalidToken
+	}
+	if magicLinkToken.UsedAt != nil || time.Now().After(magicLinkToken.ExpiresAt) {
+		return response.TokenResponse{}, invalidToken
+	}
+
+	used, err := s.magicLinkTokenRepo.MarkMagicLinkTokenUsed(ctx, magicLinkToken.ID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if !used {
+		return response.TokenResponse{}, invalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, magicLinkToken.UserID)
+	if err != nil {
+		return response.TokenResponse{}, invalidToken
+	}
+	if !user.IsEmailVerified() {
+		// Whoever registered the account never proved the address, the link does: drop their credentials
+		claimed, err := s.userRepo.ClaimUnverifiedAccount(ctx, user.ID, user.Email)
+		if err != nil {
+			return response.TokenResponse{}, err
+		}
+		if claimed {
+			s.logger.WithField("user_id", user.ID).Info("Magic link verified the email, previous credentials invalidated")
+		}
+		user.VerifyEmail(time.Now())
+	}
+	if err := checkAccountActive(user); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	user = entity.User{ID: user.ID, Email: user.Email}
+	// The link replaces the password only, MFA still applies
+	mfaToken, err := s.mfaService.ChallengeLogin(ctx, user)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if mfaToken != "" {
+		return response.TokenResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+	return s.tokenService.IssueTokens(ctx, user)
+}
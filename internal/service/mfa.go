@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	mfaRecoveryCodeCount = 10
+	// mfaCodeSkew accepts codes from one step before and after the current one to absorb clock drift
+	mfaCodeSkew = 1
+)
+
+// MFAService defines the interface for multi-factor authentication operations
+type MFAService interface {
+	Enroll(ctx context.Context) (response.MFAEnrollmentResponse, error)
+	Confirm(ctx context.Context, req request.MFAConfirmRequest) (response.MFARecoveryCodesResponse, error)
+	ChallengeLogin(ctx context.Context, user entity.User) (mfaToken string, err error)
+	Verify(ctx context.Context, req request.MFAVerifyRequest) (response.TokenResponse, error)
+}
+
+// mfaService implements MFAService
+type mfaService struct {
+	mfaRepo             repository.MFARepo
+	userRepo            repository.UserRepo
+	tokenService        TokenService
+	loginAttemptService LoginAttemptService
+	revocationStore     repository.TokenRevocationStore
+	logger              *logger.StandardLogger
+	config              *config.Config
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService(
+	mfaRepo repository.MFARepo,
+	userRepo repository.UserRepo,
+	tokenService TokenService,
+	loginAttemptService LoginAttemptService,
+	revocationStore repository.TokenRevocationStore,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) MFAService {
+	return &mfaService{
+		mfaRepo:             mfaRepo,
+		userRepo:            userRepo,
+		tokenService:        tokenService,
+		loginAttemptService: loginAttemptService,
+		revocationStore:     revocationStore,
+		logger:              logger,
+		config:              config,
+	}
+}
+
+// Enroll starts TOTP enrolment for the caller. The secret only protects logins once confirmed.
+func (s *mfaService) Enroll(ctx context.Context) (response.MFAEnrollmentResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.MFAEnrollmentResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	factor, err := s.getFactor(ctx, userID)
+	if err != nil {
+		return response.MFAEnrollmentResponse{}, err
+	}
+	if factor.IsConfirmed() {
+		return response.MFAEnrollmentResponse{}, errors.NewConflictError("MFA is already enabled")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return response.MFAEnrollmentResponse{}, err
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to generate TOTP secret")
+		return response.MFAEnrollmentResponse{}, errors.NewInternalServerError("Failed to generate MFA secret")
+	}
+
+	err = s.mfaRepo.ReplacePendingMFAFactor(ctx, entity.MFAFactor{
+		UserID: userID,
+		Secret: secret,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: user.Email,
+		},
+	})
+	if err != nil {
+		return response.MFAEnrollmentResponse{}, err
+	}
+
+	return response.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: util.TOTPURI(s.config.Auth.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm activates the pending factor once the caller proves their app produces valid codes.
+// The recovery codes are only returned here; just their hashes are stored.
+func (s *mfaService) Confirm(
+	ctx context.Context,
+	req request.MFAConfirmRequest,
+) (response.MFARecoveryCodesResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.MFARecoveryCodesResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	factor, err := s.getFactor(ctx, userID)
+	if err != nil {
+		return response.MFARecoveryCodesResponse{}, err
+	}
+	if factor.ID == uuid.Nil {
+		return response.MFARecoveryCodesResponse{}, errors.NewBadRequestError("MFA enrolment has not been started")
+	}
+	if factor.IsConfirmed() {
+		return response.MFARecoveryCodesResponse{}, errors.NewConflictError("MFA is already enabled")
+	}
+
+	step, ok := util.ValidateTOTP(factor.Secret, req.Code, time.Now(), mfaCodeSkew)
+	if !ok {
+		return response.MFARecoveryCodesResponse{}, errors.NewBadRequestError("Invalid MFA code")
+	}
+
+	codes, hashed, err := generateRecoveryCodes(userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to generate MFA recovery codes")
+		return response.MFARecoveryCodesResponse{}, errors.NewInternalServerError("Failed to generate recovery codes")
+	}
+
+	confirmed, err := s.mfaRepo.ConfirmMFAFactor(ctx, factor, step, hashed)
+	if err != nil {
+		return response.MFARecoveryCodesResponse{}, err
+	}
+	if !confirmed {
+		return response.MFARecoveryCodesResponse{}, errors.NewConflictError("MFA is already enabled")
+	}
+
+	return response.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// ChallengeLogin returns a short-lived MFA challenge token when the user has MFA enabled,
+// or an empty string when the password alone is enough.
+func (s *mfaService) ChallengeLogin(ctx context.Context, user entity.User) (mfaToken string, err error) {
+	factor, err := s.getFactor(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if !factor.IsConfirmed() {
+		return "", nil
+	}
+
+	mfaToken, err = util.SignActionToken(
+		s.config.Auth.ActionTokenSecret,
+		constant.PurposeMFAChallenge,
+		user.ID.String(),
+		user.Email,
+		s.config.Auth.MFAChallengeTTL,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to sign MFA challenge token")
+		return "", errors.NewInternalServerError("Failed to generate MFA challenge")
+	}
+	return mfaToken, nil
+}
+
+// Verify completes a login started with a challenge token, using either a TOTP code or a recovery code.
+// Wrong codes count towards a per-user lockout, and a challenge is spent once it has issued tokens.
+func (s *mfaService) Verify(ctx context.Context, req request.MFAVerifyRequest) (response.TokenResponse, error) {
+	invalidChallenge := errors.NewUnauthorizedError("Invalid or expired MFA challenge")
+
+	claims, err := util.ParseActionToken(s.config.Auth.ActionTokenSecret, constant.PurposeMFAChallenge, req.MFAToken)
+	if err != nil {
+		s.logger.WithError(err).Info("Invalid MFA challenge token")
+		return response.TokenResponse{}, invalidChallenge
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil || claims.ID == "" {
+		return response.TokenResponse{}, invalidChallenge
+	}
+	spent, err := s.revocationStore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if spent {
+		return response.TokenResponse{}, invalidChallenge
+	}
+
+	if err := s.loginAttemptService.CheckMFA(ctx, userID); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	// The account may have been suspended since the password was checked
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if isNotFound(err) {
+		return response.TokenResponse{}, invalidChallenge
+	}
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if err := checkAccountActive(user); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	factor, err := s.getFactor(ctx, userID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if !factor.IsConfirmed() {
+		return response.TokenResponse{}, invalidChallenge
+	}
+
+	var used bool
+	if len(req.RecoveryCode) != 0 {
+		used, err = s.mfaRepo.UseMFARecoveryCode(ctx, userID, util.HashToken(normalizeRecoveryCode(req.RecoveryCode)))
+	} else if step, ok := util.ValidateTOTP(factor.Secret, req.Code, time.Now(), mfaCodeSkew); ok {
+		used, err = s.mfaRepo.UseMFAStep(ctx, factor.ID, step)
+	}
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if !used {
+		s.logger.WithField("user_id", userID).Info("MFA verification failed")
+		if err := s.loginAttemptService.RecordFailedMFA(ctx, userID); err != nil {
+			s.logger.WithError(err).Error("Failed to record failed MFA verification")
+		}
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Invalid MFA code")
+	}
+
+	if err := s.revocationStore.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return response.TokenResponse{}, err
+	}
+	if err := s.loginAttemptService.ResetMFA(ctx, userID); err != nil {
+		s.logger.WithError(err).Error("Failed to reset failed MFA counter")
+	}
+
+	return s.tokenService.IssueTokens(ctx, entity.User{
+		ID:    userID,
+		Email: user.Email,
+	})
+}
+
+// getFactor returns the user's factor, or an empty one when they never enrolled
+func (s *mfaService) getFactor(ctx context.Context, userID uuid.UUID) (entity.MFAFactor, error) {
+	factor, err := s.mfaRepo.GetMFAFactorByUserID(ctx, userID)
+	if isNotFound(err) {
+		return entity.MFAFactor{}, nil
+	}
+	if err != nil {
+		return entity.MFAFactor{}, err
+	}
+	return factor, nil
+}
+
+// generateRecoveryCodes returns the codes shown to the user and the hashed records to store
+func generateRecoveryCodes(userID uuid.UUID) ([]string, []entity.MFARecoveryCode, error) {
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	records := make([]entity.MFARecoveryCode, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		raw := make([]byte, 8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		records = append(records, entity.MFARecoveryCode{
+			UserID:   userID,
+			CodeHash: util.HashToken(code),
+		})
+	}
+	return codes, records, nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes with or without the dash and in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -5,4 +5,22 @@ import "go.uber.org/fx"
 var Module = fx.Options(
 	fx.Provide(NewAuthService),
 	fx.Provide(NewUserService),
+	fx.Provide(NewTokenService),
+	fx.Provide(NewPasswordService),
+	fx.Provide(NewVerificationService),
+	fx.Provide(NewMFAService),
+	fx.Provide(NewLoginAttemptService),
+	fx.Provide(NewOIDCService),
+	fx.Provide(NewAPIKeyService),
+	fx.Provide(NewSessionService),
+	fx.Provide(NewMagicLinkService),
+	fx.Provide(NewOAuthService),
+	fx.Provide(NewImpersonationService),
+	fx.Provide(NewAdminUserService),
+	fx.Provide(NewAccountStatusService),
+	fx.Provide(NewDataExportService),
+	fx.Provide(NewUserImportService),
+	fx.Provide(NewErasureService),
+	fx.Provide(NewOrganizationService),
+	fx.Provide(NewInvitationService),
 )
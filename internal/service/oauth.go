@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// oauthClientSecretMarker starts every client secret so leaked secrets are easy to recognise and scan for
+	oauthClientSecretMarker = "iecs_"
+	oauthClientIDBytes      = 16
+	oauthClientSecretBytes  = 32
+)
+
+// OAuthError is reported by the token endpoint in the RFC 6749 format instead of the usual response wrapper
+type OAuthError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(status int, code, description string) *OAuthError {
+	return &OAuthError{
+		Status:      status,
+		Code:        code,
+		Description: description,
+	}
+}
+
+// OAuthService defines the interface for registered OAuth clients and the client credentials grant
+type OAuthService interface {
+	CreateClient(ctx context.Context, req request.CreateOAuthClientRequest) (response.CreatedOAuthClientResponse, error)
+	ListClients(ctx context.Context) ([]response.OAuthClientResponse, error)
+	RevokeClient(ctx context.Context, id uuid.UUID) error
+	ClientCredentialsToken(ctx context.Context, req request.ClientCredentialsRequest) (response.OAuthTokenResponse, error)
+	IsClientActive(ctx context.Context, clientID string) (bool, error)
+	IntrospectToken(ctx context.Context, req request.IntrospectionRequest) (response.IntrospectionResponse, error)
+}
+
+// oauthService implements OAuthService
+type oauthService struct {
+	oauthClientRepo repository.OAuthClientRepo
+	revocationStore repository.TokenRevocationStore
+	sessionService  SessionService
+	accountService  AccountStatusService
+	keySet          *util.JWTKeySet
+	logger          *logger.StandardLogger
+	config          *config.Config
+}
+
+// NewOAuthService creates a new OAuth service
+func NewOAuthService(
+	oauthClientRepo repository.OAuthClientRepo,
+	revocationStore repository.TokenRevocationStore,
+	sessionService SessionService,
+	accountService AccountStatusService,
+	keySet *util.JWTKeySet,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) OAuthService {
+	return &oauthService{
+		oauthClientRepo: oauthClientRepo,
+		revocationStore: revocationStore,
+		sessionService:  sessionService,
+		accountService:  accountService,
+		keySet:          keySet,
+		logger:          logger,
+		config:          config,
+	}
+}
+
+// CreateClient registers a client. Scopes are permission names, and an admin can only grant the ones they hold.
+func (s *oauthService) CreateClient(
+	ctx context.Context,
+	req request.CreateOAuthClientRequest,
+) (response.CreatedOAuthClientResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.CreatedOAuthClientResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	granted := make(map[string]bool)
+	for _, permission := range util.PermissionsFromCTX(ctx) {
+		granted[permission] = true
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !granted[scope] {
+			return response.CreatedOAuthClientResponse{}, errors.NewForbiddenError("Scope not granted to user: " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	clientID, err := util.GenerateSecureToken(oauthClientIDBytes)
+	if err != nil {
+		return response.CreatedOAuthClientResponse{}, errors.NewInternalServerError("Failed to generate client credentials")
+	}
+	secret, err := util.GenerateSecureToken(oauthClientSecretBytes)
+	if err != nil {
+		return response.CreatedOAuthClientResponse{}, errors.NewInternalServerError("Failed to generate client credentials")
+	}
+	secret = oauthClientSecretMarker + secret
+
+	client := entity.OAuthClient{
+		ID:         uuid.New(),
+		ClientID:   clientID,
+		Name:       req.Name,
+		SecretHash: util.HashToken(secret),
+		Scopes:     strings.Join(scopes, " "),
+	}
+	now := time.Now()
+	client.CreatedAt = &now
+	client.CreatedBy = userID.String()
+	if err := s.oauthClientRepo.CreateOAuthClient(ctx, client); err != nil {
+		return response.CreatedOAuthClientResponse{}, err
+	}
+
+	s.logger.WithField("client_id", clientID).WithField("created_by", userID).Info("OAuth client registered")
+	return response.CreatedOAuthClientResponse{
+		OAuthClientResponse: toOAuthClientResponse(client),
+		ClientSecret:        secret,
+	}, nil
+}
+
+// ListClients returns the registered clients that have not been revoked, without their secrets
+func (s *oauthService) ListClients(ctx context.Context) ([]response.OAuthClientResponse, error) {
+	clients, err := s.oauthClientRepo.GetOAuthClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]response.OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, toOAuthClientResponse(client))
+	}
+	return resp, nil
+}
+
+// RevokeClient revokes a client. It can't get new tokens, and the tokens it holds stop being accepted.
+func (s *oauthService) RevokeClient(ctx context.Context, id uuid.UUID) error {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+
+	revoked, err := s.oauthClientRepo.RevokeOAuthClient(ctx, id, userID.String())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.NewNotFoundError("OAuth client not found")
+	}
+	s.logger.WithField("id", id).WithField("revoked_by", userID).Info("OAuth client revoked")
+	return nil
+}
+
+// ClientCredentialsToken implements the client credentials grant (RFC 6749 section 4.4).
+// Without a scope parameter the token carries every scope the client is allowed.
+func (s *oauthService) ClientCredentialsToken(
+	ctx context.Context,
+	req request.ClientCredentialsRequest,
+) (response.OAuthTokenResponse, error) {
+	if req.GrantType == "" {
+		return response.OAuthTokenResponse{}, newOAuthError(
+			http.StatusBadRequest, constant.OAuthErrorInvalidRequest, "grant_type is required",
+		)
+	}
+	if req.GrantType != constant.GrantTypeClientCredentials {
+		return response.OAuthTokenResponse{}, newOAuthError(
+			http.StatusBadRequest, constant.OAuthErrorUnsupportedGrantType, "Only the client_credentials grant is supported",
+		)
+	}
+	invalidClient := newOAuthError(http.StatusUnauthorized, constant.OAuthErrorInvalidClient, "Client authentication failed")
+	if req.ClientID == "" || req.ClientSecret == "" {
+		return response.OAuthTokenResponse{}, invalidClient
+	}
+
+	client, err := s.oauthClientRepo.GetOAuthClientByClientID(ctx, req.ClientID)
+	if isNotFound(err) {
+		return response.OAuthTokenResponse{}, invalidClient
+	}
+	if err != nil {
+		return response.OAuthTokenResponse{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(req.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		s.logger.WithField("client_id", req.ClientID).Info("OAuth client authentication failed")
+		return response.OAuthTokenResponse{}, invalidClient
+	}
+	if !client.IsActive() {
+		s.logger.WithField("client_id", req.ClientID).Info("Token requested by revoked OAuth client")
+		return response.OAuthTokenResponse{}, invalidClient
+	}
+
+	allowed := client.ScopeList()
+	scopes := allowed
+	if requested := strings.Fields(req.Scope); len(requested) > 0 {
+		allowedSet := make(map[string]bool)
+		for _, scope := range allowed {
+			allowedSet[scope] = true
+		}
+		scopes = make([]string, 0, len(requested))
+		seen := make(map[string]bool)
+		for _, scope := range requested {
+			if !allowedSet[scope] {
+				return response.OAuthTokenResponse{}, newOAuthError(
+					http.StatusBadRequest, constant.OAuthErrorInvalidScope, "Scope not allowed for client: "+scope,
+				)
+			}
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	now := time.Now()
+	ttl := s.config.Auth.OAuthClientTokenTTL
+	token, err := s.keySet.SignAccessToken(util.AccessClaims{
+		ClientID: client.ClientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   client.ClientID,
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to sign client token")
+		return response.OAuthTokenResponse{}, newOAuthError(
+			http.StatusInternalServerError, constant.OAuthErrorServerError, "Failed to issue token",
+		)
+	}
+
+	return response.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// IsClientActive implements util.ClientChecker
+func (s *oauthService) IsClientActive(ctx context.Context, clientID string) (bool, error) {
+	client, err := s.oauthClientRepo.GetOAuthClientByClientID(ctx, clientID)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return client.IsActive(), nil
+}
+
+// IntrospectToken describes an access token (RFC 7662). Besides the signature and expiry, the token
+// must not be revoked, its session and the user's account must still be active and, for client tokens,
+// its client still registered.
+// Refresh tokens and anything else we did not issue as an access token are reported inactive.
+func (s *oauthService) IntrospectToken(
+	ctx context.Context,
+	req request.IntrospectionRequest,
+) (response.IntrospectionResponse, error) {
+	inactive := response.IntrospectionResponse{Active: false}
+
+	claims, err := s.keySet.ParseAccessToken(req.Token)
+	if err != nil {
+		return inactive, nil
+	}
+
+	revoked, err := s.revocationStore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return inactive, err
+	}
+	if revoked {
+		return inactive, nil
+	}
+
+	resp := response.IntrospectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Exp:       claims.ExpiresAt.Unix(),
+		Iat:       claims.IssuedAt.Unix(),
+		Nbf:       claims.NotBefore.Unix(),
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+	}
+
+	if claims.IsClientToken() {
+		active, err := s.IsClientActive(ctx, claims.ClientID)
+		if err != nil {
+			return inactive, err
+		}
+		if !active {
+			return inactive, nil
+		}
+		resp.ClientID = claims.ClientID
+		resp.Scope = claims.Scope
+		return resp, nil
+	}
+
+	// Same check as JwtAuthMiddleware: suspended, deactivated and erased users' tokens stop working
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return inactive, nil
+	}
+	active, err := s.accountService.IsAccountActive(ctx, userID)
+	if err != nil {
+		return inactive, err
+	}
+	if !active {
+		return inactive, nil
+	}
+
+	if claims.SessionID != "" {
+		active, err := s.sessionService.IsSessionActive(ctx, claims.SessionID)
+		if err != nil {
+			return inactive, err
+		}
+		if !active {
+			return inactive, nil
+		}
+	}
+	resp.Username = claims.Email
+	resp.Email = claims.Email
+	resp.SessionID = claims.SessionID
+	// A user token may do what the user's permissions allow, which is what its scope means to a resource server
+	resp.Scope = strings.Join(claims.Permissions, " ")
+	return resp, nil
+}
+
+func toOAuthClientResponse(client entity.OAuthClient) response.OAuthClientResponse {
+	return response.OAuthClientResponse{
+		ID:        client.ID,
+		ClientID:  client.ClientID,
+		Name:      client.Name,
+		Scopes:    client.ScopeList(),
+		CreatedAt: client.CreatedAt,
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/oidc"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCService defines the interface for signing in with external OpenID Connect providers
+type OIDCService interface {
+	AuthorizationURL(ctx context.Context, provider string) (response.OIDCAuthorizationResponse, error)
+	Callback(ctx context.Context, provider string, req request.OIDCCallbackRequest) (response.TokenResponse, error)
+}
+
+// oidcService implements OIDCService
+type oidcService struct {
+	providers    *oidc.Registry
+	oidcRepo     repository.OIDCRepo
+	userRepo     repository.UserRepo
+	roleRepo     repository.RoleRepo
+	tokenService TokenService
+	mfaService   MFAService
+	logger       *logger.StandardLogger
+	config       *config.Config
+}
+
+// NewOIDCService creates a new OIDC service
+func NewOIDCService(
+	providers *oidc.Registry,
+	oidcRepo repository.OIDCRepo,
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	tokenService TokenService,
+	mfaService MFAService,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) OIDCService {
+	return &oidcService{
+		providers:    providers,
+		oidcRepo:     oidcRepo,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		tokenService: tokenService,
+		mfaService:   mfaService,
+		logger:       logger,
+		config:       config,
+	}
+}
+
+// AuthorizationURL starts an authorization-code login with PKCE at the provider.
+// The code verifier and nonce stay on the server; the browser only carries the state.
+func (s *oidcService) AuthorizationURL(
+	ctx context.Context,
+	providerName string,
+) (response.OIDCAuthorizationResponse, error) {
+	provider, ok := s.providers.Provider(providerName)
+	if !ok {
+		return response.OIDCAuthorizationResponse{}, errors.NewNotFoundError("Unknown identity provider")
+	}
+
+	state, errState := util.GenerateSecureToken(32)
+	nonce, errNonce := util.GenerateSecureToken(32)
+	codeVerifier, errVerifier := util.GenerateSecureToken(32)
+	if err := stderrors.Join(errState, errNonce, errVerifier); err != nil {
+		s.logger.WithError(err).Error("Failed to generate OIDC login state")
+		return response.OIDCAuthorizationResponse{}, errors.NewInternalServerError("Failed to start login")
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		s.logger.WithField("provider", providerName).WithError(err).Error("OIDC discovery failed")
+		return response.OIDCAuthorizationResponse{}, errors.NewInternalServerError("Identity provider is unavailable")
+	}
+
+	err = s.oidcRepo.CreateOIDCLoginState(ctx, entity.OIDCLoginState{
+		StateHash:    util.HashToken(state),
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(s.config.OIDC.StateTTL),
+	})
+	if err != nil {
+		return response.OIDCAuthorizationResponse{}, err
+	}
+
+	return response.OIDCAuthorizationResponse{
+		AuthorizationURL: authorizationURL,
+		State:            state,
+	}, nil
+}
+
+// Callback completes the login: the code is exchanged, the ID token validated and the
+// external identity resolved to a local user, who then receives our own tokens.
+func (s *oidcService) Callback(
+	ctx context.Context,
+	providerName string,
+	req request.OIDCCallbackRequest,
+) (response.TokenResponse, error) {
+	provider, ok := s.providers.Provider(providerName)
+	if !ok {
+		return response.TokenResponse{}, errors.NewNotFoundError("Unknown identity provider")
+	}
+
+	state, err := s.oidcRepo.ConsumeOIDCLoginState(ctx, util.HashToken(req.State))
+	if err != nil || state.Provider != providerName || time.Now().After(state.ExpiresAt) {
+		return response.TokenResponse{}, errors.NewBadRequestError("Invalid or expired login state")
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		s.logger.WithField("provider", providerName).WithError(err).Info("OIDC code exchange failed")
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Identity provider login failed")
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, state.Nonce)
+	if err != nil {
+		s.logger.WithField("provider", providerName).WithError(err).Info("OIDC ID token rejected")
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Identity provider login failed")
+	}
+
+	user, err := s.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if err := checkAccountActive(user); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	user = entity.User{ID: user.ID, Email: user.Email}
+	mfaToken, err := s.mfaService.ChallengeLogin(ctx, user)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if mfaToken != "" {
+		return response.TokenResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+	return s.tokenService.IssueTokens(ctx, user)
+}
+
+// resolveUser finds the user linked to the external identity. An unknown identity is linked to the
+// account with the same email, or a new account is created; both require the provider to have verified the email.
+// An account whose owner never verified the email is not linked: whoever registered it may not own the address,
+// and linking would hand them the account of the provider's user.
+func (s *oidcService) resolveUser(ctx context.Context, providerName string, claims oidc.IDTokenClaims) (entity.User, error) {
+	identity, err := s.oidcRepo.GetUserIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		return s.userRepo.GetUserByID(ctx, identity.UserID)
+	}
+	if !isNotFound(err) {
+		return entity.User{}, err
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !bool(claims.EmailVerified) {
+		return entity.User{}, errors.NewForbiddenError("The identity provider did not return a verified email address")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !user.IsEmailVerified() {
+			s.logger.WithField("provider", providerName).WithField("user_id", user.ID).
+				Info("External identity not linked, the account email is not verified")
+			return entity.User{}, errors.NewConflictError(
+				"An account with this email exists but its email address is not verified. " +
+					"Verify it from the email we sent, then sign in with the provider again",
+			)
+		}
+	case isNotFound(err):
+		user, err = s.createUser(ctx, claims, email)
+		if err != nil {
+			return entity.User{}, err
+		}
+	default:
+		return entity.User{}, err
+	}
+
+	err = s.oidcRepo.CreateUserIdentity(ctx, entity.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: email,
+		},
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	s.logger.WithField("provider", providerName).WithField("user_id", user.ID).Info("Linked external identity")
+	return user, nil
+}
+
+// createUser provisions an account for a first-time external login.
+// The password is random and never shown, the user can set one through the password reset flow.
+func (s *oidcService) createUser(ctx context.Context, claims oidc.IDTokenClaims, email string) (entity.User, error) {
+	password, err := util.GenerateSecureToken(32)
+	if err != nil {
+		return entity.User{}, errors.NewInternalServerError("Failed to create user")
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+	verifiedAt := time.Now()
+
+	user, err := s.userRepo.UserRegister(ctx, entity.User{
+		FirstName:       firstName,
+		LastName:        lastName,
+		Email:           email,
+		Password:        password,
+		EmailVerifiedAt: &verifiedAt,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: email,
+		},
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	if user.ID == uuid.Nil {
+		return entity.User{}, errors.NewInternalServerError("Failed to create user")
+	}
+
+	if err := s.roleRepo.AssignRole(ctx, user.ID, constant.RoleUser); err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// isNotFound reports whether err is an AppError for a missing record
+func isNotFound(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.As(err, &appErr) && appErr.Status == http.StatusNotFound
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationService defines the interface for managing organizations and their members.
+// Methods working on the active organization act in the tenant set by middleware.RequireOrganization.
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, req request.CreateOrganizationRequest) (response.MembershipResponse, error)
+	ListMemberships(ctx context.Context) ([]response.MembershipResponse, error)
+	SwitchOrganization(ctx context.Context, organizationID uuid.UUID) (response.SwitchOrganizationResponse, error)
+	GetOrganization(ctx context.Context) (response.OrganizationResponse, error)
+	UpdateOrganization(ctx context.Context, req request.UpdateOrganizationRequest) (response.OrganizationResponse, error)
+	ListMembers(ctx context.Context) ([]response.OrganizationMemberResponse, error)
+	UpdateMemberRole(
+		ctx context.Context,
+		userID uuid.UUID,
+		req request.UpdateMemberRoleRequest,
+	) (response.OrganizationMemberResponse, error)
+	RemoveMember(ctx context.Context, userID uuid.UUID) error
+	OrganizationRole(ctx context.Context, organizationID, userID uuid.UUID) (string, error)
+}
+
+// organizationService implements OrganizationService
+type organizationService struct {
+	organizationRepo repository.OrganizationRepo
+	userRepo         repository.UserRepo
+	sessionRepo      repository.SessionRepo
+	tokenService     TokenService
+	logger           *logger.StandardLogger
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(
+	organizationRepo repository.OrganizationRepo,
+	userRepo repository.UserRepo,
+	sessionRepo repository.SessionRepo,
+	tokenService TokenService,
+	logger *logger.StandardLogger,
+) OrganizationService {
+	return &organizationService{
+		organizationRepo: organizationRepo,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		tokenService:     tokenService,
+		logger:           logger,
+	}
+}
+
+// CreateOrganization creates an organization owned by the caller
+func (s *organizationService) CreateOrganization(
+	ctx context.Context,
+	req request.CreateOrganizationRequest,
+) (response.MembershipResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.MembershipResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	taken, err := s.organizationRepo.IsSlugTaken(ctx, req.Slug)
+	if err != nil {
+		return response.MembershipResponse{}, err
+	}
+	if taken {
+		return response.MembershipResponse{}, errors.NewConflictError("Slug is already taken")
+	}
+
+	organization := entity.Organization{
+		ID:   uuid.New(),
+		Name: req.Name,
+		Slug: req.Slug,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: userID.String(),
+		},
+	}
+	owner := entity.OrganizationMember{
+		UserID: userID,
+		Role:   constant.OrganizationRoleOwner,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: userID.String(),
+		},
+	}
+	if err := s.organizationRepo.CreateOrganization(ctx, organization, owner); err != nil {
+		s.logger.WithError(err).Error("Failed to create organization")
+		return response.MembershipResponse{}, err
+	}
+
+	s.logger.
+		WithField("organization_id", organization.ID).
+		WithField("user_id", userID).
+		Info("Organization created")
+
+	return response.MembershipResponse{
+		Organization: toOrganizationResponse(organization),
+		Role:         owner.Role,
+	}, nil
+}
+
+// ListMemberships lists the organizations the caller belongs to, marking the one their token works in
+func (s *organizationService) ListMemberships(ctx context.Context) ([]response.MembershipResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return nil, errors.NewBadRequestError("User ID is not found")
+	}
+
+	memberships, err := s.organizationRepo.GetUserMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeID := util.OrganizationIDFromCTX(ctx)
+	resp := make([]response.MembershipResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		resp = append(resp, response.MembershipResponse{
+			Organization: toOrganizationResponse(membership.Organization),
+			Role:         membership.Role,
+			Active:       membership.OrganizationID == activeID,
+		})
+	}
+	return resp, nil
+}
+
+// SwitchOrganization makes the caller's session work in another of their organizations
+// and returns an access token for it
+func (s *organizationService) SwitchOrganization(
+	ctx context.Context,
+	organizationID uuid.UUID,
+) (response.SwitchOrganizationResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return response.SwitchOrganizationResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+	sessionID := util.SessionIDFromCTX(ctx)
+	if sessionID == uuid.Nil {
+		return response.SwitchOrganizationResponse{}, errors.NewBadRequestError("Only a login session can switch organization")
+	}
+
+	membership, err := s.organizationRepo.GetUserMembership(ctx, organizationID, userID)
+	if isNotFound(err) {
+		return response.SwitchOrganizationResponse{}, errors.NewForbiddenError("Not a member of the organization")
+	}
+	if err != nil {
+		return response.SwitchOrganizationResponse{}, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return response.SwitchOrganizationResponse{}, err
+	}
+
+	if err := s.sessionRepo.SetSessionOrganization(ctx, sessionID, &organizationID); err != nil {
+		s.logger.WithError(err).Error("Failed to update session organization")
+		return response.SwitchOrganizationResponse{}, err
+	}
+	token, err := s.tokenService.IssueAccessToken(ctx, user, sessionID, &organizationID)
+	if err != nil {
+		return response.SwitchOrganizationResponse{}, err
+	}
+
+	return response.SwitchOrganizationResponse{
+		Token:          token,
+		OrganizationID: organizationID,
+		Role:           membership.Role,
+	}, nil
+}
+
+// GetOrganization returns the active organization
+func (s *organizationService) GetOrganization(ctx context.Context) (response.OrganizationResponse, error) {
+	organization, err := s.organizationRepo.GetOrganizationByID(ctx, util.TenantIDFromCTX(ctx))
+	if err != nil {
+		return response.OrganizationResponse{}, err
+	}
+	return toOrganizationResponse(organization), nil
+}
+
+// UpdateOrganization renames the active organization. The slug never changes.
+func (s *organizationService) UpdateOrganization(
+	ctx context.Context,
+	req request.UpdateOrganizationRequest,
+) (response.OrganizationResponse, error) {
+	organizationID := util.TenantIDFromCTX(ctx)
+	err := s.organizationRepo.UpdateOrganization(ctx, organizationID, req.Name, util.UserIDFromCTX(ctx).String())
+	if err != nil {
+		return response.OrganizationResponse{}, err
+	}
+	return s.GetOrganization(ctx)
+}
+
+// ListMembers lists the members of the active organization
+func (s *organizationService) ListMembers(ctx context.Context) ([]response.OrganizationMemberResponse, error) {
+	members, err := s.organizationRepo.GetMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]response.OrganizationMemberResponse, 0, len(members))
+	for _, member := range members {
+		resp = append(resp, toOrganizationMemberResponse(member))
+	}
+	return resp, nil
+}
+
+// UpdateMemberRole changes the role of a member of the active organization.
+// Only owners can change an owner or make someone an owner, and the last owner can't be demoted.
+func (s *organizationService) UpdateMemberRole(
+	ctx context.Context,
+	userID uuid.UUID,
+	req request.UpdateMemberRoleRequest,
+) (response.OrganizationMemberResponse, error) {
+	member, err := s.organizationRepo.GetMember(ctx, userID)
+	if err != nil {
+		return response.OrganizationMemberResponse{}, err
+	}
+	if member.Role == req.Role {
+		return toOrganizationMemberResponse(member), nil
+	}
+	if err := checkCanAssign(ctx, member.Role); err != nil {
+		return response.OrganizationMemberResponse{}, err
+	}
+	if err := checkCanAssign(ctx, req.Role); err != nil {
+		return response.OrganizationMemberResponse{}, err
+	}
+	if member.Role == constant.OrganizationRoleOwner {
+		if err := s.checkOtherOwner(ctx); err != nil {
+			return response.OrganizationMemberResponse{}, err
+		}
+	}
+
+	updated, err := s.organizationRepo.UpdateMemberRole(ctx, userID, req.Role, util.UserIDFromCTX(ctx).String())
+	if err != nil {
+		return response.OrganizationMemberResponse{}, err
+	}
+	if !updated {
+		return response.OrganizationMemberResponse{}, errors.NewNotFoundError("Member not found")
+	}
+
+	member.Role = req.Role
+	return toOrganizationMemberResponse(member), nil
+}
+
+// RemoveMember removes a member from the active organization.
+// Only owners can remove an owner, and the last owner can't be removed.
+func (s *organizationService) RemoveMember(ctx context.Context, userID uuid.UUID) error {
+	member, err := s.organizationRepo.GetMember(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkCanAssign(ctx, member.Role); err != nil {
+		return err
+	}
+	if member.Role == constant.OrganizationRoleOwner {
+		if err := s.checkOtherOwner(ctx); err != nil {
+			return err
+		}
+	}
+
+	removed, err := s.organizationRepo.RemoveMember(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.NewNotFoundError("Member not found")
+	}
+
+	s.logger.
+		WithField("organization_id", util.TenantIDFromCTX(ctx)).
+		WithField("user_id", userID).
+		Info("Organization member removed")
+	return nil
+}
+
+// OrganizationRole implements util.MembershipChecker.
+// It returns a NotFound error when the user is not a member of the organization.
+func (s *organizationService) OrganizationRole(ctx context.Context, organizationID, userID uuid.UUID) (string, error) {
+	membership, err := s.organizationRepo.GetUserMembership(ctx, organizationID, userID)
+	if err != nil {
+		return "", err
+	}
+	return membership.Role, nil
+}
+
+// checkCanAssign refuses changes involving the owner role unless the caller is an owner
+func checkCanAssign(ctx context.Context, role string) error {
+	if role == constant.OrganizationRoleOwner && util.OrgRoleFromCTX(ctx) != constant.OrganizationRoleOwner {
+		return errors.NewForbiddenError("Only owners can grant or change the owner role")
+	}
+	return nil
+}
+
+// checkOtherOwner refuses to demote or remove an owner when they are the only one
+func (s *organizationService) checkOtherOwner(ctx context.Context) error {
+	owners, err := s.organizationRepo.CountOwners(ctx)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.NewConflictError("The organization must keep at least one owner")
+	}
+	return nil
+}
+
+func toOrganizationResponse(organization entity.Organization) response.OrganizationResponse {
+	return response.OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Slug:      organization.Slug,
+		CreatedAt: organization.CreatedAt,
+	}
+}
+
+func toOrganizationMemberResponse(member entity.OrganizationMember) response.OrganizationMemberResponse {
+	return response.OrganizationMemberResponse{
+		UserID:    member.UserID,
+		Email:     member.User.Email,
+		FirstName: member.User.FirstName,
+		LastName:  member.User.LastName,
+		Role:      member.Role,
+		JoinedAt:  member.CreatedAt,
+	}
+}
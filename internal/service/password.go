@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/password"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordService defines the interface for password recovery operations
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, req request.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req request.ResetPasswordRequest) error
+	ChangePassword(ctx context.Context, req request.ChangePasswordRequest) error
+}
+
+// passwordService implements PasswordService
+type passwordService struct {
+	userRepo               repository.UserRepo
+	passwordResetTokenRepo repository.PasswordResetTokenRepo
+	refreshTokenRepo       repository.RefreshTokenRepo
+	apiKeyRepo             repository.APIKeyRepo
+	loginAttemptService    LoginAttemptService
+	notifier               notifier.Notifier
+	passwordPolicy         *password.Policy
+	logger                 *logger.StandardLogger
+	config                 *config.Config
+}
+
+// NewPasswordService creates a new password service
+func NewPasswordService(
+	userRepo repository.UserRepo,
+	passwordResetTokenRepo repository.PasswordResetTokenRepo,
+	refreshTokenRepo repository.RefreshTokenRepo,
+	apiKeyRepo repository.APIKeyRepo,
+	loginAttemptService LoginAttemptService,
+	notifier notifier.Notifier,
+	passwordPolicy *password.Policy,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) PasswordService {
+	return &passwordService{
+		userRepo:               userRepo,
+		passwordResetTokenRepo: passwordResetTokenRepo,
+		refreshTokenRepo:       refreshTokenRepo,
+		apiKeyRepo:             apiKeyRepo,
+		loginAttemptService:    loginAttemptService,
+		notifier:               notifier,
+		passwordPolicy:         passwordPolicy,
+		logger:                 logger,
+		config:                 config,
+	}
+}
+
+// ForgotPassword mails a single-use reset link to the account owner.
+// It succeeds whether or not the email is registered so callers cannot probe for accounts.
+func (s *passwordService) ForgotPassword(ctx context.Context, req request.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.WithField("email", req.Email).WithError(err).Info("Password reset requested for unknown email")
+		return nil
+	}
+
+	// Only the most recent link should work
+	if err := s.passwordResetTokenRepo.InvalidateUserPasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := util.GenerateSecureToken(32)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to generate password reset token")
+		return errors.NewInternalServerError("Failed to generate password reset token")
+	}
+
+	err = s.passwordResetTokenRepo.CreatePasswordResetToken(ctx, entity.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.Auth.PasswordResetTokenTTL),
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: user.Email,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.config.Auth.PasswordResetURL, url.QueryEscape(token))
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\n"+
+				"If you did not ask to reset your password you can ignore this message.",
+			user.FirstName, s.config.Auth.PasswordResetTokenTTL, link,
+		),
+	})
+	if err != nil {
+		s.logger.WithField("email", user.Email).WithError(err).Error("Failed to send password reset message")
+	}
+	return nil
+}
+
+// ResetPassword redeems a reset token and sets the new password.
+// Every refresh token and API key of the user is revoked, so whoever knew the old password is locked out.
+func (s *passwordService) ResetPassword(ctx context.Context, req request.ResetPasswordRequest) error {
+	invalidToken := errors.NewBadRequestError("Invalid or expired password reset token")
+
+	resetToken, err := s.passwordResetTokenRepo.GetPasswordResetTokenByHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		return invalidToken
+	}
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return invalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		return invalidToken
+	}
+	// Checked before the token is spent so the user can retry with a better password
+	err = s.passwordPolicy.Validate("password", req.Password, user.Email, user.FirstName, user.LastName)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.passwordResetTokenRepo.MarkPasswordResetTokenUsed(ctx, resetToken.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalidToken
+	}
+
+	user.Password = req.Password
+	user.UpdatedBy = user.Email
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to reset password")
+		return err
+	}
+
+	if err := s.passwordResetTokenRepo.InvalidateUserPasswordResetTokens(ctx, user.ID); err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to invalidate password reset tokens")
+	}
+	if err := s.refreshTokenRepo.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to revoke refresh tokens")
+	}
+	if err := s.apiKeyRepo.RevokeUserAPIKeys(ctx, user.ID); err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to revoke API keys")
+	}
+	return nil
+}
+
+// ChangePassword sets a new password for the logged-in user after checking the current one.
+// Wrong current passwords count as failed logins, so a stolen access token can't be used to guess the password.
+// Every other session of the user is revoked; the one making the request stays logged in.
+func (s *passwordService) ChangePassword(ctx context.Context, req request.ChangePasswordRequest) error {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	clientIP := util.ClientIPFromCTX(ctx)
+	if err := s.loginAttemptService.CheckLogin(ctx, user.Email, clientIP); err != nil {
+		return err
+	}
+	validatedID, err := s.userRepo.ValidateUser(entity.User{Email: user.Email, Password: req.CurrentPassword})
+	if appErr, ok := err.(*errors.AppError); err != nil && (!ok || appErr.Status != http.StatusUnauthorized) {
+		return err
+	}
+	if validatedID != user.ID {
+		if err := s.loginAttemptService.RecordFailedLogin(ctx, user.Email, clientIP); err != nil {
+			s.logger.WithError(err).Error("Failed to record failed login")
+		}
+		return errors.NewForbiddenError("Current password is incorrect")
+	}
+	if err := s.loginAttemptService.ResetLogin(ctx, user.Email); err != nil {
+		s.logger.WithError(err).Error("Failed to reset failed login counters")
+	}
+	err = s.passwordPolicy.Validate("password", req.Password, user.Email, user.FirstName, user.LastName)
+	if err != nil {
+		return err
+	}
+
+	user.Password = req.Password
+	user.UpdatedBy = user.Email
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to change password")
+		return err
+	}
+
+	if err := s.passwordResetTokenRepo.InvalidateUserPasswordResetTokens(ctx, user.ID); err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to invalidate password reset tokens")
+	}
+	if sessionID := util.SessionIDFromCTX(ctx); sessionID != uuid.Nil {
+		err = s.refreshTokenRepo.RevokeOtherUserRefreshTokens(ctx, user.ID, sessionID)
+	} else {
+		err = s.refreshTokenRepo.RevokeUserRefreshTokens(ctx, user.ID)
+	}
+	if err != nil {
+		s.logger.WithField("user_id", user.ID).WithError(err).Error("Failed to revoke other sessions")
+		return err
+	}
+	return nil
+}
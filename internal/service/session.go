@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sessionLastSeenResolution limits how often last_seen_at is written for an active session
+const sessionLastSeenResolution = time.Minute
+
+// SessionService defines the interface for listing and revoking a user's login sessions
+type SessionService interface {
+	ListSessions(ctx context.Context) ([]response.SessionResponse, error)
+	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// sessionService implements SessionService
+type sessionService struct {
+	sessionRepo      repository.SessionRepo
+	refreshTokenRepo repository.RefreshTokenRepo
+	logger           *logger.StandardLogger
+	config           *config.Config
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(
+	sessionRepo repository.SessionRepo,
+	refreshTokenRepo repository.RefreshTokenRepo,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) SessionService {
+	return &sessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		logger:           logger,
+		config:           config,
+	}
+}
+
+// ListSessions returns the caller's active sessions and flags the one making the request
+func (s *sessionService) ListSessions(ctx context.Context) ([]response.SessionResponse, error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return nil, errors.NewBadRequestError("User ID is not found")
+	}
+
+	sessions, err := s.sessionRepo.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	currentID := util.SessionIDFromCTX(ctx)
+	resp := make([]response.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, response.SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		})
+	}
+	return resp, nil
+}
+
+// RevokeSession ends one of the caller's sessions: its refresh tokens stop working and
+// its access tokens are rejected on the next request
+func (s *sessionService) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return errors.NewBadRequestError("User ID is not found")
+	}
+
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// Someone else's session is reported as missing so IDs cannot be probed
+	if session.UserID != userID || !session.IsActive(time.Now()) {
+		return errors.NewNotFoundError("Session not found")
+	}
+
+	if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, session.ID); err != nil {
+		return err
+	}
+	s.logger.
+		WithContext(ctx).
+		WithField("user_id", userID).
+		WithField("session_id", session.ID).
+		Info("Session revoked")
+	return nil
+}
+
+// IsSessionActive implements util.SessionChecker for JwtAuthMiddleware and records that the session was seen
+func (s *sessionService) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return false, nil
+	}
+
+	session, err := s.sessionRepo.GetSessionByID(ctx, id)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if session.RevokedAt != nil {
+		return false, nil
+	}
+
+	seenBefore := now.Add(-sessionLastSeenResolution)
+	if session.LastSeenAt.Before(seenBefore) {
+		if err := s.sessionRepo.TouchSession(ctx, session.ID, seenBefore); err != nil {
+			s.logger.
+				WithContext(ctx).
+				WithError(err).
+				WithField("session_id", session.ID).
+				Warn("Failed to record session activity")
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,194 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountStatusService_ChangeState tests the allowed status transitions, that leaving the
+// active state ends the user's sessions and that nothing changes without an audit record
+func TestAccountStatusService_ChangeState(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+	req := request.ChangeUserStateRequest{Reason: "Chargeback fraud"}
+
+	userIn := func(state string) entity.User {
+		return entity.User{ID: uuid.New(), Email: "user@example.com", State: enum.EnumStateDB(state)}
+	}
+	suspend := func(s service.AccountStatusService, userID uuid.UUID) error {
+		_, err := s.SuspendUser(ctx, userID, req)
+		return err
+	}
+	reactivate := func(s service.AccountStatusService, userID uuid.UUID) error {
+		_, err := s.ReactivateUser(ctx, userID, req)
+		return err
+	}
+	deactivate := func(s service.AccountStatusService, userID uuid.UUID) error {
+		_, err := s.DeactivateUser(ctx, userID, req)
+		return err
+	}
+
+	testCases := []struct {
+		name          string
+		user          entity.User
+		change        func(service.AccountStatusService, uuid.UUID) error
+		action        string
+		to            string
+		auditErr      error
+		expectedError error
+	}{
+		{
+			name:   "active user is suspended",
+			user:   userIn(enum.StateActive),
+			change: suspend,
+			action: constant.AuditActionUserSuspend,
+			to:     enum.StateSuspended,
+		},
+		{
+			name:   "suspended user is reactivated",
+			user:   userIn(enum.StateSuspended),
+			change: reactivate,
+			action: constant.AuditActionUserReactivate,
+			to:     enum.StateActive,
+		},
+		{
+			name:   "pending user is deactivated",
+			user:   userIn(enum.StatePending),
+			change: deactivate,
+			action: constant.AuditActionUserDeactivate,
+			to:     enum.StateDeactivated,
+		},
+		{
+			name:          "pending user can't be suspended",
+			user:          userIn(enum.StatePending),
+			change:        suspend,
+			expectedError: errors.NewConflictError("Account is pending and can't become suspended"),
+		},
+		{
+			name:          "active user can't be reactivated",
+			user:          userIn(enum.StateActive),
+			change:        reactivate,
+			expectedError: errors.NewConflictError("Account is active and can't become active"),
+		},
+		{
+			name:          "no change without an audit record",
+			user:          userIn(enum.StateActive),
+			change:        suspend,
+			action:        constant.AuditActionUserSuspend,
+			auditErr:      errors.NewInternalServerError("Database error: connection refused"),
+			expectedError: errors.NewInternalServerError("Database error: connection refused"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			mockAuditLogRepo := new(MockAuditLogRepo)
+			mockUserRepo.On("GetUserByID", mock.Anything, tc.user.ID).Return(tc.user, nil)
+			if tc.action != "" {
+				mockAuditLogRepo.On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(log entity.AuditLog) bool {
+					return log.ActorID == adminID &&
+						log.TargetID == tc.user.ID &&
+						log.Action == tc.action &&
+						log.Reason == req.Reason
+				})).Return(tc.auditErr)
+			}
+			if tc.expectedError == nil {
+				mockUserRepo.On("UpdateUserState", mock.Anything, tc.user.ID, tc.user.StateName(), tc.to, adminID.String()).
+					Return(true, nil)
+				if tc.to != enum.StateActive {
+					mockRefreshTokenRepo.On("RevokeUserRefreshTokens", mock.Anything, tc.user.ID).Return(nil)
+				}
+			}
+
+			accountStatusService := service.NewAccountStatusService(
+				mockUserRepo,
+				newMockRoleRepo(),
+				mockRefreshTokenRepo,
+				mockAuditLogRepo,
+				logger.NewLogger(mockConfig),
+			)
+			err := tc.change(accountStatusService, tc.user.ID)
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			mockUserRepo.AssertExpectations(t)
+			mockRefreshTokenRepo.AssertExpectations(t)
+			mockAuditLogRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("own account", func(t *testing.T) {
+		t.Parallel()
+
+		accountStatusService := service.NewAccountStatusService(
+			new(MockUserRepo),
+			newMockRoleRepo(),
+			new(MockRefreshTokenRepo),
+			new(MockAuditLogRepo),
+			logger.NewLogger(mockConfig),
+		)
+		err := suspend(accountStatusService, adminID)
+		require.Error(t, err)
+		assert.Equal(t, errors.NewBadRequestError("You can't change the status of your own account").Error(), err.Error())
+	})
+}
+
+// TestAccountStatusService_IsAccountActive tests that only active, existing accounts are reported active
+func TestAccountStatusService_IsAccountActive(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	active := entity.User{ID: uuid.New()}
+	suspended := entity.User{ID: uuid.New(), State: enum.EnumStateDB(enum.StateSuspended)}
+	deleted := uuid.New()
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetUserByID", mock.Anything, active.ID).Return(active, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, suspended.ID).Return(suspended, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, deleted).Return(entity.User{}, errors.NewNotFoundError("User not found"))
+
+	accountStatusService := service.NewAccountStatusService(
+		mockUserRepo,
+		newMockRoleRepo(),
+		new(MockRefreshTokenRepo),
+		new(MockAuditLogRepo),
+		logger.NewLogger(mockConfig),
+	)
+
+	ok, err := accountStatusService.IsAccountActive(context.Background(), active.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = accountStatusService.IsAccountActive(context.Background(), suspended.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = accountStatusService.IsAccountActive(context.Background(), deleted)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
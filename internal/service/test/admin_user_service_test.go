@@ -0,0 +1,307 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdminUserTestService(
+	userRepo *MockUserRepo,
+	refreshTokenRepo *MockRefreshTokenRepo,
+) service.AdminUserService {
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	return service.NewAdminUserService(userRepo, newMockRoleRepo(), refreshTokenRepo, logger.NewLogger(mockConfig))
+}
+
+// TestAdminUserService_ListUsers tests that the user list is returned in a paginated envelope
+func TestAdminUserService_ListUsers(t *testing.T) {
+	t.Parallel()
+
+	users := []entity.User{
+		{ID: uuid.New(), Email: "a@example.com", Roles: []entity.Role{{Name: constant.RoleAdmin}}},
+		{ID: uuid.New(), Email: "b@example.com"},
+	}
+	req := request.UserFilterRequest{
+		BaseFilterRequest: request.BaseFilterRequest{PageSize: 2, PageIndex: 1, Names: "example"},
+	}
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetUsers", mock.Anything, req).Return(users, int64(5), nil)
+
+	resp, err := newAdminUserTestService(mockUserRepo, new(MockRefreshTokenRepo)).
+		ListUsers(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.PageIndex)
+	assert.Equal(t, 2, resp.PageSize)
+	assert.Equal(t, int64(5), resp.Total)
+	assert.Equal(t, int64(3), resp.TotalPages)
+	items, ok := resp.Items.([]response.AdminUserResponse)
+	require.True(t, ok)
+	require.Len(t, items, 2)
+	assert.Equal(t, users[0].ID, items[0].ID)
+	assert.Equal(t, []string{constant.RoleAdmin}, items[0].Roles)
+	assert.Empty(t, items[1].Roles)
+}
+
+// TestAdminUserService_UpdateUser tests partial updates and that a changed email must be verified again
+func TestAdminUserService_UpdateUser(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	verifiedAt := time.Now()
+	stringPtr := func(value string) *string { return &value }
+
+	testCases := []struct {
+		name          string
+		req           request.AdminUpdateUserRequest
+		setupMocks    func(*MockUserRepo, entity.User)
+		expectedError error
+		check         func(*testing.T, entity.User)
+	}{
+		{
+			name: "name only",
+			req:  request.AdminUpdateUserRequest{FirstName: stringPtr("Jane")},
+			check: func(t *testing.T, updated entity.User) {
+				assert.Equal(t, "Jane", updated.FirstName)
+				assert.Equal(t, "Doe", updated.LastName)
+				assert.Equal(t, "john@example.com", updated.Email)
+				assert.NotNil(t, updated.EmailVerifiedAt)
+			},
+		},
+		{
+			name: "new email is unverified",
+			req:  request.AdminUpdateUserRequest{Email: stringPtr("jane@example.com")},
+			setupMocks: func(userRepo *MockUserRepo, _ entity.User) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "jane@example.com").Return(nil)
+			},
+			check: func(t *testing.T, updated entity.User) {
+				assert.Equal(t, "jane@example.com", updated.Email)
+				assert.Nil(t, updated.EmailVerifiedAt)
+			},
+		},
+		{
+			name: "email taken",
+			req:  request.AdminUpdateUserRequest{Email: stringPtr("taken@example.com")},
+			setupMocks: func(userRepo *MockUserRepo, _ entity.User) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "taken@example.com").
+					Return(errors.NewConflictError("Email already exists"))
+			},
+			expectedError: errors.NewConflictError("Email already exists"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := entity.User{
+				ID:              uuid.New(),
+				FirstName:       "John",
+				LastName:        "Doe",
+				Email:           "john@example.com",
+				EmailVerifiedAt: &verifiedAt,
+			}
+			mockUserRepo := new(MockUserRepo)
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+			var updated entity.User
+			mockUserRepo.On("UpdateUserProfile", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					updated = args.Get(1).(entity.User)
+				}).
+				Return(nil)
+			if tc.setupMocks != nil {
+				tc.setupMocks(mockUserRepo, user)
+			}
+
+			ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+			resp, err := newAdminUserTestService(mockUserRepo, new(MockRefreshTokenRepo)).
+				UpdateUser(ctx, user.ID, tc.req)
+			if tc.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				mockUserRepo.AssertNotCalled(t, "UpdateUserProfile", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, adminID.String(), updated.UpdatedBy)
+			assert.Equal(t, updated.Email, resp.Email)
+			tc.check(t, updated)
+		})
+	}
+}
+
+// TestAdminUserService_DeleteUser tests that deleting a user records who did it and ends their sessions
+func TestAdminUserService_DeleteUser(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+
+	t.Run("user is deleted and logged out", func(t *testing.T) {
+		t.Parallel()
+
+		userID := uuid.New()
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("DeleteUser", mock.Anything, mock.MatchedBy(func(user entity.User) bool {
+			return user.ID == userID && user.DeletedBy == adminID.String()
+		})).Return(nil)
+		mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+		mockRefreshTokenRepo.On("RevokeUserRefreshTokens", mock.Anything, userID).Return(nil)
+
+		err := newAdminUserTestService(mockUserRepo, mockRefreshTokenRepo).DeleteUser(ctx, userID)
+		require.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+		mockRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("admins can't delete themselves", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		err := newAdminUserTestService(mockUserRepo, new(MockRefreshTokenRepo)).DeleteUser(ctx, adminID)
+		assert.Error(t, err)
+		assert.Equal(t, "You can't delete your own account", err.Error())
+		mockUserRepo.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	})
+}
+
+// TestAdminUserService_RestoreUser tests that only deleted users can be restored
+func TestAdminUserService_RestoreUser(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+
+	t.Run("deleted user is restored", func(t *testing.T) {
+		t.Parallel()
+
+		user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("RestoreUser", mock.Anything, user.ID, adminID.String()).Return(true, nil)
+		mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+
+		resp, err := newAdminUserTestService(mockUserRepo, new(MockRefreshTokenRepo)).RestoreUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, resp.ID)
+		assert.Nil(t, resp.DeletedAt)
+	})
+
+	t.Run("user is not deleted", func(t *testing.T) {
+		t.Parallel()
+
+		userID := uuid.New()
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("RestoreUser", mock.Anything, userID, adminID.String()).Return(false, nil)
+
+		_, err := newAdminUserTestService(mockUserRepo, new(MockRefreshTokenRepo)).RestoreUser(ctx, userID)
+		assert.Error(t, err)
+		assert.Equal(t, errors.NewNotFoundError("Deleted user not found").Error(), err.Error())
+	})
+}
+
+// TestAdminUserService_ExportUsers tests that every batch of users is written as CSV or as a JSON array,
+// oldest first by default
+func TestAdminUserService_ExportUsers(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	batches := [][]entity.User{
+		{
+			{
+				ID: uuid.New(), Email: "a@example.com", FirstName: "=cmd", LastName: "Doe",
+				EmailVerifiedAt: &createdAt,
+				Roles:           []entity.Role{{Name: constant.RoleAdmin}, {Name: constant.RoleUser}},
+				BaseEntity:      entity.BaseEntity{CreatedAt: &createdAt},
+			},
+		},
+		{
+			{ID: uuid.New(), Email: "b@example.com", FirstName: "Jo, Jr", LastName: "Roe"},
+		},
+	}
+	expectedFilter := request.UserFilterRequest{
+		BaseFilterRequest: request.BaseFilterRequest{Names: "example", Sort: "created_at"},
+	}
+
+	newRepo := func() *MockUserRepo {
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("GetUsersInBatches", mock.Anything, expectedFilter, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				fn := args.Get(3).(func(users []entity.User) error)
+				for _, batch := range batches {
+					require.NoError(t, fn(batch))
+				}
+			}).
+			Return(nil)
+		return mockUserRepo
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		err := newAdminUserTestService(newRepo(), new(MockRefreshTokenRepo)).ExportUsers(
+			context.Background(),
+			request.UserExportRequest{
+				UserFilterRequest: request.UserFilterRequest{BaseFilterRequest: request.BaseFilterRequest{Names: "example"}},
+				Format:            constant.UserExportFormatCSV,
+			},
+			&out,
+		)
+		require.NoError(t, err)
+
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{
+			"id", "email", "first_name", "last_name", "email_verified", "status", "roles", "created_at", "deleted_at",
+		}, records[0])
+		assert.Equal(t, []string{
+			batches[0][0].ID.String(), "a@example.com", "'=cmd", "Doe", "true", enum.StateActive,
+			"admin;user", "2024-03-01T12:00:00Z", "",
+		}, records[1])
+		assert.Equal(t, "Jo, Jr", records[2][2])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		err := newAdminUserTestService(newRepo(), new(MockRefreshTokenRepo)).ExportUsers(
+			context.Background(),
+			request.UserExportRequest{
+				UserFilterRequest: request.UserFilterRequest{BaseFilterRequest: request.BaseFilterRequest{Names: "example"}},
+				Format:            constant.UserExportFormatJSON,
+			},
+			&out,
+		)
+		require.NoError(t, err)
+
+		var users []response.AdminUserResponse
+		require.NoError(t, json.Unmarshal(out.Bytes(), &users))
+		require.Len(t, users, 2)
+		assert.Equal(t, batches[0][0].ID, users[0].ID)
+		assert.Equal(t, []string{constant.RoleAdmin, constant.RoleUser}, users[0].Roles)
+		assert.Equal(t, "Jo, Jr", users[1].FirstName)
+	})
+}
@@ -0,0 +1,176 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPIKeyService_CreateAndAuthenticate tests that a created key authenticates its owner with the granted scopes
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	mockLogger := logger.NewLogger(mockConfig)
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+	userRole := entity.Role{
+		Name: constant.RoleUser,
+		Permissions: []entity.Permission{
+			{Name: constant.PermissionProfileRead},
+			{Name: constant.PermissionProfileWrite},
+		},
+	}
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+
+	testCases := []struct {
+		name        string
+		scopes      []string
+		tamper      func(key *entity.APIKey, presented string) string
+		rolesAtUse  []entity.Role
+		expectErr   int
+		expectPerms []string
+	}{
+		{
+			name:        "valid key",
+			scopes:      []string{constant.PermissionProfileRead},
+			rolesAtUse:  []entity.Role{userRole},
+			expectPerms: []string{constant.PermissionProfileRead},
+		},
+		{
+			name:       "scope the user lost is dropped",
+			scopes:     []string{constant.PermissionProfileRead, constant.PermissionProfileWrite},
+			rolesAtUse: []entity.Role{{Name: "viewer", Permissions: []entity.Permission{{Name: constant.PermissionProfileRead}}}},
+			expectPerms: []string{
+				constant.PermissionProfileRead,
+			},
+		},
+		{
+			name:   "wrong secret",
+			scopes: []string{constant.PermissionProfileRead},
+			tamper: func(key *entity.APIKey, presented string) string {
+				return presented[:len(presented)-4] + "AAAA"
+			},
+			expectErr: http.StatusUnauthorized,
+		},
+		{
+			name:   "malformed key",
+			scopes: []string{constant.PermissionProfileRead},
+			tamper: func(key *entity.APIKey, presented string) string {
+				return strings.TrimPrefix(presented, "iek_")
+			},
+			expectErr: http.StatusUnauthorized,
+		},
+		{
+			name:   "revoked key",
+			scopes: []string{constant.PermissionProfileRead},
+			tamper: func(key *entity.APIKey, presented string) string {
+				revokedAt := time.Now().Add(-time.Minute)
+				key.RevokedAt = &revokedAt
+				return presented
+			},
+			expectErr: http.StatusUnauthorized,
+		},
+		{
+			name:   "expired key",
+			scopes: []string{constant.PermissionProfileRead},
+			tamper: func(key *entity.APIKey, presented string) string {
+				expiresAt := time.Now().Add(-time.Minute)
+				key.ExpiresAt = &expiresAt
+				return presented
+			},
+			expectErr: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockAPIKeyRepo := new(MockAPIKeyRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockRoleRepo := new(MockRoleRepo)
+			var stored entity.APIKey
+			mockAPIKeyRepo.On("CreateAPIKey", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					stored = args.Get(1).(entity.APIKey)
+				}).
+				Return(nil)
+			mockRoleRepo.On("GetRolesByUserID", mock.Anything, user.ID).Return([]entity.Role{userRole}, nil).Once()
+			apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo, mockUserRepo, mockRoleRepo, mockLogger, mockConfig)
+
+			created, err := apiKeyService.CreateAPIKey(ctx, request.CreateAPIKeyRequest{
+				Name:   "ci",
+				Scopes: tc.scopes,
+			})
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(created.Key, created.Prefix+"_"))
+			assert.NotContains(t, stored.SecretHash, created.Key[len(created.Prefix)+1:])
+			assert.Equal(t, user.ID, stored.UserID)
+
+			presented := created.Key
+			if tc.tamper != nil {
+				presented = tc.tamper(&stored, presented)
+			}
+			mockAPIKeyRepo.On("GetAPIKeyByPrefix", mock.Anything, stored.Prefix).Return(stored, nil).Maybe()
+			mockAPIKeyRepo.On("TouchAPIKey", mock.Anything, stored.ID, mock.Anything).Return(nil).Maybe()
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+			mockRoleRepo.On("GetRolesByUserID", mock.Anything, user.ID).Return(tc.rolesAtUse, nil).Maybe()
+
+			principal, err := apiKeyService.AuthenticateAPIKey(context.Background(), presented)
+			if tc.expectErr != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectErr, err.(*errors.AppError).Status)
+				mockAPIKeyRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, stored.ID, principal.KeyID)
+			assert.Equal(t, user.ID, principal.UserID)
+			assert.Equal(t, user.Email, principal.Email)
+			assert.Equal(t, tc.expectPerms, principal.Permissions)
+			mockAPIKeyRepo.AssertCalled(t, "TouchAPIKey", mock.Anything, stored.ID, mock.Anything)
+		})
+	}
+}
+
+// TestAPIKeyService_CreateAPIKey tests that keys can only carry permissions the user holds
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	mockLogger := logger.NewLogger(mockConfig)
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, userID.String())
+
+	mockAPIKeyRepo := new(MockAPIKeyRepo)
+	mockRoleRepo := new(MockRoleRepo)
+	mockRoleRepo.On("GetRolesByUserID", mock.Anything, userID).Return([]entity.Role{{
+		Name:        constant.RoleUser,
+		Permissions: []entity.Permission{{Name: constant.PermissionProfileRead}},
+	}}, nil)
+	apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo, new(MockUserRepo), mockRoleRepo, mockLogger, mockConfig)
+
+	_, err := apiKeyService.CreateAPIKey(ctx, request.CreateAPIKeyRequest{
+		Name:   "escalation",
+		Scopes: []string{constant.PermissionProfileRead, constant.PermissionUserWrite},
+	})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+	mockAPIKeyRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+}
@@ -5,16 +5,23 @@ import (
 	"errors"
 	"ienergy-template-go/config"
 	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
 	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
 	"ienergy-template-go/internal/service"
 	"ienergy-template-go/pkg/constant"
+	apperrors "ienergy-template-go/pkg/errors"
 	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestAuthService_Login tests the Login functionality of AuthService
@@ -22,27 +29,30 @@ func TestAuthService_Login(t *testing.T) {
 	t.Parallel()
 
 	// Setup test dependencies
-	mockUserRepo := new(MockUserRepo)
 	mockConfig := &config.Config{
 		Server: config.ServerCfg{
 			Env: constant.DevelopmentEnv,
 		},
 		JWT: config.JWTConfig{
-			Secret:         "secret",
-			ExpirationTime: "1", // 1 hour
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1", // 1 hour
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24", // 24 hours
 		},
 	}
 	mockLogger := logger.NewLogger(mockConfig)
-
-	authService := service.NewAuthService(mockUserRepo, mockLogger, mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
 
 	// Define test cases
 	testCases := []struct {
-		name          string
-		req           request.UserLoginRequest
-		mockSetup     func(*MockUserRepo)
-		expectedError error
-		validateResp  func(*testing.T, response.TokenResponse, error)
+		name                     string
+		req                      request.UserLoginRequest
+		requireEmailVerification bool
+		mfaToken                 string
+		mockSetup                func(*MockUserRepo, *MockRefreshTokenRepo)
+		expectedError            error
+		validateResp             func(*testing.T, response.TokenResponse, error)
 	}{
 		{
 			name: "successful login",
@@ -50,13 +60,17 @@ func TestAuthService_Login(t *testing.T) {
 				Email:    "test@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(m *MockUserRepo) {
-				m.On("ValidateUser", mock.Anything).Return(uuid.New(), nil)
+			mockSetup: func(m *MockUserRepo, r *MockRefreshTokenRepo) {
+				userID := uuid.New()
+				m.On("ValidateUser", mock.Anything).Return(userID, nil)
+				m.On("GetUserByID", mock.Anything, userID).Return(entity.User{ID: userID, Email: "test@example.com"}, nil)
+				r.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
 			},
 			expectedError: nil,
 			validateResp: func(t *testing.T, resp response.TokenResponse, err error) {
 				assert.NoError(t, err)
 				assert.NotEmpty(t, resp.Token)
+				assert.NotEmpty(t, resp.RefreshToken)
 			},
 		},
 		{
@@ -65,7 +79,7 @@ func TestAuthService_Login(t *testing.T) {
 				Email:    "test@example.com",
 				Password: "wrongpassword",
 			},
-			mockSetup: func(m *MockUserRepo) {
+			mockSetup: func(m *MockUserRepo, r *MockRefreshTokenRepo) {
 				m.On("ValidateUser", mock.Anything).Return(uuid.Nil, errors.New("invalid credentials"))
 			},
 			expectedError: errors.New("invalid credentials"),
@@ -74,6 +88,96 @@ func TestAuthService_Login(t *testing.T) {
 				assert.Empty(t, resp.Token)
 			},
 		},
+		{
+			name: "verified email when verification is required",
+			req: request.UserLoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			requireEmailVerification: true,
+			mockSetup: func(m *MockUserRepo, r *MockRefreshTokenRepo) {
+				userID := uuid.New()
+				verifiedAt := time.Now()
+				m.On("ValidateUser", mock.Anything).Return(userID, nil)
+				m.On("GetUserByID", mock.Anything, userID).Return(entity.User{
+					ID:              userID,
+					Email:           "test@example.com",
+					EmailVerifiedAt: &verifiedAt,
+				}, nil)
+				r.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+			},
+			validateResp: func(t *testing.T, resp response.TokenResponse, err error) {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, resp.Token)
+			},
+		},
+		{
+			name: "unverified email when verification is required",
+			req: request.UserLoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			requireEmailVerification: true,
+			mockSetup: func(m *MockUserRepo, r *MockRefreshTokenRepo) {
+				userID := uuid.New()
+				m.On("ValidateUser", mock.Anything).Return(userID, nil)
+				m.On("GetUserByID", mock.Anything, userID).Return(entity.User{
+					ID:    userID,
+					Email: "test@example.com",
+				}, nil)
+			},
+			validateResp: func(t *testing.T, resp response.TokenResponse, err error) {
+				require.Error(t, err)
+				appErr, ok := err.(*apperrors.AppError)
+				require.True(t, ok)
+				assert.Equal(t, constant.EmailNotVerified, appErr.Code)
+				assert.Empty(t, resp.Token)
+			},
+		},
+		{
+			name: "suspended account",
+			req: request.UserLoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mockSetup: func(m *MockUserRepo, r *MockRefreshTokenRepo) {
+				userID := uuid.New()
+				m.On("ValidateUser", mock.Anything).Return(userID, nil)
+				m.On("GetUserByID", mock.Anything, userID).Return(entity.User{
+					ID:    userID,
+					Email: "test@example.com",
+					State: enum.EnumStateDB(enum.StateSuspended),
+				}, nil)
+			},
+			validateResp: func(t *testing.T, resp response.TokenResponse, err error) {
+				require.Error(t, err)
+				appErr, ok := err.(*apperrors.AppError)
+				require.True(t, ok)
+				assert.Equal(t, http.StatusForbidden, appErr.Status)
+				assert.Equal(t, "Account is suspended", appErr.Message)
+				assert.Empty(t, resp.Token)
+			},
+		},
+		{
+			name: "MFA enabled returns a challenge instead of tokens",
+			req: request.UserLoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mfaToken: "challenge",
+			mockSetup: func(m *MockUserRepo, r *MockRefreshTokenRepo) {
+				userID := uuid.New()
+				m.On("ValidateUser", mock.Anything).Return(userID, nil)
+				m.On("GetUserByID", mock.Anything, userID).Return(entity.User{ID: userID, Email: "test@example.com"}, nil)
+			},
+			validateResp: func(t *testing.T, resp response.TokenResponse, err error) {
+				require.NoError(t, err)
+				assert.True(t, resp.MFARequired)
+				assert.Equal(t, "challenge", resp.MFAToken)
+				assert.Empty(t, resp.Token)
+				assert.Empty(t, resp.RefreshToken)
+			},
+		},
 	}
 
 	// Run test cases
@@ -83,7 +187,36 @@ func TestAuthService_Login(t *testing.T) {
 			t.Parallel()
 
 			// Setup mock
-			tc.mockSetup(mockUserRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			tc.mockSetup(mockUserRepo, mockRefreshTokenRepo)
+			mockMFAService := new(MockMFAService)
+			mockMFAService.On("ChallengeLogin", mock.Anything, mock.Anything).Return(tc.mfaToken, nil).Maybe()
+			cfg := *mockConfig
+			cfg.Auth.RequireEmailVerification = tc.requireEmailVerification
+
+			tokenService := service.NewTokenService(
+				mockRefreshTokenRepo,
+				newMockSessionRepo(),
+				mockUserRepo,
+				newMockRoleRepo(),
+				newMockOrganizationRepo(),
+				repository.NewMemoryTokenRevocationStore(),
+				keySet,
+				mockLogger,
+				&cfg,
+			)
+			authService := service.NewAuthService(
+				mockUserRepo,
+				newMockRoleRepo(),
+				tokenService,
+				new(MockVerificationService),
+				mockMFAService,
+				service.NewLoginAttemptService(repository.NewMemoryLoginAttemptStore(), mockLogger, &cfg),
+				newPasswordPolicy(t, &cfg),
+				mockLogger,
+				&cfg,
+			)
 
 			// Execute test
 			resp, err := authService.Login(context.Background(), tc.req)
@@ -91,6 +224,7 @@ func TestAuthService_Login(t *testing.T) {
 			// Validate results
 			tc.validateResp(t, resp, err)
 			mockUserRepo.AssertExpectations(t)
+			mockRefreshTokenRepo.AssertExpectations(t)
 		})
 	}
 }
@@ -106,13 +240,42 @@ func TestAuthService_Register(t *testing.T) {
 			Env: constant.DevelopmentEnv,
 		},
 		JWT: config.JWTConfig{
-			Secret:         "secret",
+			Secret:         "test-jwt-secret-of-at-least-32-bytes",
 			ExpirationTime: "1", // 1 hour
 		},
 	}
 	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
 
-	authService := service.NewAuthService(mockUserRepo, mockLogger, mockConfig)
+	tokenService := service.NewTokenService(
+		new(MockRefreshTokenRepo),
+		newMockSessionRepo(),
+		mockUserRepo,
+		newMockRoleRepo(),
+		newMockOrganizationRepo(),
+		repository.NewMemoryTokenRevocationStore(),
+		keySet,
+		mockLogger,
+		mockConfig,
+	)
+	mockRoleRepo := newMockRoleRepo()
+	mockRoleRepo.On("AssignRole", mock.Anything, mock.Anything, constant.RoleUser).Return(nil)
+	mockVerificationService := new(MockVerificationService)
+	mockVerificationService.On("SendVerificationEmail", mock.Anything, mock.MatchedBy(func(u entity.User) bool {
+		return u.Email == "test@example.com"
+	})).Return(nil)
+	authService := service.NewAuthService(
+		mockUserRepo,
+		mockRoleRepo,
+		tokenService,
+		mockVerificationService,
+		newMockMFAService(),
+		service.NewLoginAttemptService(repository.NewMemoryLoginAttemptStore(), mockLogger, mockConfig),
+		newPasswordPolicy(t, mockConfig),
+		mockLogger,
+		mockConfig,
+	)
 
 	// Define test cases
 	testCases := []struct {
@@ -186,3 +349,112 @@ func TestAuthService_Register(t *testing.T) {
 		})
 	}
 }
+
+// TestAuthService_Logout tests that Logout revokes the caller's tokens
+func TestAuthService_Logout(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1", // 1 hour
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24", // 24 hours
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+	userID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		tokenID     string
+		withRefresh bool
+		expectErr   bool
+	}{
+		{
+			name:    "revokes access token",
+			tokenID: uuid.NewString(),
+		},
+		{
+			name:        "revokes access token and refresh token family",
+			tokenID:     uuid.NewString(),
+			withRefresh: true,
+		},
+		{
+			name:      "missing token id",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			revocationStore := repository.NewMemoryTokenRevocationStore()
+			tokenService := service.NewTokenService(
+				mockRefreshTokenRepo,
+				newMockSessionRepo(),
+				mockUserRepo,
+				newMockRoleRepo(),
+				newMockOrganizationRepo(),
+				revocationStore,
+				keySet,
+				mockLogger,
+				mockConfig,
+			)
+			authService := service.NewAuthService(
+				mockUserRepo,
+				newMockRoleRepo(),
+				tokenService,
+				new(MockVerificationService),
+				newMockMFAService(),
+				service.NewLoginAttemptService(repository.NewMemoryLoginAttemptStore(), mockLogger, mockConfig),
+				newPasswordPolicy(t, mockConfig),
+				mockLogger,
+				mockConfig,
+			)
+
+			req := request.LogoutRequest{}
+			if tc.withRefresh {
+				var stored entity.RefreshToken
+				mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) {
+						stored = args.Get(1).(entity.RefreshToken)
+					}).
+					Return(nil).Once()
+				tokens, err := tokenService.IssueTokens(context.Background(), entity.User{ID: userID})
+				require.NoError(t, err)
+				req.RefreshToken = tokens.RefreshToken
+
+				mockRefreshTokenRepo.On("GetRefreshTokenByID", mock.Anything, stored.ID).Return(stored, nil)
+				mockRefreshTokenRepo.On("RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID).Return(nil)
+			}
+
+			ctx := context.WithValue(context.Background(), util.UserIDCTX, userID.String())
+			if tc.tokenID != "" {
+				ctx = context.WithValue(ctx, util.TokenIDCTX, tc.tokenID)
+				ctx = context.WithValue(ctx, util.TokenExpiresAtCTX, time.Now().Add(time.Hour))
+			}
+
+			err := authService.Logout(ctx, req)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			revoked, err := revocationStore.IsRevoked(context.Background(), tc.tokenID)
+			require.NoError(t, err)
+			assert.True(t, revoked)
+			mockRefreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
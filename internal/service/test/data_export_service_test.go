@@ -0,0 +1,299 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type dataExportTestDeps struct {
+	dataExportRepo *MockDataExportRepo
+	userRepo       *MockUserRepo
+	sessionRepo    *MockSessionRepo
+	apiKeyRepo     *MockAPIKeyRepo
+	oidcRepo       *MockOIDCRepo
+	mfaRepo        *MockMFARepo
+	orgRepo        *MockOrganizationRepo
+	invitationRepo *MockInvitationRepo
+	auditLogRepo   *MockAuditLogRepo
+	notifier       *MockNotifier
+}
+
+func newDataExportTestService(lc fx.Lifecycle, deps dataExportTestDeps) service.DataExportService {
+	mockConfig := &config.Config{
+		Server:  config.ServerCfg{Env: constant.DevelopmentEnv},
+		Privacy: config.PrivacyConfig{DataExportTTL: 24 * time.Hour, DataExportPruneInterval: time.Hour},
+	}
+	return service.NewDataExportService(
+		lc,
+		deps.dataExportRepo,
+		deps.userRepo,
+		newMockRoleRepo(),
+		deps.sessionRepo,
+		deps.apiKeyRepo,
+		deps.oidcRepo,
+		deps.mfaRepo,
+		deps.orgRepo,
+		deps.invitationRepo,
+		deps.auditLogRepo,
+		deps.notifier,
+		logger.NewLogger(mockConfig),
+		mockConfig,
+	)
+}
+
+func newDataExportTestDeps() dataExportTestDeps {
+	return dataExportTestDeps{
+		dataExportRepo: new(MockDataExportRepo),
+		userRepo:       new(MockUserRepo),
+		sessionRepo:    new(MockSessionRepo),
+		apiKeyRepo:     new(MockAPIKeyRepo),
+		oidcRepo:       new(MockOIDCRepo),
+		mfaRepo:        new(MockMFARepo),
+		orgRepo:        new(MockOrganizationRepo),
+		invitationRepo: new(MockInvitationRepo),
+		auditLogRepo:   new(MockAuditLogRepo),
+		notifier:       new(MockNotifier),
+	}
+}
+
+// TestDataExportService_RequestExport tests that an export is queued unless one is already being prepared
+func TestDataExportService_RequestExport(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, userID.String())
+
+	t.Run("export is queued", func(t *testing.T) {
+		t.Parallel()
+
+		deps := newDataExportTestDeps()
+		deps.dataExportRepo.On("HasPendingDataExport", mock.Anything, userID).Return(false, nil)
+		deps.dataExportRepo.On("CreateDataExport", mock.Anything, mock.MatchedBy(func(export entity.DataExport) bool {
+			return export.UserID == userID &&
+				export.Format == constant.DataExportFormatJSON &&
+				export.Status == entity.DataExportPending
+		})).Return(nil)
+
+		resp, err := newDataExportTestService(fxtest.NewLifecycle(t), deps).
+			RequestExport(ctx, request.DataExportRequest{Format: constant.DataExportFormatJSON})
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, resp.ID)
+		assert.Equal(t, entity.DataExportPending, resp.Status)
+		deps.dataExportRepo.AssertExpectations(t)
+	})
+
+	t.Run("export already being prepared", func(t *testing.T) {
+		t.Parallel()
+
+		deps := newDataExportTestDeps()
+		deps.dataExportRepo.On("HasPendingDataExport", mock.Anything, userID).Return(true, nil)
+
+		_, err := newDataExportTestService(fxtest.NewLifecycle(t), deps).
+			RequestExport(ctx, request.DataExportRequest{Format: constant.DataExportFormatZIP})
+		require.Error(t, err)
+		assert.Equal(t, errors.NewConflictError("An export of your data is already being prepared").Error(), err.Error())
+		deps.dataExportRepo.AssertNotCalled(t, "CreateDataExport", mock.Anything, mock.Anything)
+	})
+}
+
+// TestDataExportService_GenerateExport tests that the worker turns a pending export into a ZIP archive
+// of the user's data and tells the user by email
+func TestDataExportService_GenerateExport(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	adminID := uuid.New()
+	export := entity.DataExport{ID: uuid.New(), UserID: userID, Format: constant.DataExportFormatZIP, Status: entity.DataExportPending}
+	user := entity.User{ID: userID, Email: "user@example.com", FirstName: "Jane", LastName: "Doe"}
+
+	deps := newDataExportTestDeps()
+	deps.dataExportRepo.On("GetPendingDataExports", mock.Anything).Return([]entity.DataExport{export}, nil)
+	deps.userRepo.On("GetUserByID", mock.Anything, userID).Return(user, nil)
+	deps.mfaRepo.On("GetMFAFactorByUserID", mock.Anything, userID).
+		Return(entity.MFAFactor{}, errors.NewNotFoundError("MFA factor not found"))
+	deps.sessionRepo.On("GetSessionsByUserID", mock.Anything, userID).Return([]entity.Session{
+		{ID: uuid.New(), UserID: userID, IPAddress: "203.0.113.7", UserAgent: "Firefox"},
+	}, nil)
+	deps.apiKeyRepo.On("GetAPIKeysByUserID", mock.Anything, userID).Return([]entity.APIKey{}, nil)
+	deps.oidcRepo.On("GetUserIdentitiesByUserID", mock.Anything, userID).Return([]entity.UserIdentity{}, nil)
+	organization := entity.Organization{ID: uuid.New(), Name: "Acme", Slug: "acme"}
+	deps.orgRepo.On("GetUserMemberships", mock.Anything, userID).Return([]entity.OrganizationMember{
+		{OrganizationID: organization.ID, UserID: userID, Role: constant.OrganizationRoleOwner, Organization: organization},
+	}, nil)
+	deps.invitationRepo.On("GetUserInvitations", mock.Anything, userID, user.Email).Return([]entity.OrganizationInvitation{
+		{
+			OrganizationID: organization.ID,
+			Email:          "colleague@example.com",
+			Role:           constant.OrganizationRoleMember,
+			InvitedBy:      userID,
+			ExpiresAt:      time.Now().Add(time.Hour),
+			Organization:   organization,
+		},
+	}, nil)
+	deps.auditLogRepo.On("GetAuditLogsByUserID", mock.Anything, userID).Return([]entity.AuditLog{
+		{ActorID: adminID, TargetID: userID, Action: constant.AuditActionUserSuspend, IPAddress: "198.51.100.1"},
+	}, nil)
+
+	var archive []byte
+	completed := make(chan struct{})
+	deps.dataExportRepo.On("CompleteDataExport", mock.Anything, export.ID, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			archive = args.Get(2).([]byte)
+		}).
+		Return(true, nil)
+	deps.notifier.On("Send", mock.Anything, mock.MatchedBy(func(msg notifier.Message) bool {
+		return msg.To == user.Email
+	})).
+		Run(func(mock.Arguments) { close(completed) }).
+		Return(nil)
+
+	lc := fxtest.NewLifecycle(t)
+	newDataExportTestService(lc, deps)
+	lc.RequireStart()
+	select {
+	case <-completed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("data export was not generated")
+	}
+	lc.RequireStop()
+
+	zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, file := range zipReader.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(reader)
+		require.NoError(t, err)
+		reader.Close()
+	}
+	require.Contains(t, files, "profile.json")
+	require.Contains(t, files, "sessions.json")
+	require.Contains(t, files, "organizations.json")
+	require.Contains(t, files, "invitations.json")
+	require.Contains(t, files, "audit_log.json")
+
+	var profile response.UserDataProfile
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, user.Email, profile.Email)
+	assert.Equal(t, "Jane", profile.FirstName)
+	assert.False(t, profile.MFAEnabled)
+
+	var sessions []response.UserDataSession
+	require.NoError(t, json.Unmarshal(files["sessions.json"], &sessions))
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "203.0.113.7", sessions[0].IPAddress)
+
+	var organizations []response.UserDataOrganization
+	require.NoError(t, json.Unmarshal(files["organizations.json"], &organizations))
+	require.Len(t, organizations, 1)
+	assert.Equal(t, "Acme", organizations[0].Name)
+	assert.Equal(t, constant.OrganizationRoleOwner, organizations[0].Role)
+
+	var invitations []response.UserDataInvitation
+	require.NoError(t, json.Unmarshal(files["invitations.json"], &invitations))
+	require.Len(t, invitations, 1)
+	assert.True(t, invitations[0].Sent)
+	assert.Equal(t, "colleague@example.com", invitations[0].Email)
+	assert.Equal(t, constant.InvitationStatusPending, invitations[0].Status)
+
+	var auditLog []response.UserDataAuditLogEntry
+	require.NoError(t, json.Unmarshal(files["audit_log.json"], &auditLog))
+	require.Len(t, auditLog, 1)
+	assert.Equal(t, constant.AuditActionUserSuspend, auditLog[0].Action)
+	assert.Empty(t, auditLog[0].IPAddress, "the admin's IP address is not disclosed")
+}
+
+// TestDataExportService_DownloadExport tests that only the owner can download a finished, unexpired export
+func TestDataExportService_DownloadExport(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, userID.String())
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	testCases := []struct {
+		name          string
+		export        entity.DataExport
+		expectedError error
+	}{
+		{
+			name: "ready export",
+			export: entity.DataExport{
+				ID: uuid.New(), UserID: userID, Format: constant.DataExportFormatJSON,
+				Status: entity.DataExportReady, Archive: []byte(`{}`), ExpiresAt: &later,
+				BaseEntity: entity.BaseEntity{CreatedAt: &now},
+			},
+		},
+		{
+			name: "export of another user",
+			export: entity.DataExport{
+				ID: uuid.New(), UserID: uuid.New(), Format: constant.DataExportFormatJSON,
+				Status: entity.DataExportReady, ExpiresAt: &later,
+			},
+			expectedError: errors.NewNotFoundError("Data export not found"),
+		},
+		{
+			name: "export still being prepared",
+			export: entity.DataExport{
+				ID: uuid.New(), UserID: userID, Format: constant.DataExportFormatZIP,
+				Status: entity.DataExportPending,
+			},
+			expectedError: errors.NewConflictError("The export is still being prepared"),
+		},
+		{
+			name: "expired export",
+			export: entity.DataExport{
+				ID: uuid.New(), UserID: userID, Format: constant.DataExportFormatZIP,
+				Status: entity.DataExportReady, ExpiresAt: &earlier,
+			},
+			expectedError: errors.NewNotFoundError("Data export not found"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := newDataExportTestDeps()
+			deps.dataExportRepo.On("GetDataExportByID", mock.Anything, tc.export.ID).Return(tc.export, nil)
+
+			file, err := newDataExportTestService(fxtest.NewLifecycle(t), deps).DownloadExport(ctx, tc.export.ID)
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				assert.Empty(t, file.Data)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "application/json", file.ContentType)
+			assert.Equal(t, tc.export.Archive, file.Data)
+			assert.Contains(t, file.FileName, ".json")
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newErasureTestService(
+	userRepo *MockUserRepo,
+	organizationRepo *MockOrganizationRepo,
+	auditLogRepo *MockAuditLogRepo,
+	notifier *MockNotifier,
+) service.ErasureService {
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	return service.NewErasureService(userRepo, organizationRepo, auditLogRepo, notifier, logger.NewLogger(mockConfig))
+}
+
+// TestErasureService_EraseAccount tests that users can erase their own account after confirming their password
+func TestErasureService_EraseAccount(t *testing.T) {
+	t.Parallel()
+
+	user := entity.User{ID: uuid.New(), Email: "user@example.com"}
+	userCTX := func() context.Context {
+		return context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+	}
+
+	testCases := []struct {
+		name          string
+		ctx           func() context.Context
+		setupMocks    func(*MockUserRepo, *MockAuditLogRepo, *MockNotifier)
+		expectedError error
+	}{
+		{
+			name: "account is erased",
+			ctx:  userCTX,
+			setupMocks: func(userRepo *MockUserRepo, auditLogRepo *MockAuditLogRepo, notifier *MockNotifier) {
+				userRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				userRepo.On("ValidateUser", mock.Anything).Return(user.ID, nil)
+				auditLogRepo.On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(log entity.AuditLog) bool {
+					return log.ActorID == user.ID && log.TargetID == user.ID && log.Action == constant.AuditActionUserErase
+				})).Return(nil)
+				userRepo.On("EraseUser", mock.Anything, user.ID, user.ID.String()).Return(true, nil)
+				notifier.On("Send", mock.Anything, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "wrong password",
+			ctx:  userCTX,
+			setupMocks: func(userRepo *MockUserRepo, auditLogRepo *MockAuditLogRepo, notifier *MockNotifier) {
+				userRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				userRepo.On("ValidateUser", mock.Anything).Return(uuid.Nil, errors.NewUnauthorizedError("Invalid credentials"))
+			},
+			expectedError: errors.NewForbiddenError("Password is incorrect"),
+		},
+		{
+			name: "impersonating the user",
+			ctx: func() context.Context {
+				return context.WithValue(userCTX(), util.ActorIDCTX, uuid.NewString())
+			},
+			setupMocks:    func(*MockUserRepo, *MockAuditLogRepo, *MockNotifier) {},
+			expectedError: errors.NewForbiddenError("Not allowed while impersonating a user"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockAuditLogRepo := new(MockAuditLogRepo)
+			mockNotifier := new(MockNotifier)
+			tc.setupMocks(mockUserRepo, mockAuditLogRepo, mockNotifier)
+
+			err := newErasureTestService(mockUserRepo, newMockOrganizationRepo(), mockAuditLogRepo, mockNotifier).
+				EraseAccount(tc.ctx(), request.EraseAccountRequest{Password: "password123"})
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				mockUserRepo.AssertNotCalled(t, "EraseUser", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+			mockAuditLogRepo.AssertExpectations(t)
+			mockNotifier.AssertExpectations(t)
+		})
+	}
+}
+
+// TestErasureService_EraseUser tests that admins can erase other users, deleted ones included,
+// and that nothing is erased without an audit record
+func TestErasureService_EraseUser(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+	erasedAt := time.Now()
+	deleted := entity.User{ID: uuid.New(), Email: "deleted@example.com", BaseEntity: entity.BaseEntity{DeletedAt: 1}}
+	erased := entity.User{ID: uuid.New(), Email: entity.ErasedEmail(uuid.New()), ErasedAt: &erasedAt}
+
+	testCases := []struct {
+		name          string
+		userID        uuid.UUID
+		setupMocks    func(*MockUserRepo, *MockAuditLogRepo, *MockNotifier)
+		expectedError error
+	}{
+		{
+			name:   "deleted user is erased",
+			userID: deleted.ID,
+			setupMocks: func(userRepo *MockUserRepo, auditLogRepo *MockAuditLogRepo, notifier *MockNotifier) {
+				userRepo.On("GetUserByIDWithDeleted", mock.Anything, deleted.ID).Return(deleted, nil)
+				auditLogRepo.On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(log entity.AuditLog) bool {
+					return log.ActorID == adminID &&
+						log.TargetID == deleted.ID &&
+						log.Action == constant.AuditActionUserErase &&
+						log.Reason == "Ticket 7"
+				})).Return(nil)
+				userRepo.On("EraseUser", mock.Anything, deleted.ID, adminID.String()).Return(true, nil)
+				notifier.On("Send", mock.Anything, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:          "erasing yourself",
+			userID:        adminID,
+			setupMocks:    func(*MockUserRepo, *MockAuditLogRepo, *MockNotifier) {},
+			expectedError: errors.NewBadRequestError("You can't erase your own account here"),
+		},
+		{
+			name:   "user already erased",
+			userID: erased.ID,
+			setupMocks: func(userRepo *MockUserRepo, auditLogRepo *MockAuditLogRepo, notifier *MockNotifier) {
+				userRepo.On("GetUserByIDWithDeleted", mock.Anything, erased.ID).Return(erased, nil)
+			},
+			expectedError: errors.NewConflictError("User has already been erased"),
+		},
+		{
+			name:   "nothing is erased without an audit record",
+			userID: deleted.ID,
+			setupMocks: func(userRepo *MockUserRepo, auditLogRepo *MockAuditLogRepo, notifier *MockNotifier) {
+				userRepo.On("GetUserByIDWithDeleted", mock.Anything, deleted.ID).Return(deleted, nil)
+				auditLogRepo.On("CreateAuditLog", mock.Anything, mock.Anything).
+					Return(errors.NewInternalServerError("Database error: connection refused"))
+			},
+			expectedError: errors.NewInternalServerError("Database error: connection refused"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockAuditLogRepo := new(MockAuditLogRepo)
+			mockNotifier := new(MockNotifier)
+			tc.setupMocks(mockUserRepo, mockAuditLogRepo, mockNotifier)
+
+			err := newErasureTestService(mockUserRepo, newMockOrganizationRepo(), mockAuditLogRepo, mockNotifier).
+				EraseUser(ctx, tc.userID, request.EraseUserRequest{Reason: "Ticket 7"})
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				mockUserRepo.AssertNotCalled(t, "EraseUser", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+			mockAuditLogRepo.AssertExpectations(t)
+			mockNotifier.AssertExpectations(t)
+		})
+	}
+}
+
+// TestErasureService_SoleOwner tests that the only owner of an organization can't be erased,
+// while an owner with a co-owner can
+func TestErasureService_SoleOwner(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+	user := entity.User{ID: uuid.New(), Email: "owner@example.com"}
+	organization := entity.Organization{ID: uuid.New(), Name: "Acme"}
+
+	testCases := []struct {
+		name          string
+		owners        int64
+		expectedError error
+	}{
+		{
+			name:          "only owner",
+			owners:        1,
+			expectedError: errors.NewConflictError("The user is the only owner of Acme. Make another member an owner first"),
+		},
+		{
+			name:   "owner with a co-owner",
+			owners: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockUserRepo.On("GetUserByIDWithDeleted", mock.Anything, user.ID).Return(user, nil)
+			mockUserRepo.On("EraseUser", mock.Anything, user.ID, adminID.String()).Return(true, nil).Maybe()
+			mockOrganizationRepo := new(MockOrganizationRepo)
+			mockOrganizationRepo.On("GetUserMemberships", mock.Anything, user.ID).Return([]entity.OrganizationMember{
+				{OrganizationID: organization.ID, UserID: user.ID, Role: constant.OrganizationRoleOwner, Organization: organization},
+			}, nil)
+			mockOrganizationRepo.On("CountOwners", mock.MatchedBy(func(ctx context.Context) bool {
+				return util.TenantIDFromCTX(ctx) == organization.ID
+			})).Return(tc.owners, nil)
+			mockAuditLogRepo := new(MockAuditLogRepo)
+			mockAuditLogRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil).Maybe()
+			mockNotifier := new(MockNotifier)
+			mockNotifier.On("Send", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			err := newErasureTestService(mockUserRepo, mockOrganizationRepo, mockAuditLogRepo, mockNotifier).
+				EraseUser(ctx, user.ID, request.EraseUserRequest{Reason: "Ticket 7"})
+			if tc.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				mockUserRepo.AssertNotCalled(t, "EraseUser", mock.Anything, mock.Anything, mock.Anything)
+				mockAuditLogRepo.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+			mockOrganizationRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImpersonationService_Impersonate tests that admins get a short-lived token for another user
+// carrying an act claim, and that nothing is issued unless the audit record was written
+func TestImpersonationService_Impersonate(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+		Auth: config.AuthConfig{
+			ImpersonationTokenTTL: 15 * time.Minute,
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+
+	adminID := uuid.New()
+	sessionID := uuid.New()
+	user := entity.User{ID: uuid.New(), Email: "user@example.com"}
+	admin := entity.User{ID: uuid.New(), Email: "other-admin@example.com"}
+	userRoles := []entity.Role{{Name: constant.RoleUser}}
+	adminRoles := []entity.Role{{
+		Name:        constant.RoleAdmin,
+		Permissions: []entity.Permission{{Name: constant.PermissionImpersonate}},
+	}}
+
+	adminCTX := func() context.Context {
+		ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+		ctx = context.WithValue(ctx, util.UserEmailCTX, "admin@example.com")
+		return context.WithValue(ctx, util.SessionIDCTX, sessionID.String())
+	}
+
+	testCases := []struct {
+		name          string
+		ctx           func() context.Context
+		userID        uuid.UUID
+		setupMocks    func(*MockUserRepo, *MockRoleRepo, *MockAuditLogRepo)
+		expectedError error
+	}{
+		{
+			name:   "admin impersonates a user",
+			ctx:    adminCTX,
+			userID: user.ID,
+			setupMocks: func(userRepo *MockUserRepo, roleRepo *MockRoleRepo, auditLogRepo *MockAuditLogRepo) {
+				userRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				roleRepo.On("GetRolesByUserID", mock.Anything, user.ID).Return(userRoles, nil)
+				auditLogRepo.On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(log entity.AuditLog) bool {
+					return log.ActorID == adminID &&
+						log.TargetID == user.ID &&
+						log.Action == constant.AuditActionImpersonate &&
+						log.Reason == "Support ticket 42"
+				})).Return(nil)
+			},
+		},
+		{
+			name:          "impersonating yourself",
+			ctx:           adminCTX,
+			userID:        adminID,
+			setupMocks:    func(*MockUserRepo, *MockRoleRepo, *MockAuditLogRepo) {},
+			expectedError: errors.NewBadRequestError("You can't impersonate yourself"),
+		},
+		{
+			name: "already impersonating",
+			ctx: func() context.Context {
+				return context.WithValue(adminCTX(), util.ActorIDCTX, uuid.NewString())
+			},
+			userID:        user.ID,
+			setupMocks:    func(*MockUserRepo, *MockRoleRepo, *MockAuditLogRepo) {},
+			expectedError: errors.NewForbiddenError("Not allowed while impersonating a user"),
+		},
+		{
+			name:   "admins can't be impersonated",
+			ctx:    adminCTX,
+			userID: admin.ID,
+			setupMocks: func(userRepo *MockUserRepo, roleRepo *MockRoleRepo, auditLogRepo *MockAuditLogRepo) {
+				userRepo.On("GetUserByID", mock.Anything, admin.ID).Return(admin, nil)
+				roleRepo.On("GetRolesByUserID", mock.Anything, admin.ID).Return(adminRoles, nil)
+			},
+			expectedError: errors.NewForbiddenError("Admins can't be impersonated"),
+		},
+		{
+			name:   "no token without an audit record",
+			ctx:    adminCTX,
+			userID: user.ID,
+			setupMocks: func(userRepo *MockUserRepo, roleRepo *MockRoleRepo, auditLogRepo *MockAuditLogRepo) {
+				userRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				roleRepo.On("GetRolesByUserID", mock.Anything, user.ID).Return(userRoles, nil)
+				auditLogRepo.On("CreateAuditLog", mock.Anything, mock.Anything).
+					Return(errors.NewInternalServerError("Database error: connection refused"))
+			},
+			expectedError: errors.NewInternalServerError("Database error: connection refused"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockRoleRepo := new(MockRoleRepo)
+			mockAuditLogRepo := new(MockAuditLogRepo)
+			tc.setupMocks(mockUserRepo, mockRoleRepo, mockAuditLogRepo)
+
+			tokenService := service.NewTokenService(
+				new(MockRefreshTokenRepo),
+				newMockSessionRepo(),
+				mockUserRepo,
+				mockRoleRepo,
+				newMockOrganizationRepo(),
+				repository.NewMemoryTokenRevocationStore(),
+				keySet,
+				mockLogger,
+				mockConfig,
+			)
+			impersonationService := service.NewImpersonationService(
+				mockUserRepo,
+				mockRoleRepo,
+				mockAuditLogRepo,
+				tokenService,
+				mockLogger,
+				mockConfig,
+			)
+
+			resp, err := impersonationService.Impersonate(
+				tc.ctx(),
+				tc.userID,
+				request.ImpersonateRequest{Reason: "Support ticket 42"},
+			)
+			if tc.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				assert.Empty(t, resp.Token)
+				mockAuditLogRepo.AssertExpectations(t)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, resp.UserID)
+			assert.WithinDuration(t, time.Now().Add(15*time.Minute), resp.ExpiresAt, time.Minute)
+
+			claims, err := keySet.ParseAccessToken(resp.Token)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID.String(), claims.Subject)
+			assert.Equal(t, sessionID.String(), claims.SessionID)
+			require.NotNil(t, claims.Actor)
+			assert.Equal(t, adminID.String(), claims.Actor.Subject)
+			assert.Equal(t, "admin@example.com", claims.Actor.Email)
+			mockAuditLogRepo.AssertExpectations(t)
+		})
+	}
+}
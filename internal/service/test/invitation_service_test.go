@@ -0,0 +1,394 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newInvitationTestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		Auth: config.AuthConfig{
+			ActionTokenSecret:  "action_secret",
+			InvitationURL:      "http://localhost:3000/accept-invitation",
+			InvitationTokenTTL: 72 * time.Hour,
+		},
+	}
+}
+
+// invitationDeps holds the mocks an invitation service is built from
+type invitationDeps struct {
+	invitationRepo   *MockInvitationRepo
+	organizationRepo *MockOrganizationRepo
+	userRepo         *MockUserRepo
+	authService      *MockAuthService
+	notifier         *MockNotifier
+}
+
+func newInvitationDeps() invitationDeps {
+	return invitationDeps{
+		invitationRepo:   new(MockInvitationRepo),
+		organizationRepo: new(MockOrganizationRepo),
+		userRepo:         new(MockUserRepo),
+		authService:      new(MockAuthService),
+		notifier:         new(MockNotifier),
+	}
+}
+
+func (d invitationDeps) service(mockConfig *config.Config) service.InvitationService {
+	return service.NewInvitationService(
+		d.invitationRepo,
+		d.organizationRepo,
+		d.userRepo,
+		d.authService,
+		d.notifier,
+		logger.NewLogger(mockConfig),
+		mockConfig,
+	)
+}
+
+// TestInvitationService_CreateInvitation tests that invitations are mailed as signed links
+// and that only owners invite owners
+func TestInvitationService_CreateInvitation(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newInvitationTestConfig()
+	organization := entity.Organization{ID: uuid.New(), Name: "Acme Energy", Slug: "acme-energy"}
+	inviter := entity.User{ID: uuid.New(), FirstName: "Jane", LastName: "Doe"}
+	callerAs := func(role string) context.Context {
+		ctx := util.WithTenant(context.Background(), organization.ID)
+		ctx = context.WithValue(ctx, util.UserIDCTX, inviter.ID.String())
+		return context.WithValue(ctx, util.OrgRoleCTX, role)
+	}
+	req := request.CreateInvitationRequest{Email: "new@example.com", Role: constant.OrganizationRoleMember}
+
+	t.Run("mails a signed link", func(t *testing.T) {
+		t.Parallel()
+
+		deps := newInvitationDeps()
+		deps.userRepo.On("GetUserByEmail", mock.Anything, req.Email).Return(entity.User{}, errors.NewNotFoundError("User not found"))
+		deps.userRepo.On("GetUserByID", mock.Anything, inviter.ID).Return(inviter, nil)
+		deps.organizationRepo.On("GetOrganizationByID", mock.Anything, organization.ID).Return(organization, nil)
+		var created entity.OrganizationInvitation
+		deps.invitationRepo.On("CreateInvitation", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				created = args.Get(1).(entity.OrganizationInvitation)
+			}).
+			Return(nil)
+		var sent notifier.Message
+		deps.notifier.On("Send", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(notifier.Message)
+			}).
+			Return(nil)
+
+		resp, err := deps.service(mockConfig).CreateInvitation(callerAs(constant.OrganizationRoleAdmin), req)
+		require.NoError(t, err)
+		assert.Equal(t, constant.InvitationStatusPending, resp.Status)
+		assert.Equal(t, organization.ID, created.OrganizationID)
+		assert.Equal(t, inviter.ID, created.InvitedBy)
+		assert.Equal(t, req.Role, created.Role)
+
+		assert.Equal(t, req.Email, sent.To)
+		assert.Contains(t, sent.Body, organization.Name)
+		linkStart := strings.Index(sent.Body, mockConfig.Auth.InvitationURL)
+		require.GreaterOrEqual(t, linkStart, 0)
+		link, err := url.Parse(strings.Fields(sent.Body[linkStart:])[0])
+		require.NoError(t, err)
+		claims, err := util.ParseActionToken(
+			mockConfig.Auth.ActionTokenSecret,
+			constant.PurposeInvitation,
+			link.Query().Get("token"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID.String(), claims.Subject)
+		assert.Equal(t, req.Email, claims.Email)
+	})
+
+	t.Run("admin can't invite an owner", func(t *testing.T) {
+		t.Parallel()
+
+		deps := newInvitationDeps()
+		_, err := deps.service(mockConfig).CreateInvitation(
+			callerAs(constant.OrganizationRoleAdmin),
+			request.CreateInvitationRequest{Email: req.Email, Role: constant.OrganizationRoleOwner},
+		)
+		assert.Equal(t, errors.NewForbiddenError("Only owners can grant or change the owner role"), err)
+		deps.invitationRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+		deps.notifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("existing member", func(t *testing.T) {
+		t.Parallel()
+
+		member := entity.User{ID: uuid.New(), Email: req.Email}
+		deps := newInvitationDeps()
+		deps.userRepo.On("GetUserByEmail", mock.Anything, req.Email).Return(member, nil)
+		deps.organizationRepo.On("GetMember", mock.Anything, member.ID).
+			Return(entity.OrganizationMember{UserID: member.ID, Role: constant.OrganizationRoleMember}, nil)
+
+		_, err := deps.service(mockConfig).CreateInvitation(callerAs(constant.OrganizationRoleOwner), req)
+		assert.Equal(t, errors.NewConflictError("User is already a member of the organization"), err)
+		deps.invitationRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+	})
+}
+
+// TestInvitationService_RevokeInvitation tests that only pending invitations are revoked
+// and that only owners revoke an invitation to become owner
+func TestInvitationService_RevokeInvitation(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newInvitationTestConfig()
+	callerID := uuid.New()
+	callerAs := func(role string) context.Context {
+		ctx := util.WithTenant(context.Background(), uuid.New())
+		ctx = context.WithValue(ctx, util.UserIDCTX, callerID.String())
+		return context.WithValue(ctx, util.OrgRoleCTX, role)
+	}
+
+	testCases := []struct {
+		name          string
+		callerRole    string
+		role          string
+		revoked       bool
+		expectedError error
+	}{
+		{
+			name:       "admin revokes a member invitation",
+			callerRole: constant.OrganizationRoleAdmin,
+			role:       constant.OrganizationRoleMember,
+			revoked:    true,
+		},
+		{
+			name:          "admin can't revoke an owner invitation",
+			callerRole:    constant.OrganizationRoleAdmin,
+			role:          constant.OrganizationRoleOwner,
+			expectedError: errors.NewForbiddenError("Only owners can grant or change the owner role"),
+		},
+		{
+			name:          "invitation is no longer pending",
+			callerRole:    constant.OrganizationRoleOwner,
+			role:          constant.OrganizationRoleMember,
+			revoked:       false,
+			expectedError: errors.NewConflictError("Invitation is no longer pending"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			invitation := entity.OrganizationInvitation{ID: uuid.New(), Role: tc.role}
+			deps := newInvitationDeps()
+			deps.invitationRepo.On("GetInvitation", mock.Anything, invitation.ID).Return(invitation, nil)
+			deps.invitationRepo.On("RevokeInvitation", mock.Anything, invitation.ID, callerID.String()).
+				Return(tc.revoked, nil).Maybe()
+
+			err := deps.service(mockConfig).RevokeInvitation(callerAs(tc.callerRole), invitation.ID)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+// TestInvitationService_AcceptInvitation tests that an invitation links an existing account
+// or registers one, and is only redeemed while pending
+func TestInvitationService_AcceptInvitation(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newInvitationTestConfig()
+	organization := entity.Organization{ID: uuid.New(), Name: "Acme Energy", Slug: "acme-energy"}
+	newInvitation := func() entity.OrganizationInvitation {
+		return entity.OrganizationInvitation{
+			ID:             uuid.New(),
+			OrganizationID: organization.ID,
+			Email:          "invitee@example.com",
+			Role:           constant.OrganizationRoleAdmin,
+			InvitedBy:      uuid.New(),
+			ExpiresAt:      time.Now().Add(time.Hour),
+			Organization:   organization,
+		}
+	}
+	signToken := func(t *testing.T, purpose string, invitation entity.OrganizationInvitation) string {
+		token, err := util.SignActionToken(
+			mockConfig.Auth.ActionTokenSecret,
+			purpose,
+			invitation.ID.String(),
+			invitation.Email,
+			time.Hour,
+		)
+		require.NoError(t, err)
+		return token
+	}
+	joins := func(invitation entity.OrganizationInvitation, userID uuid.UUID) interface{} {
+		return mock.MatchedBy(func(member entity.OrganizationMember) bool {
+			return member.UserID == userID &&
+				member.OrganizationID == invitation.OrganizationID &&
+				member.Role == invitation.Role
+		})
+	}
+	notFound := errors.NewNotFoundError("User not found")
+
+	t.Run("existing account joins", func(t *testing.T) {
+		t.Parallel()
+
+		invitation := newInvitation()
+		user := entity.User{ID: uuid.New(), Email: invitation.Email}
+		deps := newInvitationDeps()
+		deps.invitationRepo.On("GetInvitationByID", mock.Anything, invitation.ID).Return(invitation, nil)
+		deps.userRepo.On("GetUserByEmail", mock.Anything, invitation.Email).Return(user, nil)
+		deps.organizationRepo.On("GetUserMembership", mock.Anything, organization.ID, user.ID).
+			Return(entity.OrganizationMember{}, errors.NewNotFoundError("Not a member of the organization"))
+		deps.invitationRepo.On("AcceptInvitation", mock.Anything, invitation, joins(invitation, user.ID)).Return(true, nil)
+
+		resp, err := deps.service(mockConfig).AcceptInvitation(
+			context.Background(),
+			request.AcceptInvitationRequest{Token: signToken(t, constant.PurposeInvitation, invitation)},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, resp.UserID)
+		assert.Equal(t, organization.ID, resp.Organization.ID)
+		assert.Equal(t, invitation.Role, resp.Role)
+		assert.False(t, resp.AccountCreated)
+		deps.authService.AssertNotCalled(t, "Register", mock.Anything, mock.Anything)
+	})
+
+	t.Run("new account is registered", func(t *testing.T) {
+		t.Parallel()
+
+		invitation := newInvitation()
+		userID := uuid.New()
+		req := request.AcceptInvitationRequest{
+			FirstName:       "John",
+			LastName:        "Doe",
+			Password:        "password123",
+			ConfirmPassword: "password123",
+		}
+		req.Token = signToken(t, constant.PurposeInvitation, invitation)
+		deps := newInvitationDeps()
+		deps.invitationRepo.On("GetInvitationByID", mock.Anything, invitation.ID).Return(invitation, nil)
+		deps.userRepo.On("GetUserByEmail", mock.Anything, invitation.Email).Return(entity.User{}, notFound)
+		deps.authService.On("Register", mock.Anything, request.UserRegisterRequest{
+			FirstName:       req.FirstName,
+			LastName:        req.LastName,
+			Email:           invitation.Email,
+			Password:        req.Password,
+			ConfirmPassword: req.ConfirmPassword,
+		}).Return(response.UserInfoResponse{UserID: userID, Email: invitation.Email}, nil)
+		deps.userRepo.On("MarkEmailVerified", mock.Anything, userID, invitation.Email).Return(true, nil)
+		deps.invitationRepo.On("AcceptInvitation", mock.Anything, invitation, joins(invitation, userID)).Return(true, nil)
+
+		resp, err := deps.service(mockConfig).AcceptInvitation(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, userID, resp.UserID)
+		assert.True(t, resp.AccountCreated)
+		deps.userRepo.AssertExpectations(t)
+	})
+
+	t.Run("new account needs a name and password", func(t *testing.T) {
+		t.Parallel()
+
+		invitation := newInvitation()
+		deps := newInvitationDeps()
+		deps.invitationRepo.On("GetInvitationByID", mock.Anything, invitation.ID).Return(invitation, nil)
+		deps.userRepo.On("GetUserByEmail", mock.Anything, invitation.Email).Return(entity.User{}, notFound)
+
+		_, err := deps.service(mockConfig).AcceptInvitation(
+			context.Background(),
+			request.AcceptInvitationRequest{Token: signToken(t, constant.PurposeInvitation, invitation)},
+		)
+		require.Error(t, err)
+		deps.authService.AssertNotCalled(t, "Register", mock.Anything, mock.Anything)
+		deps.invitationRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	invalidCases := []struct {
+		name    string
+		purpose string
+		change  func(*entity.OrganizationInvitation)
+	}{
+		{
+			name:    "revoked invitation",
+			purpose: constant.PurposeInvitation,
+			change: func(invitation *entity.OrganizationInvitation) {
+				now := time.Now()
+				invitation.RevokedAt = &now
+			},
+		},
+		{
+			name:    "expired invitation",
+			purpose: constant.PurposeInvitation,
+			change: func(invitation *entity.OrganizationInvitation) {
+				invitation.ExpiresAt = time.Now().Add(-time.Minute)
+			},
+		},
+		{
+			name:    "token for another email",
+			purpose: constant.PurposeInvitation,
+			change: func(invitation *entity.OrganizationInvitation) {
+				invitation.Email = "someone-else@example.com"
+			},
+		},
+		{
+			name:    "token of another flow",
+			purpose: constant.PurposeEmailVerification,
+			change:  func(*entity.OrganizationInvitation) {},
+		},
+	}
+
+	for _, tc := range invalidCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			invitation := newInvitation()
+			token := signToken(t, tc.purpose, invitation)
+			tc.change(&invitation)
+			deps := newInvitationDeps()
+			deps.invitationRepo.On("GetInvitationByID", mock.Anything, invitation.ID).Return(invitation, nil).Maybe()
+
+			_, err := deps.service(mockConfig).AcceptInvitation(context.Background(), request.AcceptInvitationRequest{Token: token})
+			assert.Equal(t, errors.NewBadRequestError("Invalid or expired invitation"), err)
+			deps.invitationRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("invitation already redeemed", func(t *testing.T) {
+		t.Parallel()
+
+		invitation := newInvitation()
+		user := entity.User{ID: uuid.New(), Email: invitation.Email}
+		deps := newInvitationDeps()
+		deps.invitationRepo.On("GetInvitationByID", mock.Anything, invitation.ID).Return(invitation, nil)
+		deps.userRepo.On("GetUserByEmail", mock.Anything, invitation.Email).Return(user, nil)
+		deps.organizationRepo.On("GetUserMembership", mock.Anything, organization.ID, user.ID).
+			Return(entity.OrganizationMember{}, errors.NewNotFoundError("Not a member of the organization"))
+		deps.invitationRepo.On("AcceptInvitation", mock.Anything, invitation, mock.Anything).Return(false, nil)
+
+		_, err := deps.service(mockConfig).AcceptInvitation(
+			context.Background(),
+			request.AcceptInvitationRequest{Token: signToken(t, constant.PurposeInvitation, invitation)},
+		)
+		assert.Equal(t, errors.NewBadRequestError("Invalid or expired invitation"), err)
+	})
+}
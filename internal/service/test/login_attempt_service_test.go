@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoginAttemptService tests lockout thresholds, back-off and resets
+func TestLoginAttemptService(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		Auth: config.AuthConfig{
+			LoginLockoutThreshold:   3,
+			LoginIPLockoutThreshold: 5,
+			LoginLockoutDuration:    time.Minute,
+			LoginLockoutMaxDuration: 3 * time.Minute,
+			LoginAttemptWindow:      time.Hour,
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	ctx := context.Background()
+
+	assertLocked := func(t *testing.T, err error) {
+		require.Error(t, err)
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, constant.AccountLocked, appErr.Code)
+	}
+	lockedFor := func(t *testing.T, store repository.LoginAttemptStore, key string) time.Duration {
+		attempt, err := store.GetLoginAttempt(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, attempt.LockedUntil)
+		return time.Until(*attempt.LockedUntil).Round(time.Minute)
+	}
+
+	t.Run("account is locked with exponential back-off", func(t *testing.T) {
+		t.Parallel()
+
+		store := repository.NewMemoryLoginAttemptStore()
+		loginAttemptService := service.NewLoginAttemptService(store, mockLogger, mockConfig)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "Test@Example.com", "10.0.0.1"))
+		}
+		assert.NoError(t, loginAttemptService.CheckLogin(ctx, "test@example.com", "10.0.0.2"))
+
+		require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "test@example.com", "10.0.0.1"))
+		assertLocked(t, loginAttemptService.CheckLogin(ctx, "test@example.com", "10.0.0.2"))
+		assert.Equal(t, time.Minute, lockedFor(t, store, "account:test@example.com"))
+
+		require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "test@example.com", "10.0.0.1"))
+		assert.Equal(t, 2*time.Minute, lockedFor(t, store, "account:test@example.com"))
+
+		// The back-off is capped at the maximum duration
+		require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "test@example.com", "10.0.0.1"))
+		assert.Equal(t, 3*time.Minute, lockedFor(t, store, "account:test@example.com"))
+
+		// Other accounts are not affected by the account lock
+		assert.NoError(t, loginAttemptService.CheckLogin(ctx, "other@example.com", "10.0.0.2"))
+	})
+
+	t.Run("client IP is locked across accounts", func(t *testing.T) {
+		t.Parallel()
+
+		loginAttemptService := service.NewLoginAttemptService(
+			repository.NewMemoryLoginAttemptStore(), mockLogger, mockConfig,
+		)
+		emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}
+		for _, email := range emails {
+			require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, email, "10.0.0.1"))
+		}
+		assertLocked(t, loginAttemptService.CheckLogin(ctx, "f@example.com", "10.0.0.1"))
+		assert.NoError(t, loginAttemptService.CheckLogin(ctx, "f@example.com", "10.0.0.2"))
+	})
+
+	t.Run("successful login resets the account counter only", func(t *testing.T) {
+		t.Parallel()
+
+		store := repository.NewMemoryLoginAttemptStore()
+		loginAttemptService := service.NewLoginAttemptService(store, mockLogger, mockConfig)
+		for i := 0; i < 2; i++ {
+			require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "test@example.com", "10.0.0.1"))
+		}
+		require.NoError(t, loginAttemptService.ResetLogin(ctx, "test@example.com"))
+
+		require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "test@example.com", "10.0.0.1"))
+		attempt, err := store.GetLoginAttempt(ctx, "account:test@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempt.Failures)
+		attempt, err = store.GetLoginAttempt(ctx, "ip:10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempt.Failures)
+		assert.NoError(t, loginAttemptService.CheckLogin(ctx, "test@example.com", "10.0.0.1"))
+	})
+
+	t.Run("logging into another account doesn't unlock the client IP", func(t *testing.T) {
+		t.Parallel()
+
+		loginAttemptService := service.NewLoginAttemptService(
+			repository.NewMemoryLoginAttemptStore(), mockLogger, mockConfig,
+		)
+		emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"}
+		for _, email := range emails {
+			require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, email, "10.0.0.1"))
+		}
+		require.NoError(t, loginAttemptService.ResetLogin(ctx, "attacker@example.com"))
+		require.NoError(t, loginAttemptService.RecordFailedLogin(ctx, "e@example.com", "10.0.0.1"))
+		assertLocked(t, loginAttemptService.CheckLogin(ctx, "f@example.com", "10.0.0.1"))
+	})
+
+	t.Run("failures older than the window are forgotten", func(t *testing.T) {
+		t.Parallel()
+
+		store := repository.NewMemoryLoginAttemptStore()
+		for i := 0; i < 2; i++ {
+			_, err := store.RecordLoginFailure(ctx, "account:test@example.com", time.Hour)
+			require.NoError(t, err)
+		}
+		time.Sleep(time.Millisecond)
+		failures, err := store.RecordLoginFailure(ctx, "account:test@example.com", time.Microsecond)
+		require.NoError(t, err)
+		assert.Equal(t, 1, failures)
+	})
+}
@@ -0,0 +1,268 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newMagicLinkTestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+		Auth: config.AuthConfig{
+			MagicLinkEnabled:  true,
+			MagicLinkURL:      "http://localhost:3000/magic-link",
+			MagicLinkTokenTTL: 15 * time.Minute,
+		},
+	}
+}
+
+// TestMagicLinkService_RequestMagicLink tests that login links are only mailed to registered users
+func TestMagicLinkService_RequestMagicLink(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newMagicLinkTestConfig()
+	mockLogger := logger.NewLogger(mockConfig)
+	user := entity.User{
+		ID:        uuid.New(),
+		Email:     "test@example.com",
+		FirstName: "John",
+	}
+
+	t.Run("registered email receives a link", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		mockMagicLinkRepo := new(MockMagicLinkTokenRepo)
+		mockNotifier := new(MockNotifier)
+
+		var stored entity.MagicLinkToken
+		var sent notifier.Message
+		mockUserRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		mockMagicLinkRepo.On("InvalidateUserMagicLinkTokens", mock.Anything, user.ID).Return(nil)
+		mockMagicLinkRepo.On("CreateMagicLinkToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(entity.MagicLinkToken)
+			}).
+			Return(nil)
+		mockNotifier.On("Send", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(notifier.Message)
+			}).
+			Return(nil)
+
+		magicLinkService := service.NewMagicLinkService(
+			mockMagicLinkRepo, mockUserRepo, nil, newMockMFAService(), mockNotifier, mockLogger, mockConfig,
+		)
+		err := magicLinkService.RequestMagicLink(context.Background(), request.MagicLinkRequest{Email: user.Email})
+		require.NoError(t, err)
+
+		assert.Equal(t, user.Email, sent.To)
+		linkStart := strings.Index(sent.Body, mockConfig.Auth.MagicLinkURL)
+		require.GreaterOrEqual(t, linkStart, 0)
+		link, err := url.Parse(strings.Fields(sent.Body[linkStart:])[0])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+		assert.NotEmpty(t, token)
+		assert.Equal(t, util.HashToken(token), stored.TokenHash)
+		assert.Equal(t, user.ID, stored.UserID)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), stored.ExpiresAt, time.Minute)
+		mockMagicLinkRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown email is not revealed", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		mockNotifier := new(MockNotifier)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").
+			Return(entity.User{}, errors.NewNotFoundError("User not found"))
+
+		magicLinkService := service.NewMagicLinkService(
+			new(MockMagicLinkTokenRepo), mockUserRepo, nil, newMockMFAService(), mockNotifier, mockLogger, mockConfig,
+		)
+		err := magicLinkService.RequestMagicLink(context.Background(), request.MagicLinkRequest{Email: "nobody@example.com"})
+		assert.NoError(t, err)
+		mockNotifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		disabledConfig := newMagicLinkTestConfig()
+		disabledConfig.Auth.MagicLinkEnabled = false
+		mockUserRepo := new(MockUserRepo)
+
+		magicLinkService := service.NewMagicLinkService(
+			new(MockMagicLinkTokenRepo), mockUserRepo, nil, newMockMFAService(), new(MockNotifier), mockLogger, disabledConfig,
+		)
+		err := magicLinkService.RequestMagicLink(context.Background(), request.MagicLinkRequest{Email: user.Email})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+		mockUserRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+	})
+}
+
+// TestMagicLinkService_VerifyMagicLink tests redeeming magic link tokens
+func TestMagicLinkService_VerifyMagicLink(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newMagicLinkTestConfig()
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+	token := "magic-link-token"
+	validToken := entity.MagicLinkToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		mockSetup      func(*MockMagicLinkTokenRepo, *MockUserRepo, *MockMFAService)
+		expectedStatus int
+		expectTokens   bool
+		expectMFA      bool
+	}{
+		{
+			name: "successful login verifies the email and drops previous credentials",
+			mockSetup: func(m *MockMagicLinkTokenRepo, u *MockUserRepo, _ *MockMFAService) {
+				m.On("GetMagicLinkTokenByHash", mock.Anything, validToken.TokenHash).Return(validToken, nil)
+				m.On("MarkMagicLinkTokenUsed", mock.Anything, validToken.ID).Return(true, nil)
+				u.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				u.On("ClaimUnverifiedAccount", mock.Anything, user.ID, user.Email).Return(true, nil)
+			},
+			expectTokens: true,
+		},
+		{
+			name: "mfa challenge",
+			mockSetup: func(m *MockMagicLinkTokenRepo, u *MockUserRepo, mfa *MockMFAService) {
+				verifiedAt := time.Now()
+				verified := user
+				verified.EmailVerifiedAt = &verifiedAt
+				m.On("GetMagicLinkTokenByHash", mock.Anything, validToken.TokenHash).Return(validToken, nil)
+				m.On("MarkMagicLinkTokenUsed", mock.Anything, validToken.ID).Return(true, nil)
+				u.On("GetUserByID", mock.Anything, user.ID).Return(verified, nil)
+				mfa.On("ChallengeLogin", mock.Anything, mock.Anything).Return("mfa-token", nil)
+			},
+			expectMFA: true,
+		},
+		{
+			name: "unknown token",
+			mockSetup: func(m *MockMagicLinkTokenRepo, _ *MockUserRepo, _ *MockMFAService) {
+				m.On("GetMagicLinkTokenByHash", mock.Anything, validToken.TokenHash).
+					Return(entity.MagicLinkToken{}, errors.NewNotFoundError("Magic link not found"))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "expired token",
+			mockSetup: func(m *MockMagicLinkTokenRepo, _ *MockUserRepo, _ *MockMFAService) {
+				expired := validToken
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				m.On("GetMagicLinkTokenByHash", mock.Anything, validToken.TokenHash).Return(expired, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "used token",
+			mockSetup: func(m *MockMagicLinkTokenRepo, _ *MockUserRepo, _ *MockMFAService) {
+				used := validToken
+				used.UsedAt = &usedAt
+				m.On("GetMagicLinkTokenByHash", mock.Anything, validToken.TokenHash).Return(used, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "token redeemed concurrently",
+			mockSetup: func(m *MockMagicLinkTokenRepo, _ *MockUserRepo, _ *MockMFAService) {
+				m.On("GetMagicLinkTokenByHash", mock.Anything, validToken.TokenHash).Return(validToken, nil)
+				m.On("MarkMagicLinkTokenUsed", mock.Anything, validToken.ID).Return(false, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockMagicLinkRepo := new(MockMagicLinkTokenRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockMFAService := new(MockMFAService)
+			tc.mockSetup(mockMagicLinkRepo, mockUserRepo, mockMFAService)
+			mockMFAService.On("ChallengeLogin", mock.Anything, mock.Anything).Return("", nil).Maybe()
+
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+			tokenService := service.NewTokenService(
+				mockRefreshTokenRepo,
+				newMockSessionRepo(),
+				mockUserRepo,
+				newMockRoleRepo(),
+				newMockOrganizationRepo(),
+				repository.NewMemoryTokenRevocationStore(),
+				keySet,
+				mockLogger,
+				mockConfig,
+			)
+			magicLinkService := service.NewMagicLinkService(
+				mockMagicLinkRepo, mockUserRepo, tokenService, mockMFAService, new(MockNotifier), mockLogger, mockConfig,
+			)
+
+			resp, err := magicLinkService.VerifyMagicLink(context.Background(), request.MagicLinkVerifyRequest{Token: token})
+			if tc.expectedStatus != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedStatus, err.(*errors.AppError).Status)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectMFA {
+				assert.True(t, resp.MFARequired)
+				assert.Equal(t, "mfa-token", resp.MFAToken)
+				assert.Empty(t, resp.Token)
+			}
+			if tc.expectTokens {
+				assert.NotEmpty(t, resp.Token)
+				assert.NotEmpty(t, resp.RefreshToken)
+			}
+			mockMagicLinkRepo.AssertExpectations(t)
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
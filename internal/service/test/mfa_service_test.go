@@ -0,0 +1,364 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newMFATestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+		Auth: config.AuthConfig{
+			ActionTokenSecret: "action_secret",
+			MFAIssuer:         "iEnergy",
+			MFAChallengeTTL:   5 * time.Minute,
+
+			LoginLockoutThreshold:   3,
+			LoginLockoutDuration:    time.Minute,
+			LoginLockoutMaxDuration: time.Hour,
+			LoginAttemptWindow:      time.Hour,
+		},
+	}
+}
+
+func newMFAService(t *testing.T, mfaRepo *MockMFARepo, userRepo *MockUserRepo, cfg *config.Config) service.MFAService {
+	keySet, err := util.NewJWTKeySet(cfg)
+	require.NoError(t, err)
+	refreshTokenRepo := new(MockRefreshTokenRepo)
+	refreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+	tokenService := service.NewTokenService(
+		refreshTokenRepo,
+		newMockSessionRepo(),
+		userRepo,
+		newMockRoleRepo(),
+		newMockOrganizationRepo(),
+		repository.NewMemoryTokenRevocationStore(),
+		keySet,
+		logger.NewLogger(cfg),
+		cfg,
+	)
+	loginAttemptService := service.NewLoginAttemptService(repository.NewMemoryLoginAttemptStore(), logger.NewLogger(cfg), cfg)
+	return service.NewMFAService(
+		mfaRepo,
+		userRepo,
+		tokenService,
+		loginAttemptService,
+		repository.NewMemoryTokenRevocationStore(),
+		logger.NewLogger(cfg),
+		cfg,
+	)
+}
+
+// TestTOTPCode checks the generator against the RFC 6238 SHA-1 test vectors
+func TestTOTPCode(t *testing.T) {
+	t.Parallel()
+
+	// base32 of the ASCII seed "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range vectors {
+		code, err := util.TOTPCode(secret, util.TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+// TestMFAService_Enrollment tests starting and confirming TOTP enrolment
+func TestMFAService_Enrollment(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newMFATestConfig()
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+
+	t.Run("enroll returns secret and otpauth URI", func(t *testing.T) {
+		t.Parallel()
+
+		mockMFARepo := new(MockMFARepo)
+		mockUserRepo := new(MockUserRepo)
+		mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).
+			Return(entity.MFAFactor{}, errors.NewNotFoundError("MFA factor not found"))
+		mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockMFARepo.On("ReplacePendingMFAFactor", mock.Anything, mock.MatchedBy(func(f entity.MFAFactor) bool {
+			return f.UserID == user.ID && f.Secret != "" && f.ConfirmedAt == nil
+		})).Return(nil)
+
+		resp, err := newMFAService(t, mockMFARepo, mockUserRepo, mockConfig).Enroll(ctx)
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.Secret)
+		assert.True(t, strings.HasPrefix(resp.OTPAuthURI, "otpauth://totp/iEnergy:test@example.com?"))
+		assert.Contains(t, resp.OTPAuthURI, "secret="+resp.Secret)
+		mockMFARepo.AssertExpectations(t)
+	})
+
+	t.Run("enroll refuses when MFA is already enabled", func(t *testing.T) {
+		t.Parallel()
+
+		confirmedAt := time.Now()
+		mockMFARepo := new(MockMFARepo)
+		mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).
+			Return(entity.MFAFactor{ID: uuid.New(), UserID: user.ID, ConfirmedAt: &confirmedAt}, nil)
+
+		_, err := newMFAService(t, mockMFARepo, new(MockUserRepo), mockConfig).Enroll(ctx)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusConflict, err.(*errors.AppError).Status)
+		mockMFARepo.AssertNotCalled(t, "ReplacePendingMFAFactor", mock.Anything, mock.Anything)
+	})
+
+	t.Run("confirm with a valid code returns hashed recovery codes", func(t *testing.T) {
+		t.Parallel()
+
+		secret, err := util.GenerateTOTPSecret()
+		require.NoError(t, err)
+		factor := entity.MFAFactor{ID: uuid.New(), UserID: user.ID, Secret: secret}
+		code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+		require.NoError(t, err)
+
+		var stored []entity.MFARecoveryCode
+		mockMFARepo := new(MockMFARepo)
+		mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).Return(factor, nil)
+		mockMFARepo.On("ConfirmMFAFactor", mock.Anything, factor, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(3).([]entity.MFARecoveryCode)
+			}).
+			Return(true, nil)
+
+		resp, err := newMFAService(t, mockMFARepo, new(MockUserRepo), mockConfig).
+			Confirm(ctx, request.MFAConfirmRequest{Code: code})
+		require.NoError(t, err)
+		require.Len(t, resp.RecoveryCodes, 10)
+		require.Len(t, stored, 10)
+		for i, recoveryCode := range resp.RecoveryCodes {
+			assert.Equal(t, util.HashToken(strings.ReplaceAll(recoveryCode, "-", "")), stored[i].CodeHash)
+		}
+	})
+
+	t.Run("confirm rejects a wrong code", func(t *testing.T) {
+		t.Parallel()
+
+		secret, err := util.GenerateTOTPSecret()
+		require.NoError(t, err)
+		mockMFARepo := new(MockMFARepo)
+		mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).
+			Return(entity.MFAFactor{ID: uuid.New(), UserID: user.ID, Secret: secret}, nil)
+
+		_, err = newMFAService(t, mockMFARepo, new(MockUserRepo), mockConfig).
+			Confirm(ctx, request.MFAConfirmRequest{Code: "12345"})
+		assert.Error(t, err)
+		mockMFARepo.AssertNotCalled(t, "ConfirmMFAFactor", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestMFAService_Verify tests the second step of an MFA login
+func TestMFAService_Verify(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newMFATestConfig()
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	confirmedAt := time.Now()
+	factor := entity.MFAFactor{ID: uuid.New(), UserID: user.ID, Secret: secret, ConfirmedAt: &confirmedAt}
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+
+	challenge := func(t *testing.T, ttl time.Duration) string {
+		token, err := util.SignActionToken(
+			mockConfig.Auth.ActionTokenSecret, constant.PurposeMFAChallenge, user.ID.String(), user.Email, ttl,
+		)
+		require.NoError(t, err)
+		return token
+	}
+
+	testCases := []struct {
+		name      string
+		req       func(*testing.T) request.MFAVerifyRequest
+		mockSetup func(*MockMFARepo)
+		expectErr bool
+	}{
+		{
+			name: "valid TOTP code",
+			req: func(t *testing.T) request.MFAVerifyRequest {
+				return request.MFAVerifyRequest{MFAToken: challenge(t, time.Minute), Code: code}
+			},
+			mockSetup: func(m *MockMFARepo) {
+				m.On("UseMFAStep", mock.Anything, factor.ID, mock.Anything).Return(true, nil)
+			},
+		},
+		{
+			name: "replayed TOTP code",
+			req: func(t *testing.T) request.MFAVerifyRequest {
+				return request.MFAVerifyRequest{MFAToken: challenge(t, time.Minute), Code: code}
+			},
+			mockSetup: func(m *MockMFARepo) {
+				m.On("UseMFAStep", mock.Anything, factor.ID, mock.Anything).Return(false, nil)
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid recovery code typed with dash and upper case",
+			req: func(t *testing.T) request.MFAVerifyRequest {
+				return request.MFAVerifyRequest{MFAToken: challenge(t, time.Minute), RecoveryCode: "ABCDE-FGHIJ"}
+			},
+			mockSetup: func(m *MockMFARepo) {
+				m.On("UseMFARecoveryCode", mock.Anything, user.ID, util.HashToken("abcdefghij")).Return(true, nil)
+			},
+		},
+		{
+			name: "wrong TOTP code",
+			req: func(t *testing.T) request.MFAVerifyRequest {
+				return request.MFAVerifyRequest{MFAToken: challenge(t, time.Minute), Code: "000000x"}
+			},
+			mockSetup: func(m *MockMFARepo) {},
+			expectErr: true,
+		},
+		{
+			name: "expired challenge",
+			req: func(t *testing.T) request.MFAVerifyRequest {
+				return request.MFAVerifyRequest{MFAToken: challenge(t, -time.Minute), Code: code}
+			},
+			mockSetup: func(m *MockMFARepo) {},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockMFARepo := new(MockMFARepo)
+			mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).Return(factor, nil).Maybe()
+			tc.mockSetup(mockMFARepo)
+			mockUserRepo := new(MockUserRepo)
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+
+			resp, err := newMFAService(t, mockMFARepo, mockUserRepo, mockConfig).Verify(context.Background(), tc.req(t))
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.Equal(t, http.StatusUnauthorized, err.(*errors.AppError).Status)
+				assert.Empty(t, resp.Token)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, resp.Token)
+			assert.NotEmpty(t, resp.RefreshToken)
+			mockMFARepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestMFAService_VerifyLimits tests that wrong codes lock MFA for the user, that a challenge is spent
+// once it issued tokens, and that an account suspended after the password check gets no tokens
+func TestMFAService_VerifyLimits(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newMFATestConfig()
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	confirmedAt := time.Now()
+	factor := entity.MFAFactor{ID: uuid.New(), UserID: user.ID, Secret: secret, ConfirmedAt: &confirmedAt}
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	challenge, err := util.SignActionToken(
+		mockConfig.Auth.ActionTokenSecret, constant.PurposeMFAChallenge, user.ID.String(), user.Email, time.Minute,
+	)
+	require.NoError(t, err)
+
+	t.Run("wrong codes lock the user out", func(t *testing.T) {
+		t.Parallel()
+
+		mockMFARepo := new(MockMFARepo)
+		mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).Return(factor, nil)
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mfaService := newMFAService(t, mockMFARepo, mockUserRepo, mockConfig)
+
+		for i := 0; i < mockConfig.Auth.LoginLockoutThreshold; i++ {
+			_, err := mfaService.Verify(context.Background(), request.MFAVerifyRequest{MFAToken: challenge, Code: "000000x"})
+			require.Error(t, err)
+			assert.Equal(t, http.StatusUnauthorized, err.(*errors.AppError).Status)
+		}
+
+		_, err := mfaService.Verify(context.Background(), request.MFAVerifyRequest{MFAToken: challenge, Code: code})
+		require.Error(t, err)
+		assert.Equal(t, errors.NewAccountLockedError("").Status, err.(*errors.AppError).Status)
+		mockMFARepo.AssertNotCalled(t, "UseMFAStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("challenge is single-use", func(t *testing.T) {
+		t.Parallel()
+
+		mockMFARepo := new(MockMFARepo)
+		mockMFARepo.On("GetMFAFactorByUserID", mock.Anything, user.ID).Return(factor, nil)
+		mockMFARepo.On("UseMFARecoveryCode", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mfaService := newMFAService(t, mockMFARepo, mockUserRepo, mockConfig)
+
+		req := request.MFAVerifyRequest{MFAToken: challenge, RecoveryCode: "abcde-fghij"}
+		_, err := mfaService.Verify(context.Background(), req)
+		require.NoError(t, err)
+
+		req.RecoveryCode = "klmno-pqrst"
+		_, err = mfaService.Verify(context.Background(), req)
+		require.Error(t, err)
+		assert.Equal(t, errors.NewUnauthorizedError("Invalid or expired MFA challenge").Error(), err.Error())
+		mockMFARepo.AssertNumberOfCalls(t, "UseMFARecoveryCode", 1)
+	})
+
+	t.Run("account suspended after the password check", func(t *testing.T) {
+		t.Parallel()
+
+		suspended := user
+		suspended.State = enum.EnumStateDB(enum.StateSuspended)
+		mockMFARepo := new(MockMFARepo)
+		mockUserRepo := new(MockUserRepo)
+		mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(suspended, nil)
+
+		resp, err := newMFAService(t, mockMFARepo, mockUserRepo, mockConfig).
+			Verify(context.Background(), request.MFAVerifyRequest{MFAToken: challenge, Code: code})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+		assert.Empty(t, resp.Token)
+		mockMFARepo.AssertNotCalled(t, "UseMFAStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,44 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) CreateAPIKey(ctx context.Context, key entity.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) GetAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]entity.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, keyID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) TouchAPIKey(ctx context.Context, keyID uuid.UUID, usedBefore time.Time) error {
+	args := m.Called(ctx, keyID, usedBefore)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) RevokeUserAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
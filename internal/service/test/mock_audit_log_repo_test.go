@@ -0,0 +1,23 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditLogRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepo) CreateAuditLog(ctx context.Context, log entity.AuditLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepo) GetAuditLogsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.AuditLog, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.AuditLog), args.Error(1)
+}
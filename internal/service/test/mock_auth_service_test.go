@@ -0,0 +1,33 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Login(ctx context.Context, req request.UserLoginRequest) (response.TokenResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.TokenResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Register(ctx context.Context, req request.UserRegisterRequest) (response.UserInfoResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.UserInfoResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, req request.RefreshTokenRequest) (response.TokenResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.TokenResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, req request.LogoutRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDataExportRepo struct {
+	mock.Mock
+}
+
+func (m *MockDataExportRepo) CreateDataExport(ctx context.Context, export entity.DataExport) error {
+	args := m.Called(ctx, export)
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepo) GetDataExportByID(ctx context.Context, exportID uuid.UUID) (entity.DataExport, error) {
+	args := m.Called(ctx, exportID)
+	return args.Get(0).(entity.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepo) GetDataExportsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.DataExport, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepo) GetPendingDataExports(ctx context.Context) ([]entity.DataExport, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepo) HasPendingDataExport(ctx context.Context, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDataExportRepo) CompleteDataExport(
+	ctx context.Context,
+	exportID uuid.UUID,
+	archive []byte,
+	expiresAt time.Time,
+) (bool, error) {
+	args := m.Called(ctx, exportID, archive, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDataExportRepo) FailDataExport(ctx context.Context, exportID uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(ctx, exportID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepo) DeleteExpiredDataExports(ctx context.Context, now time.Time) error {
+	args := m.Called(ctx, now)
+	return args.Error(0)
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInvitationRepo struct {
+	mock.Mock
+}
+
+func (m *MockInvitationRepo) CreateInvitation(ctx context.Context, invitation entity.OrganizationInvitation) error {
+	args := m.Called(ctx, invitation)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepo) GetInvitations(ctx context.Context) ([]entity.OrganizationInvitation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) GetInvitation(ctx context.Context, invitationID uuid.UUID) (entity.OrganizationInvitation, error) {
+	args := m.Called(ctx, invitationID)
+	return args.Get(0).(entity.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) RevokeInvitation(ctx context.Context, invitationID uuid.UUID, revokedBy string) (bool, error) {
+	args := m.Called(ctx, invitationID, revokedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInvitationRepo) GetInvitationByID(
+	ctx context.Context,
+	invitationID uuid.UUID,
+) (entity.OrganizationInvitation, error) {
+	args := m.Called(ctx, invitationID)
+	return args.Get(0).(entity.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) GetUserInvitations(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+) ([]entity.OrganizationInvitation, error) {
+	args := m.Called(ctx, userID, email)
+	return args.Get(0).([]entity.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) AcceptInvitation(
+	ctx context.Context,
+	invitation entity.OrganizationInvitation,
+	member entity.OrganizationMember,
+) (bool, error) {
+	args := m.Called(ctx, invitation, member)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMagicLinkTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockMagicLinkTokenRepo) CreateMagicLinkToken(ctx context.Context, token entity.MagicLinkToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkTokenRepo) GetMagicLinkTokenByHash(
+	ctx context.Context,
+	tokenHash string,
+) (entity.MagicLinkToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(entity.MagicLinkToken), args.Error(1)
+}
+
+func (m *MockMagicLinkTokenRepo) MarkMagicLinkTokenUsed(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, tokenID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMagicLinkTokenRepo) InvalidateUserMagicLinkTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
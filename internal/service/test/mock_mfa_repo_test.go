@@ -0,0 +1,43 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFARepo struct {
+	mock.Mock
+}
+
+func (m *MockMFARepo) GetMFAFactorByUserID(ctx context.Context, userID uuid.UUID) (entity.MFAFactor, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(entity.MFAFactor), args.Error(1)
+}
+
+func (m *MockMFARepo) ReplacePendingMFAFactor(ctx context.Context, factor entity.MFAFactor) error {
+	args := m.Called(ctx, factor)
+	return args.Error(0)
+}
+
+func (m *MockMFARepo) ConfirmMFAFactor(
+	ctx context.Context,
+	factor entity.MFAFactor,
+	step int64,
+	recoveryCodes []entity.MFARecoveryCode,
+) (bool, error) {
+	args := m.Called(ctx, factor, step, recoveryCodes)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepo) UseMFAStep(ctx context.Context, factorID uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, factorID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepo) UseMFARecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,44 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+// newMockMFAService returns a mock for users without MFA, so logins are not challenged
+func newMockMFAService() *MockMFAService {
+	m := new(MockMFAService)
+	m.On("ChallengeLogin", mock.Anything, mock.Anything).Return("", nil).Maybe()
+	return m
+}
+
+func (m *MockMFAService) Enroll(ctx context.Context) (response.MFAEnrollmentResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(response.MFAEnrollmentResponse), args.Error(1)
+}
+
+func (m *MockMFAService) Confirm(
+	ctx context.Context,
+	req request.MFAConfirmRequest,
+) (response.MFARecoveryCodesResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.MFARecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockMFAService) ChallengeLogin(ctx context.Context, user entity.User) (string, error) {
+	args := m.Called(ctx, user)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(ctx context.Context, req request.MFAVerifyRequest) (response.TokenResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.TokenResponse), args.Error(1)
+}
@@ -0,0 +1,17 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/pkg/notifier"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Send(ctx context.Context, msg notifier.Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
@@ -0,0 +1,33 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOAuthClientRepo struct {
+	mock.Mock
+}
+
+func (m *MockOAuthClientRepo) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepo) GetOAuthClients(ctx context.Context) ([]entity.OAuthClient, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepo) GetOAuthClientByClientID(ctx context.Context, clientID string) (entity.OAuthClient, error) {
+	args := m.Called(ctx, clientID)
+	return args.Get(0).(entity.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepo) RevokeOAuthClient(ctx context.Context, id uuid.UUID, revokedBy string) (bool, error) {
+	args := m.Called(ctx, id, revokedBy)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"ienergy-template-go/pkg/oidc"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDCProvider is a minimal OpenID Connect provider serving discovery, JWKS and the token endpoint
+type mockOIDCProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu     sync.Mutex
+	grants map[string]mockOIDCGrant
+}
+
+// mockOIDCGrant is what the provider remembers about an issued authorization code
+type mockOIDCGrant struct {
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+func newMockOIDCProvider(t *testing.T, clientID string) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &mockOIDCProvider{
+		key:      key,
+		clientID: clientID,
+		grants:   make(map[string]mockOIDCGrant),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// grant registers an authorization code as if the user had logged in at the provider
+func (p *mockOIDCProvider) grant(code, codeChallenge string, claims jwt.MapClaims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grants[code] = mockOIDCGrant{codeChallenge: codeChallenge, claims: claims}
+}
+
+// idToken signs claims as the provider, filling in the standard claims
+func (p *mockOIDCProvider) idToken(key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.MapClaims{
+		"iss": p.server.URL,
+		"aud": p.clientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		token[name] = value
+	}
+	signed := jwt.NewWithClaims(jwt.SigningMethodRS256, token)
+	signed.Header["kid"] = "test-key"
+	raw, _ := signed.SignedString(key)
+	return raw
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	p.mu.Lock()
+	grant, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok || r.PostForm.Get("client_id") != p.clientID ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != grant.codeChallenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"id_token":     p.idToken(p.key, grant.claims),
+	})
+}
@@ -0,0 +1,41 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOIDCRepo struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepo) CreateOIDCLoginState(ctx context.Context, state entity.OIDCLoginState) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepo) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (entity.OIDCLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	if fn, ok := args.Get(0).(func(context.Context, string) (entity.OIDCLoginState, error)); ok {
+		return fn(ctx, stateHash)
+	}
+	return args.Get(0).(entity.OIDCLoginState), args.Error(1)
+}
+
+func (m *MockOIDCRepo) GetUserIdentity(ctx context.Context, provider, subject string) (entity.UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	return args.Get(0).(entity.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepo) GetUserIdentitiesByUserID(ctx context.Context, userID uuid.UUID) ([]entity.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepo) CreateUserIdentity(ctx context.Context, identity entity.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrganizationRepo struct {
+	mock.Mock
+}
+
+// newMockOrganizationRepo returns an organization repo mock where every user belongs to no organization
+// unless told otherwise
+func newMockOrganizationRepo() *MockOrganizationRepo {
+	m := new(MockOrganizationRepo)
+	m.On("GetUserMemberships", mock.Anything, mock.Anything).Return([]entity.OrganizationMember{}, nil).Maybe()
+	return m
+}
+
+func (m *MockOrganizationRepo) CreateOrganization(
+	ctx context.Context,
+	organization entity.Organization,
+	owner entity.OrganizationMember,
+) error {
+	args := m.Called(ctx, organization, owner)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepo) GetOrganizationByID(ctx context.Context, organizationID uuid.UUID) (entity.Organization, error) {
+	args := m.Called(ctx, organizationID)
+	return args.Get(0).(entity.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) IsSlugTaken(ctx context.Context, slug string) (bool, error) {
+	args := m.Called(ctx, slug)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) UpdateOrganization(ctx context.Context, organizationID uuid.UUID, name, updatedBy string) error {
+	args := m.Called(ctx, organizationID, name, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepo) GetUserMembership(
+	ctx context.Context,
+	organizationID, userID uuid.UUID,
+) (entity.OrganizationMember, error) {
+	args := m.Called(ctx, organizationID, userID)
+	return args.Get(0).(entity.OrganizationMember), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) GetUserMemberships(ctx context.Context, userID uuid.UUID) ([]entity.OrganizationMember, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.OrganizationMember), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) GetMembers(ctx context.Context) ([]entity.OrganizationMember, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.OrganizationMember), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) GetMember(ctx context.Context, userID uuid.UUID) (entity.OrganizationMember, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(entity.OrganizationMember), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) UpdateMemberRole(ctx context.Context, userID uuid.UUID, role, updatedBy string) (bool, error) {
+	args := m.Called(ctx, userID, role, updatedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) RemoveMember(ctx context.Context, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) CountOwners(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
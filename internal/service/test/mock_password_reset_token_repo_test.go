@@ -0,0 +1,36 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordResetTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetTokenRepo) CreatePasswordResetToken(ctx context.Context, token entity.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepo) GetPasswordResetTokenByHash(
+	ctx context.Context,
+	tokenHash string,
+) (entity.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(entity.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetTokenRepo) MarkPasswordResetTokenUsed(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, tokenID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetTokenRepo) InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
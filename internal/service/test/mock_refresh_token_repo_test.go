@@ -0,0 +1,47 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRefreshTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepo) CreateRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) GetRefreshTokenByID(ctx context.Context, tokenID uuid.UUID) (entity.RefreshToken, error) {
+	args := m.Called(ctx, tokenID)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) RevokeRefreshToken(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	replacedBy *uuid.UUID,
+) (bool, error) {
+	args := m.Called(ctx, tokenID, replacedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) RevokeOtherUserRefreshTokens(ctx context.Context, userID, keepFamilyID uuid.UUID) error {
+	args := m.Called(ctx, userID, keepFamilyID)
+	return args.Error(0)
+}
@@ -0,0 +1,40 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoleRepo struct {
+	mock.Mock
+}
+
+// newMockRoleRepo returns a role repo mock where every user has no roles unless told otherwise
+func newMockRoleRepo() *MockRoleRepo {
+	m := new(MockRoleRepo)
+	m.On("GetRolesByUserID", mock.Anything, mock.Anything).Return([]entity.Role{}, nil).Maybe()
+	return m
+}
+
+func (m *MockRoleRepo) GetRoleByName(ctx context.Context, name string) (entity.Role, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(entity.Role), args.Error(1)
+}
+
+func (m *MockRoleRepo) GetRolesByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Role), args.Error(1)
+}
+
+func (m *MockRoleRepo) AssignRole(ctx context.Context, userID uuid.UUID, roleName string) error {
+	args := m.Called(ctx, userID, roleName)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) SeedRoles(ctx context.Context, rolePermissions map[string][]string) error {
+	args := m.Called(ctx, rolePermissions)
+	return args.Error(0)
+}
@@ -0,0 +1,64 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepo struct {
+	mock.Mock
+}
+
+// newMockSessionRepo returns a session repo that accepts sessions being created and rotated,
+// for tests that issue tokens but are not about sessions. Sessions are in no organization.
+func newMockSessionRepo() *MockSessionRepo {
+	m := new(MockSessionRepo)
+	m.On("CreateSession", mock.Anything, mock.Anything).Return(nil).Maybe()
+	m.On("RotateSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	m.On("GetSessionByID", mock.Anything, mock.Anything).Return(entity.Session{}, nil).Maybe()
+	return m
+}
+
+func (m *MockSessionRepo) CreateSession(ctx context.Context, session entity.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (entity.Session, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) GetSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) RotateSession(
+	ctx context.Context,
+	sessionID uuid.UUID,
+	ipAddress, userAgent string,
+	expiresAt time.Time,
+) error {
+	args := m.Called(ctx, sessionID, ipAddress, userAgent, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) TouchSession(ctx context.Context, sessionID uuid.UUID, seenBefore time.Time) error {
+	args := m.Called(ctx, sessionID, seenBefore)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) SetSessionOrganization(ctx context.Context, sessionID uuid.UUID, organizationID *uuid.UUID) error {
+	args := m.Called(ctx, sessionID, organizationID)
+	return args.Error(0)
+}
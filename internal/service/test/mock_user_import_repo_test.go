@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserImportRepo struct {
+	mock.Mock
+}
+
+func (m *MockUserImportRepo) CreateUserImport(ctx context.Context, userImport entity.UserImport) error {
+	args := m.Called(ctx, userImport)
+	return args.Error(0)
+}
+
+func (m *MockUserImportRepo) GetUserImports(ctx context.Context) ([]entity.UserImport, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.UserImport), args.Error(1)
+}
+
+func (m *MockUserImportRepo) GetUserImportByID(ctx context.Context, importID uuid.UUID) (entity.UserImport, error) {
+	args := m.Called(ctx, importID)
+	return args.Get(0).(entity.UserImport), args.Error(1)
+}
+
+func (m *MockUserImportRepo) GetPendingUserImports(ctx context.Context) ([]entity.UserImport, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.UserImport), args.Error(1)
+}
+
+func (m *MockUserImportRepo) CompleteUserImport(
+	ctx context.Context,
+	importID uuid.UUID,
+	createdRows int,
+	rowErrors []entity.UserImportError,
+) (bool, error) {
+	args := m.Called(ctx, importID, createdRows, rowErrors)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserImportRepo) FailUserImport(ctx context.Context, importID uuid.UUID) error {
+	args := m.Called(ctx, importID)
+	return args.Error(0)
+}
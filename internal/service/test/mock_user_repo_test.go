@@ -3,6 +3,8 @@ package service_test
 import (
 	"context"
 	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -14,35 +16,100 @@ type MockUserRepo struct {
 
 func (m *MockUserRepo) GetUserByID(ctx context.Context, userID uuid.UUID) (entity.User, error) {
 	args := m.Called(ctx, userID)
-	return args.Get(0).(entity.User), args.Get(1).(error)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) GetUserByIDWithDeleted(ctx context.Context, userID uuid.UUID) (entity.User, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(entity.User), args.Error(1)
 }
 
 func (m *MockUserRepo) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
 	args := m.Called(ctx, email)
-	return args.Get(0).(entity.User), args.Get(1).(error)
+	return args.Get(0).(entity.User), args.Error(1)
 }
 
 func (m *MockUserRepo) UserRegister(ctx context.Context, userInfo entity.User) (entity.User, error) {
 	args := m.Called(ctx, userInfo)
-	return args.Get(0).(entity.User), args.Get(1).(error)
+	return args.Get(0).(entity.User), args.Error(1)
 }
 
 func (m *MockUserRepo) ValidateUser(userInfo entity.User) (uuid.UUID, error) {
 	args := m.Called(userInfo)
-	return args.Get(0).(uuid.UUID), args.Get(1).(error)
+	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
 func (m *MockUserRepo) UpdateUser(ctx context.Context, userInfo entity.User) error {
 	args := m.Called(ctx, userInfo)
-	return args.Get(0).(error)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) UpdateUserProfile(ctx context.Context, userInfo entity.User) error {
+	args := m.Called(ctx, userInfo)
+	return args.Error(0)
 }
 
 func (m *MockUserRepo) DeleteUser(ctx context.Context, userInfo entity.User) error {
 	args := m.Called(ctx, userInfo)
-	return args.Get(0).(error)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) RestoreUser(ctx context.Context, userID uuid.UUID, restoredBy string) (bool, error) {
+	args := m.Called(ctx, userID, restoredBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepo) UpdateUserState(ctx context.Context, userID uuid.UUID, from, to, updatedBy string) (bool, error) {
+	args := m.Called(ctx, userID, from, to, updatedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepo) GetUsers(ctx context.Context, filter request.UserFilterRequest) ([]entity.User, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepo) GetUsersInBatches(
+	ctx context.Context,
+	filter request.UserFilterRequest,
+	batchSize int,
+	fn func(users []entity.User) error,
+) error {
+	args := m.Called(ctx, filter, batchSize, fn)
+	return args.Error(0)
 }
 
 func (m *MockUserRepo) VerifyUserEmail(ctx context.Context, email string) error {
 	args := m.Called(ctx, email)
-	return args.Get(0).(error)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	args := m.Called(ctx, userID, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepo) ClaimUnverifiedAccount(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	args := m.Called(ctx, userID, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepo) MarkEmailVerificationSent(ctx context.Context, userID uuid.UUID, sentBefore time.Time) (bool, error) {
+	args := m.Called(ctx, userID, sentBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepo) SetPendingEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	args := m.Called(ctx, userID, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) ConfirmPendingEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	args := m.Called(ctx, userID, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepo) EraseUser(ctx context.Context, userID uuid.UUID, erasedBy string) (bool, error) {
+	args := m.Called(ctx, userID, erasedBy)
+	return args.Bool(0), args.Error(1)
 }
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockVerificationService) SendVerificationEmail(ctx context.Context, user entity.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockVerificationService) VerifyEmail(ctx context.Context, req request.VerifyEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockVerificationService) ResendVerificationEmail(
+	ctx context.Context,
+	req request.ResendVerificationEmailRequest,
+) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockVerificationService) SendEmailChangeConfirmation(
+	ctx context.Context,
+	user entity.User,
+	newEmail string,
+) error {
+	args := m.Called(ctx, user, newEmail)
+	return args.Error(0)
+}
+
+func (m *MockVerificationService) ConfirmEmailChange(ctx context.Context, req request.VerifyEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
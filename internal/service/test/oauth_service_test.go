@@ -0,0 +1,282 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/entity/enum"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newOAuthTestService(
+	t *testing.T,
+	oauthClientRepo *MockOAuthClientRepo,
+	sessionRepo *MockSessionRepo,
+	userRepo *MockUserRepo,
+) (service.OAuthService, *util.JWTKeySet, repository.TokenRevocationStore) {
+	t.Helper()
+	cfg := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT:    config.JWTConfig{Secret: "test-jwt-secret-of-at-least-32-bytes"},
+		Auth:   config.AuthConfig{OAuthClientTokenTTL: 10 * time.Minute},
+	}
+	keySet, err := util.NewJWTKeySet(cfg)
+	require.NoError(t, err)
+	mockLogger := logger.NewLogger(cfg)
+	revocationStore := repository.NewMemoryTokenRevocationStore()
+	sessionService := service.NewSessionService(sessionRepo, new(MockRefreshTokenRepo), mockLogger, cfg)
+	accountService := service.NewAccountStatusService(userRepo, nil, nil, nil, mockLogger)
+	return service.NewOAuthService(
+		oauthClientRepo, revocationStore, sessionService, accountService, keySet, mockLogger, cfg,
+	), keySet, revocationStore
+}
+
+// TestOAuthService_CreateClient tests that admins register clients with scopes they hold and only a hashed secret is stored
+func TestOAuthService_CreateClient(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+	ctx = context.WithValue(ctx, util.PermissionsCTX, []string{constant.PermissionUserRead, constant.PermissionClientManage})
+
+	t.Run("registered", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockOAuthClientRepo)
+		var stored entity.OAuthClient
+		mockRepo.On("CreateOAuthClient", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(entity.OAuthClient)
+			}).
+			Return(nil)
+		oauthService, _, _ := newOAuthTestService(t, mockRepo, new(MockSessionRepo), new(MockUserRepo))
+
+		resp, err := oauthService.CreateClient(ctx, request.CreateOAuthClientRequest{
+			Name:   "billing",
+			Scopes: []string{constant.PermissionUserRead, constant.PermissionUserRead},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{constant.PermissionUserRead}, resp.Scopes)
+		assert.True(t, strings.HasPrefix(resp.ClientSecret, "iecs_"))
+		assert.Equal(t, resp.ClientID, stored.ClientID)
+		assert.Equal(t, util.HashToken(resp.ClientSecret), stored.SecretHash)
+		assert.Equal(t, adminID.String(), stored.CreatedBy)
+	})
+
+	t.Run("scope the admin does not hold", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockOAuthClientRepo)
+		oauthService, _, _ := newOAuthTestService(t, mockRepo, new(MockSessionRepo), new(MockUserRepo))
+
+		_, err := oauthService.CreateClient(ctx, request.CreateOAuthClientRequest{
+			Name:   "billing",
+			Scopes: []string{constant.PermissionUserWrite},
+		})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+		mockRepo.AssertNotCalled(t, "CreateOAuthClient", mock.Anything, mock.Anything)
+	})
+}
+
+// TestOAuthService_ClientCredentialsToken tests the claims of client tokens and the rejection of revoked clients
+func TestOAuthService_ClientCredentialsToken(t *testing.T) {
+	t.Parallel()
+
+	secret := "iecs_secret"
+	client := entity.OAuthClient{
+		ID:         uuid.New(),
+		ClientID:   "billing-service",
+		SecretHash: util.HashToken(secret),
+		Scopes:     "users:read users:write",
+	}
+	revokedAt := time.Now()
+	revoked := client
+	revoked.ClientID = "retired-service"
+	revoked.RevokedAt = &revokedAt
+
+	mockRepo := new(MockOAuthClientRepo)
+	mockRepo.On("GetOAuthClientByClientID", mock.Anything, client.ClientID).Return(client, nil)
+	mockRepo.On("GetOAuthClientByClientID", mock.Anything, revoked.ClientID).Return(revoked, nil)
+	mockRepo.On("GetOAuthClientByClientID", mock.Anything, "unknown").
+		Return(entity.OAuthClient{}, errors.NewNotFoundError("OAuth client not found"))
+	oauthService, keySet, _ := newOAuthTestService(t, mockRepo, new(MockSessionRepo), new(MockUserRepo))
+
+	resp, err := oauthService.ClientCredentialsToken(context.Background(), request.ClientCredentialsRequest{
+		GrantType:    constant.GrantTypeClientCredentials,
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Scope:        "users:read",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), resp.ExpiresIn)
+
+	claims, err := keySet.ParseAccessToken(resp.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.IsClientToken())
+	assert.Equal(t, client.ClientID, claims.Subject)
+	assert.Equal(t, "users:read", claims.Scope)
+	assert.Empty(t, claims.Email)
+
+	for _, clientID := range []string{revoked.ClientID, "unknown"} {
+		_, err = oauthService.ClientCredentialsToken(context.Background(), request.ClientCredentialsRequest{
+			GrantType:    constant.GrantTypeClientCredentials,
+			ClientID:     clientID,
+			ClientSecret: secret,
+		})
+		require.Error(t, err)
+		oauthErr, ok := err.(*service.OAuthError)
+		require.True(t, ok)
+		assert.Equal(t, constant.OAuthErrorInvalidClient, oauthErr.Code)
+	}
+
+	active, err := oauthService.IsClientActive(context.Background(), revoked.ClientID)
+	require.NoError(t, err)
+	assert.False(t, active)
+	active, err = oauthService.IsClientActive(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.False(t, active)
+}
+
+// TestOAuthService_IntrospectToken tests that introspection honours revocation, sessions, account status and clients,
+// not just the signature
+func TestOAuthService_IntrospectToken(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	suspendedID := uuid.New()
+	activeSession := entity.Session{ID: uuid.New(), UserID: userID, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	revokedAt := time.Now()
+	loggedOutSession := entity.Session{ID: uuid.New(), UserID: userID, RevokedAt: &revokedAt}
+	retiredClient := entity.OAuthClient{ID: uuid.New(), ClientID: "retired-service", RevokedAt: &revokedAt}
+	client := entity.OAuthClient{ID: uuid.New(), ClientID: "billing-service"}
+
+	mockSessionRepo := new(MockSessionRepo)
+	mockSessionRepo.On("GetSessionByID", mock.Anything, activeSession.ID).Return(activeSession, nil)
+	mockSessionRepo.On("GetSessionByID", mock.Anything, loggedOutSession.ID).Return(loggedOutSession, nil)
+	mockRepo := new(MockOAuthClientRepo)
+	mockRepo.On("GetOAuthClientByClientID", mock.Anything, client.ClientID).Return(client, nil)
+	mockRepo.On("GetOAuthClientByClientID", mock.Anything, retiredClient.ClientID).Return(retiredClient, nil)
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetUserByID", mock.Anything, userID).Return(entity.User{ID: userID}, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, suspendedID).
+		Return(entity.User{ID: suspendedID, State: enum.EnumStateDB(enum.StateSuspended)}, nil)
+	oauthService, keySet, revocationStore := newOAuthTestService(t, mockRepo, mockSessionRepo, mockUserRepo)
+
+	now := time.Now()
+	sign := func(claims util.AccessClaims) string {
+		claims.RegisteredClaims = jwt.RegisteredClaims{
+			Subject:   claims.Subject,
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		}
+		token, err := keySet.SignAccessToken(claims)
+		require.NoError(t, err)
+		return token
+	}
+	userClaims := func(sessionID uuid.UUID) util.AccessClaims {
+		return util.AccessClaims{
+			Email:       "test@example.com",
+			SessionID:   sessionID.String(),
+			Permissions: []string{constant.PermissionProfileRead, constant.PermissionProfileWrite},
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject: userID.String(),
+			},
+		}
+	}
+	clientClaims := func(clientID string) util.AccessClaims {
+		return util.AccessClaims{
+			ClientID:         clientID,
+			Scope:            constant.PermissionUserRead,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: clientID},
+		}
+	}
+
+	suspendedClaims := userClaims(activeSession.ID)
+	suspendedClaims.Subject = suspendedID.String()
+
+	revokedToken := sign(userClaims(activeSession.ID))
+	revokedClaims, err := keySet.ParseAccessToken(revokedToken)
+	require.NoError(t, err)
+	require.NoError(t, revocationStore.Revoke(context.Background(), revokedClaims.ID, revokedClaims.ExpiresAt.Time))
+
+	expiredClaims := userClaims(activeSession.ID)
+	expiredClaims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Hour))
+	expiredClaims.IssuedAt = jwt.NewNumericDate(now.Add(-2 * time.Hour))
+	expiredClaims.NotBefore = expiredClaims.IssuedAt
+	expiredClaims.ID = uuid.NewString()
+	expiredToken, err := keySet.SignAccessToken(expiredClaims)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		active bool
+	}{
+		{name: "active user token", token: sign(userClaims(activeSession.ID)), active: true},
+		{name: "revoked token", token: revokedToken},
+		{name: "logged out session", token: sign(userClaims(loggedOutSession.ID))},
+		{name: "suspended user", token: sign(suspendedClaims)},
+		{name: "expired token", token: expiredToken},
+		{name: "not a token", token: "not-a-token"},
+		{name: "active client token", token: sign(clientClaims(client.ClientID)), active: true},
+		{name: "revoked client", token: sign(clientClaims(retiredClient.ClientID))},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := oauthService.IntrospectToken(context.Background(), request.IntrospectionRequest{Token: tc.token})
+			require.NoError(t, err)
+			assert.Equal(t, tc.active, resp.Active)
+			if !tc.active {
+				assert.Empty(t, resp.Sub)
+				assert.Zero(t, resp.Exp)
+			}
+		})
+	}
+
+	t.Run("user token details", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := oauthService.IntrospectToken(context.Background(), request.IntrospectionRequest{Token: sign(userClaims(activeSession.ID))})
+		require.NoError(t, err)
+		assert.Equal(t, userID.String(), resp.Sub)
+		assert.Equal(t, "test@example.com", resp.Email)
+		assert.Equal(t, "profile:read profile:write", resp.Scope)
+		assert.Equal(t, activeSession.ID.String(), resp.SessionID)
+		assert.WithinDuration(t, now.Add(time.Hour), time.Unix(resp.Exp, 0), time.Second)
+		assert.Empty(t, resp.ClientID)
+	})
+
+	t.Run("client token details", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := oauthService.IntrospectToken(context.Background(), request.IntrospectionRequest{Token: sign(clientClaims(client.ClientID))})
+		require.NoError(t, err)
+		assert.Equal(t, client.ClientID, resp.Sub)
+		assert.Equal(t, client.ClientID, resp.ClientID)
+		assert.Equal(t, constant.PermissionUserRead, resp.Scope)
+		assert.Empty(t, resp.Email)
+	})
+}
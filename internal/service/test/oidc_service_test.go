@@ -0,0 +1,256 @@
+package service_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/oidc"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOIDCService_Login runs the authorization-code flow against a local mock provider
+func TestOIDCService_Login(t *testing.T) {
+	t.Parallel()
+
+	provider := newMockOIDCProvider(t, "client-id")
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+		OIDC: config.OIDCConfig{
+			StateTTL: 10 * time.Minute,
+			Providers: map[string]config.OIDCProviderConfig{
+				"mock": {
+					Issuer:       provider.server.URL,
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+					RedirectURL:  "http://localhost:3000/auth/callback/mock",
+					Scopes:       []string{"openid", "email", "profile"},
+				},
+			},
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+	registry := oidc.NewRegistry(mockConfig)
+
+	existing := entity.User{ID: uuid.New(), Email: "existing@example.com"}
+	verifiedAt := time.Now()
+	verified := entity.User{ID: uuid.New(), Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+	notFound := errors.NewNotFoundError("not found")
+
+	testCases := []struct {
+		name      string
+		claims    jwt.MapClaims
+		tamper    func(p *mockOIDCProvider, req *request.OIDCCallbackRequest, code string, claims jwt.MapClaims)
+		mockSetup func(*MockOIDCRepo, *MockUserRepo, *MockRoleRepo)
+		expectErr int
+	}{
+		{
+			name:   "first login creates and links an account",
+			claims: jwt.MapClaims{"sub": "new-sub", "email": "new@example.com", "email_verified": true, "name": "Jane Doe"},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {
+				o.On("GetUserIdentity", mock.Anything, "mock", "new-sub").Return(entity.UserIdentity{}, notFound)
+				u.On("GetUserByEmail", mock.Anything, "new@example.com").Return(entity.User{}, notFound)
+				created := entity.User{ID: uuid.New(), Email: "new@example.com"}
+				u.On("UserRegister", mock.Anything, mock.MatchedBy(func(user entity.User) bool {
+					return user.Email == "new@example.com" && user.FirstName == "Jane" && user.LastName == "Doe" &&
+						user.IsEmailVerified() && user.Password != ""
+				})).Return(created, nil)
+				r.On("AssignRole", mock.Anything, created.ID, constant.RoleUser).Return(nil)
+				o.On("CreateUserIdentity", mock.Anything, mock.MatchedBy(func(identity entity.UserIdentity) bool {
+					return identity.UserID == created.ID && identity.Provider == "mock" && identity.Subject == "new-sub"
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "linked identity logs in",
+			claims: jwt.MapClaims{"sub": "known-sub", "email": "whatever@example.com"},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {
+				o.On("GetUserIdentity", mock.Anything, "mock", "known-sub").
+					Return(entity.UserIdentity{ID: uuid.New(), UserID: existing.ID}, nil)
+				u.On("GetUserByID", mock.Anything, existing.ID).Return(existing, nil)
+			},
+		},
+		{
+			name:   "unverified email is not linked to an existing account",
+			claims: jwt.MapClaims{"sub": "other-sub", "email": existing.Email, "email_verified": "false"},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {
+				o.On("GetUserIdentity", mock.Anything, "mock", "other-sub").Return(entity.UserIdentity{}, notFound)
+			},
+			expectErr: http.StatusForbidden,
+		},
+		{
+			name:   "verified email links an existing verified account",
+			claims: jwt.MapClaims{"sub": "link-sub", "email": verified.Email, "email_verified": true},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {
+				o.On("GetUserIdentity", mock.Anything, "mock", "link-sub").Return(entity.UserIdentity{}, notFound)
+				u.On("GetUserByEmail", mock.Anything, verified.Email).Return(verified, nil)
+				o.On("CreateUserIdentity", mock.Anything, mock.MatchedBy(func(identity entity.UserIdentity) bool {
+					return identity.UserID == verified.ID && identity.Subject == "link-sub"
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "account with an unverified email is not linked",
+			claims: jwt.MapClaims{"sub": "hijack-sub", "email": existing.Email, "email_verified": true},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {
+				o.On("GetUserIdentity", mock.Anything, "mock", "hijack-sub").Return(entity.UserIdentity{}, notFound)
+				u.On("GetUserByEmail", mock.Anything, existing.Email).Return(existing, nil)
+			},
+			expectErr: http.StatusConflict,
+		},
+		{
+			name:   "ID token signed by another key is rejected",
+			claims: jwt.MapClaims{"sub": "known-sub"},
+			tamper: func(p *mockOIDCProvider, req *request.OIDCCallbackRequest, code string, claims jwt.MapClaims) {
+				p.mu.Lock()
+				defer p.mu.Unlock()
+				p.key, _ = rsa.GenerateKey(rand.Reader, 1024)
+			},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {},
+			expectErr: http.StatusUnauthorized,
+		},
+		{
+			name:   "unknown state is rejected",
+			claims: jwt.MapClaims{"sub": "known-sub"},
+			tamper: func(p *mockOIDCProvider, req *request.OIDCCallbackRequest, code string, claims jwt.MapClaims) {
+				req.State = "forged"
+			},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {},
+			expectErr: http.StatusBadRequest,
+		},
+		{
+			name:   "code redeemed with another verifier is rejected",
+			claims: jwt.MapClaims{"sub": "known-sub"},
+			tamper: func(p *mockOIDCProvider, req *request.OIDCCallbackRequest, code string, claims jwt.MapClaims) {
+				p.grant(code, oidc.CodeChallenge("attacker-verifier"), claims)
+			},
+			mockSetup: func(o *MockOIDCRepo, u *MockUserRepo, r *MockRoleRepo) {},
+			expectErr: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Cases share the mock provider's signing key, so they run one after another
+			mockOIDCRepo := new(MockOIDCRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockRoleRepo := newMockRoleRepo()
+			tc.mockSetup(mockOIDCRepo, mockUserRepo, mockRoleRepo)
+
+			var stored entity.OIDCLoginState
+			mockOIDCRepo.On("CreateOIDCLoginState", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					stored = args.Get(1).(entity.OIDCLoginState)
+				}).
+				Return(nil)
+			mockOIDCRepo.On("ConsumeOIDCLoginState", mock.Anything, mock.Anything).
+				Return(func(ctx context.Context, stateHash string) (entity.OIDCLoginState, error) {
+					if stateHash != stored.StateHash {
+						return entity.OIDCLoginState{}, errors.NewNotFoundError("Login state not found")
+					}
+					return stored, nil
+				}, nil)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+			tokenService := service.NewTokenService(
+				mockRefreshTokenRepo,
+				newMockSessionRepo(),
+				mockUserRepo,
+				mockRoleRepo,
+				newMockOrganizationRepo(),
+				repository.NewMemoryTokenRevocationStore(),
+				keySet,
+				mockLogger,
+				mockConfig,
+			)
+			oidcService := service.NewOIDCService(
+				registry,
+				mockOIDCRepo,
+				mockUserRepo,
+				mockRoleRepo,
+				tokenService,
+				newMockMFAService(),
+				mockLogger,
+				mockConfig,
+			)
+
+			authorization, err := oidcService.AuthorizationURL(context.Background(), "mock")
+			require.NoError(t, err)
+			authURL, err := url.Parse(authorization.AuthorizationURL)
+			require.NoError(t, err)
+			query := authURL.Query()
+			assert.True(t, strings.HasPrefix(authorization.AuthorizationURL, provider.server.URL+"/authorize?"))
+			assert.Equal(t, authorization.State, query.Get("state"))
+			assert.Equal(t, "S256", query.Get("code_challenge_method"))
+			assert.Equal(t, "client-id", query.Get("client_id"))
+			assert.Equal(t, util.HashToken(authorization.State), stored.StateHash)
+			assert.NotContains(t, authorization.AuthorizationURL, stored.CodeVerifier)
+
+			// The user logs in at the provider, which redirects back with a code
+			code := uuid.NewString()
+			claims := jwt.MapClaims{"nonce": query.Get("nonce")}
+			for name, value := range tc.claims {
+				claims[name] = value
+			}
+			provider.grant(code, query.Get("code_challenge"), claims)
+			req := request.OIDCCallbackRequest{Code: code, State: authorization.State}
+			if tc.tamper != nil {
+				originalKey := provider.key
+				tc.tamper(provider, &req, code, claims)
+				defer func() { provider.key = originalKey }()
+			}
+
+			resp, err := oidcService.Callback(context.Background(), "mock", req)
+			if tc.expectErr != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectErr, err.(*errors.AppError).Status)
+				assert.Empty(t, resp.Token)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, resp.Token)
+			assert.NotEmpty(t, resp.RefreshToken)
+			mockOIDCRepo.AssertExpectations(t)
+			mockUserRepo.AssertExpectations(t)
+			mockRoleRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("unknown provider", func(t *testing.T) {
+		oidcService := service.NewOIDCService(
+			registry, new(MockOIDCRepo), new(MockUserRepo), newMockRoleRepo(), nil, newMockMFAService(), mockLogger, mockConfig,
+		)
+		_, err := oidcService.AuthorizationURL(context.Background(), "unknown")
+		require.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, err.(*errors.AppError).Status)
+	})
+}
@@ -0,0 +1,257 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOrganizationService_CreateOrganization tests that the creator becomes the owner and slugs are unique
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, userID.String())
+	req := request.CreateOrganizationRequest{Name: "Acme Energy", Slug: "acme-energy"}
+
+	t.Run("creator becomes owner", func(t *testing.T) {
+		t.Parallel()
+
+		organizationRepo := new(MockOrganizationRepo)
+		organizationRepo.On("IsSlugTaken", mock.Anything, req.Slug).Return(false, nil)
+		organizationRepo.On("CreateOrganization", mock.Anything,
+			mock.MatchedBy(func(organization entity.Organization) bool {
+				return organization.Name == req.Name && organization.Slug == req.Slug
+			}),
+			mock.MatchedBy(func(owner entity.OrganizationMember) bool {
+				return owner.UserID == userID && owner.Role == constant.OrganizationRoleOwner
+			}),
+		).Return(nil)
+
+		organizationService := service.NewOrganizationService(
+			organizationRepo, new(MockUserRepo), new(MockSessionRepo), nil, logger.NewLogger(mockConfig),
+		)
+		resp, err := organizationService.CreateOrganization(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, constant.OrganizationRoleOwner, resp.Role)
+		assert.Equal(t, req.Slug, resp.Organization.Slug)
+		organizationRepo.AssertExpectations(t)
+	})
+
+	t.Run("slug is taken", func(t *testing.T) {
+		t.Parallel()
+
+		organizationRepo := new(MockOrganizationRepo)
+		organizationRepo.On("IsSlugTaken", mock.Anything, req.Slug).Return(true, nil)
+
+		organizationService := service.NewOrganizationService(
+			organizationRepo, new(MockUserRepo), new(MockSessionRepo), nil, logger.NewLogger(mockConfig),
+		)
+		_, err := organizationService.CreateOrganization(ctx, req)
+		assert.Equal(t, errors.NewConflictError("Slug is already taken"), err)
+		organizationRepo.AssertNotCalled(t, "CreateOrganization", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestOrganizationService_SwitchOrganization tests that members get a token carrying the organization
+// and that the session keeps it for later refreshes
+func TestOrganizationService_SwitchOrganization(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+
+	user := entity.User{ID: uuid.New(), Email: "user@example.com"}
+	sessionID := uuid.New()
+	organizationID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+	ctx = context.WithValue(ctx, util.SessionIDCTX, sessionID.String())
+
+	newService := func(organizationRepo *MockOrganizationRepo, sessionRepo *MockSessionRepo) service.OrganizationService {
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+		tokenService := service.NewTokenService(
+			new(MockRefreshTokenRepo),
+			sessionRepo,
+			userRepo,
+			newMockRoleRepo(),
+			organizationRepo,
+			repository.NewMemoryTokenRevocationStore(),
+			keySet,
+			mockLogger,
+			mockConfig,
+		)
+		return service.NewOrganizationService(organizationRepo, userRepo, sessionRepo, tokenService, mockLogger)
+	}
+
+	t.Run("member switches", func(t *testing.T) {
+		t.Parallel()
+
+		organizationRepo := new(MockOrganizationRepo)
+		organizationRepo.On("GetUserMembership", mock.Anything, organizationID, user.ID).Return(entity.OrganizationMember{
+			OrganizationID: organizationID,
+			UserID:         user.ID,
+			Role:           constant.OrganizationRoleAdmin,
+		}, nil)
+		sessionRepo := new(MockSessionRepo)
+		sessionRepo.On("SetSessionOrganization", mock.Anything, sessionID, &organizationID).Return(nil)
+
+		resp, err := newService(organizationRepo, sessionRepo).SwitchOrganization(ctx, organizationID)
+		require.NoError(t, err)
+		assert.Equal(t, constant.OrganizationRoleAdmin, resp.Role)
+
+		claims, err := keySet.ParseAccessToken(resp.Token)
+		require.NoError(t, err)
+		assert.Equal(t, organizationID.String(), claims.OrganizationID)
+		assert.Equal(t, sessionID.String(), claims.SessionID)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		t.Parallel()
+
+		organizationRepo := new(MockOrganizationRepo)
+		organizationRepo.On("GetUserMembership", mock.Anything, organizationID, user.ID).
+			Return(entity.OrganizationMember{}, errors.NewNotFoundError("Not a member of the organization"))
+		sessionRepo := new(MockSessionRepo)
+
+		_, err := newService(organizationRepo, sessionRepo).SwitchOrganization(ctx, organizationID)
+		assert.Equal(t, errors.NewForbiddenError("Not a member of the organization"), err)
+		sessionRepo.AssertNotCalled(t, "SetSessionOrganization", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestOrganizationService_Members tests who may grant the owner role and that the last owner stays
+func TestOrganizationService_Members(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	callerID := uuid.New()
+	memberOf := func(role string) entity.OrganizationMember {
+		return entity.OrganizationMember{UserID: uuid.New(), Role: role}
+	}
+	callerAs := func(role string) context.Context {
+		ctx := util.WithTenant(context.Background(), uuid.New())
+		ctx = context.WithValue(ctx, util.UserIDCTX, callerID.String())
+		return context.WithValue(ctx, util.OrgRoleCTX, role)
+	}
+	changeRole := func(role string) func(service.OrganizationService, context.Context, uuid.UUID) error {
+		return func(s service.OrganizationService, ctx context.Context, userID uuid.UUID) error {
+			_, err := s.UpdateMemberRole(ctx, userID, request.UpdateMemberRoleRequest{Role: role})
+			return err
+		}
+	}
+	remove := func(s service.OrganizationService, ctx context.Context, userID uuid.UUID) error {
+		return s.RemoveMember(ctx, userID)
+	}
+
+	testCases := []struct {
+		name          string
+		callerRole    string
+		member        entity.OrganizationMember
+		owners        int64
+		change        func(service.OrganizationService, context.Context, uuid.UUID) error
+		expectedError error
+	}{
+		{
+			name:       "admin promotes a member to admin",
+			callerRole: constant.OrganizationRoleAdmin,
+			member:     memberOf(constant.OrganizationRoleMember),
+			change:     changeRole(constant.OrganizationRoleAdmin),
+		},
+		{
+			name:          "admin can't make an owner",
+			callerRole:    constant.OrganizationRoleAdmin,
+			member:        memberOf(constant.OrganizationRoleMember),
+			change:        changeRole(constant.OrganizationRoleOwner),
+			expectedError: errors.NewForbiddenError("Only owners can grant or change the owner role"),
+		},
+		{
+			name:          "admin can't remove an owner",
+			callerRole:    constant.OrganizationRoleAdmin,
+			member:        memberOf(constant.OrganizationRoleOwner),
+			change:        remove,
+			expectedError: errors.NewForbiddenError("Only owners can grant or change the owner role"),
+		},
+		{
+			name:       "owner demotes another owner",
+			callerRole: constant.OrganizationRoleOwner,
+			member:     memberOf(constant.OrganizationRoleOwner),
+			owners:     2,
+			change:     changeRole(constant.OrganizationRoleMember),
+		},
+		{
+			name:          "last owner can't be demoted",
+			callerRole:    constant.OrganizationRoleOwner,
+			member:        memberOf(constant.OrganizationRoleOwner),
+			owners:        1,
+			change:        changeRole(constant.OrganizationRoleAdmin),
+			expectedError: errors.NewConflictError("The organization must keep at least one owner"),
+		},
+		{
+			name:          "last owner can't be removed",
+			callerRole:    constant.OrganizationRoleOwner,
+			member:        memberOf(constant.OrganizationRoleOwner),
+			owners:        1,
+			change:        remove,
+			expectedError: errors.NewConflictError("The organization must keep at least one owner"),
+		},
+		{
+			name:       "admin removes a member",
+			callerRole: constant.OrganizationRoleAdmin,
+			member:     memberOf(constant.OrganizationRoleMember),
+			change:     remove,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			organizationRepo := new(MockOrganizationRepo)
+			organizationRepo.On("GetMember", mock.Anything, tc.member.UserID).Return(tc.member, nil)
+			organizationRepo.On("CountOwners", mock.Anything).Return(tc.owners, nil).Maybe()
+			organizationRepo.On("UpdateMemberRole", mock.Anything, tc.member.UserID, mock.Anything, callerID.String()).
+				Return(true, nil).Maybe()
+			organizationRepo.On("RemoveMember", mock.Anything, tc.member.UserID).Return(true, nil).Maybe()
+
+			organizationService := service.NewOrganizationService(
+				organizationRepo, new(MockUserRepo), new(MockSessionRepo), nil, logger.NewLogger(mockConfig),
+			)
+			err := tc.change(organizationService, callerAs(tc.callerRole), tc.member.UserID)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err)
+				organizationRepo.AssertNotCalled(t, "UpdateMemberRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				organizationRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"ienergy-template-go/config"
+	"ienergy-template-go/pkg/password"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPasswordPolicy builds the policy configured in cfg, which is empty in most tests
+func newPasswordPolicy(t *testing.T, cfg *config.Config) *password.Policy {
+	policy, err := password.NewPolicy(cfg)
+	require.NoError(t, err)
+	return policy
+}
+
+// TestPasswordPolicy_Check tests each rule of the password policy
+func TestPasswordPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	listFile := filepath.Join(t.TempDir(), "breached.txt")
+	// One plain entry and the SHA-1 of "Tr0ub4dor&3x" with a count, as in Have I Been Pwned downloads
+	err := os.WriteFile(listFile, []byte("# extra list\nstaple-battery-99\n"+
+		"C643246DB75853796634F3ACB9C5218398F34D98:12\n"), 0o600)
+	require.NoError(t, err)
+
+	policy := newPasswordPolicy(t, &config.Config{Password: config.PasswordPolicyConfig{
+		MinLength:            10,
+		RequireUppercase:     true,
+		RequireDigit:         true,
+		DisallowPersonalInfo: true,
+		CheckBreached:        true,
+		BreachedListFile:     listFile,
+	}})
+
+	testCases := []struct {
+		name       string
+		password   string
+		violations []string
+	}{
+		{
+			name:     "acceptable password",
+			password: "Violet-Kettle-42",
+		},
+		{
+			name:     "too short without upper case or digit",
+			password: "kettle",
+			violations: []string{
+				"must be at least 10 characters",
+				"must contain an upper case letter",
+				"must contain a digit",
+			},
+		},
+		{
+			name:       "too long for bcrypt",
+			password:   "Aa1" + string(make([]byte, 80)),
+			violations: []string{"must be at most 72 bytes"},
+		},
+		{
+			name:       "contains the email name",
+			password:   "JaneDoe-2024!",
+			violations: []string{"must not contain your name or email address"},
+		},
+		{
+			name:       "contains the first name in another case",
+			password:   "Kettle-MARIE-42",
+			violations: []string{"must not contain your name or email address"},
+		},
+		{
+			name:       "bundled breached password",
+			password:   "Password1234",
+			violations: []string{"has appeared in a data breach and cannot be used"},
+		},
+		{
+			name:       "plain entry from the configured file matches in any case",
+			password:   "Staple-Battery-99",
+			violations: []string{"has appeared in a data breach and cannot be used"},
+		},
+		{
+			name:       "hashed entry from the configured file",
+			password:   "Tr0ub4dor&3x",
+			violations: []string{"has appeared in a data breach and cannot be used"},
+		},
+		{
+			name:     "hashed entries are case sensitive",
+			password: "TR0UB4DOR&3X",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			violations := policy.Check(tc.password, "janedoe@example.com", "Marie", "Li")
+			if len(tc.violations) == 0 {
+				assert.Empty(t, violations)
+				return
+			}
+			assert.Equal(t, tc.violations, violations)
+		})
+	}
+}
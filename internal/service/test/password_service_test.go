@@ -0,0 +1,374 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newPasswordTestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		Auth: config.AuthConfig{
+			PasswordResetURL:      "http://localhost:3000/reset-password",
+			PasswordResetTokenTTL: 30 * time.Minute,
+		},
+	}
+}
+
+func newPasswordTestService(
+	t *testing.T,
+	userRepo *MockUserRepo,
+	resetRepo *MockPasswordResetTokenRepo,
+	refreshTokenRepo *MockRefreshTokenRepo,
+	apiKeyRepo *MockAPIKeyRepo,
+	notifier *MockNotifier,
+	cfg *config.Config,
+) service.PasswordService {
+	mockLogger := logger.NewLogger(cfg)
+	return service.NewPasswordService(
+		userRepo,
+		resetRepo,
+		refreshTokenRepo,
+		apiKeyRepo,
+		service.NewLoginAttemptService(repository.NewMemoryLoginAttemptStore(), mockLogger, cfg),
+		notifier,
+		newPasswordPolicy(t, cfg),
+		mockLogger,
+		cfg,
+	)
+}
+
+// TestPasswordService_ForgotPassword tests that reset links are only mailed to registered users
+func TestPasswordService_ForgotPassword(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newPasswordTestConfig()
+	user := entity.User{
+		ID:        uuid.New(),
+		Email:     "test@example.com",
+		FirstName: "John",
+	}
+
+	t.Run("registered email receives a link", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		mockResetRepo := new(MockPasswordResetTokenRepo)
+		mockNotifier := new(MockNotifier)
+
+		var stored entity.PasswordResetToken
+		var sent notifier.Message
+		mockUserRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		mockResetRepo.On("InvalidateUserPasswordResetTokens", mock.Anything, user.ID).Return(nil)
+		mockResetRepo.On("CreatePasswordResetToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(entity.PasswordResetToken)
+			}).
+			Return(nil)
+		mockNotifier.On("Send", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(notifier.Message)
+			}).
+			Return(nil)
+
+		passwordService := newPasswordTestService(
+			t, mockUserRepo, mockResetRepo, new(MockRefreshTokenRepo), new(MockAPIKeyRepo), mockNotifier, mockConfig,
+		)
+		err := passwordService.ForgotPassword(context.Background(), request.ForgotPasswordRequest{Email: user.Email})
+		require.NoError(t, err)
+
+		assert.Equal(t, user.Email, sent.To)
+		linkStart := strings.Index(sent.Body, mockConfig.Auth.PasswordResetURL)
+		require.GreaterOrEqual(t, linkStart, 0)
+		link, err := url.Parse(strings.Fields(sent.Body[linkStart:])[0])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+		assert.NotEmpty(t, token)
+		assert.Equal(t, util.HashToken(token), stored.TokenHash)
+		assert.NotContains(t, stored.TokenHash, token)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), stored.ExpiresAt, time.Minute)
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("unknown email is not revealed", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		mockNotifier := new(MockNotifier)
+		mockUserRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").
+			Return(entity.User{}, errors.NewNotFoundError("User not found"))
+
+		passwordService := newPasswordTestService(
+			t, mockUserRepo, new(MockPasswordResetTokenRepo), new(MockRefreshTokenRepo), new(MockAPIKeyRepo), mockNotifier, mockConfig,
+		)
+		err := passwordService.ForgotPassword(context.Background(), request.ForgotPasswordRequest{Email: "nobody@example.com"})
+		assert.NoError(t, err)
+		mockNotifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+// TestPasswordService_ResetPassword tests redeeming reset tokens
+func TestPasswordService_ResetPassword(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newPasswordTestConfig()
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+	token := "reset-token"
+	req := request.ResetPasswordRequest{
+		Token:           token,
+		Password:        "new-password",
+		ConfirmPassword: "new-password",
+	}
+	usedAt := time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name       string
+		resetToken entity.PasswordResetToken
+		markUsed   bool
+		expectErr  bool
+	}{
+		{
+			name: "valid token",
+			resetToken: entity.PasswordResetToken{
+				ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute),
+			},
+			markUsed: true,
+		},
+		{
+			name: "already used token",
+			resetToken: entity.PasswordResetToken{
+				ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt,
+			},
+			expectErr: true,
+		},
+		{
+			name: "expired token",
+			resetToken: entity.PasswordResetToken{
+				ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute),
+			},
+			expectErr: true,
+		},
+		{
+			name: "token redeemed concurrently",
+			resetToken: entity.PasswordResetToken{
+				ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute),
+			},
+			markUsed:  false,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockResetRepo := new(MockPasswordResetTokenRepo)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			mockAPIKeyRepo := new(MockAPIKeyRepo)
+
+			mockResetRepo.On("GetPasswordResetTokenByHash", mock.Anything, util.HashToken(token)).Return(tc.resetToken, nil)
+			mockResetRepo.On("MarkPasswordResetTokenUsed", mock.Anything, tc.resetToken.ID).Return(tc.markUsed, nil).Maybe()
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+			if !tc.expectErr {
+				mockUserRepo.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u entity.User) bool {
+					return u.ID == user.ID && u.Password == req.Password
+				})).Return(nil)
+				mockResetRepo.On("InvalidateUserPasswordResetTokens", mock.Anything, user.ID).Return(nil)
+				mockRefreshTokenRepo.On("RevokeUserRefreshTokens", mock.Anything, user.ID).Return(nil)
+				mockAPIKeyRepo.On("RevokeUserAPIKeys", mock.Anything, user.ID).Return(nil)
+			}
+
+			passwordService := newPasswordTestService(
+				t, mockUserRepo, mockResetRepo, mockRefreshTokenRepo, mockAPIKeyRepo, new(MockNotifier), mockConfig,
+			)
+			err := passwordService.ResetPassword(context.Background(), req)
+
+			if tc.expectErr {
+				assert.Error(t, err)
+				mockUserRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+			mockResetRepo.AssertExpectations(t)
+			mockRefreshTokenRepo.AssertExpectations(t)
+			mockAPIKeyRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestPasswordService_ResetPassword_Policy tests that a password refused by the policy does not spend the token
+func TestPasswordService_ResetPassword_Policy(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newPasswordTestConfig()
+	mockConfig.Password = config.PasswordPolicyConfig{MinLength: 8, DisallowPersonalInfo: true, CheckBreached: true}
+	user := entity.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane"}
+	resetToken := entity.PasswordResetToken{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute)}
+
+	mockUserRepo := new(MockUserRepo)
+	mockResetRepo := new(MockPasswordResetTokenRepo)
+	mockResetRepo.On("GetPasswordResetTokenByHash", mock.Anything, util.HashToken("reset-token")).Return(resetToken, nil)
+	mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+	passwordService := newPasswordTestService(
+		t, mockUserRepo, mockResetRepo, new(MockRefreshTokenRepo), new(MockAPIKeyRepo), new(MockNotifier), mockConfig,
+	)
+
+	err := passwordService.ResetPassword(context.Background(), request.ResetPasswordRequest{
+		Token:           "reset-token",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	})
+	require.Error(t, err)
+	appErr := err.(*errors.AppError)
+	assert.Equal(t, http.StatusBadRequest, appErr.Status)
+	assert.Equal(t, []string{"has appeared in a data breach and cannot be used"}, appErr.Fields["password"])
+	mockResetRepo.AssertNotCalled(t, "MarkPasswordResetTokenUsed", mock.Anything, mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+}
+
+// TestPasswordService_ChangePassword tests that the current password is checked and other sessions are revoked
+func TestPasswordService_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newPasswordTestConfig()
+	user := entity.User{
+		ID:       uuid.New(),
+		Email:    "test@example.com",
+		Password: "hashed",
+	}
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		sessionID  uuid.UUID
+		validateID uuid.UUID
+		validate   error
+		mockSetup  func(*MockUserRepo, *MockRefreshTokenRepo)
+		expectErr  int
+	}{
+		{
+			name:       "other sessions are revoked",
+			sessionID:  sessionID,
+			validateID: user.ID,
+			mockSetup: func(u *MockUserRepo, r *MockRefreshTokenRepo) {
+				u.On("UpdateUser", mock.Anything, mock.MatchedBy(func(updated entity.User) bool {
+					return updated.ID == user.ID && updated.Password == "new-password"
+				})).Return(nil)
+				r.On("RevokeOtherUserRefreshTokens", mock.Anything, user.ID, sessionID).Return(nil)
+			},
+		},
+		{
+			name:       "token without a session revokes every session",
+			validateID: user.ID,
+			mockSetup: func(u *MockUserRepo, r *MockRefreshTokenRepo) {
+				u.On("UpdateUser", mock.Anything, mock.Anything).Return(nil)
+				r.On("RevokeUserRefreshTokens", mock.Anything, user.ID).Return(nil)
+			},
+		},
+		{
+			name:      "wrong current password",
+			sessionID: sessionID,
+			validate:  errors.NewUnauthorizedError("Invalid email or password"),
+			mockSetup: func(u *MockUserRepo, r *MockRefreshTokenRepo) {},
+			expectErr: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			mockResetRepo := new(MockPasswordResetTokenRepo)
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+			mockUserRepo.On("ValidateUser", entity.User{Email: user.Email, Password: "old-password"}).
+				Return(tc.validateID, tc.validate)
+			mockResetRepo.On("InvalidateUserPasswordResetTokens", mock.Anything, user.ID).Return(nil).Maybe()
+			tc.mockSetup(mockUserRepo, mockRefreshTokenRepo)
+
+			passwordService := newPasswordTestService(
+				t, mockUserRepo, mockResetRepo, mockRefreshTokenRepo, new(MockAPIKeyRepo), new(MockNotifier), mockConfig,
+			)
+			ctx := context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+			if tc.sessionID != uuid.Nil {
+				ctx = context.WithValue(ctx, util.SessionIDCTX, tc.sessionID.String())
+			}
+
+			err := passwordService.ChangePassword(ctx, request.ChangePasswordRequest{
+				CurrentPassword: "old-password",
+				Password:        "new-password",
+				ConfirmPassword: "new-password",
+			})
+			if tc.expectErr != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectErr, err.(*errors.AppError).Status)
+				mockUserRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+			mockRefreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestPasswordService_ChangePassword_Lockout tests that wrong current passwords count as failed logins
+func TestPasswordService_ChangePassword_Lockout(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newPasswordTestConfig()
+	mockConfig.Auth.LoginLockoutThreshold = 2
+	mockConfig.Auth.LoginLockoutDuration = time.Minute
+	mockConfig.Auth.LoginLockoutMaxDuration = time.Hour
+	mockConfig.Auth.LoginAttemptWindow = time.Hour
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+	mockUserRepo.On("ValidateUser", mock.Anything).Return(uuid.Nil, errors.NewUnauthorizedError("Invalid email or password"))
+	passwordService := newPasswordTestService(
+		t, mockUserRepo, new(MockPasswordResetTokenRepo), new(MockRefreshTokenRepo), new(MockAPIKeyRepo), new(MockNotifier), mockConfig,
+	)
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+	req := request.ChangePasswordRequest{CurrentPassword: "guess", Password: "new-password", ConfirmPassword: "new-password"}
+
+	for i := 0; i < mockConfig.Auth.LoginLockoutThreshold; i++ {
+		err := passwordService.ChangePassword(ctx, req)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+	}
+
+	err := passwordService.ChangePassword(ctx, req)
+	require.Error(t, err)
+	assert.Equal(t, errors.NewAccountLockedError("").Status, err.(*errors.AppError).Status)
+	mockUserRepo.AssertNumberOfCalls(t, "ValidateUser", mockConfig.Auth.LoginLockoutThreshold)
+}
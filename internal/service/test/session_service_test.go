@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTokenService_IssueTokens_Session tests that a login starts a session bound to the access token
+func TestTokenService_IssueTokens_Session(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+
+	mockSessionRepo := new(MockSessionRepo)
+	var session entity.Session
+	mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			session = args.Get(1).(entity.Session)
+		}).
+		Return(nil)
+	mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+	var refreshToken entity.RefreshToken
+	mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			refreshToken = args.Get(1).(entity.RefreshToken)
+		}).
+		Return(nil)
+	tokenService := service.NewTokenService(
+		mockRefreshTokenRepo,
+		mockSessionRepo,
+		new(MockUserRepo),
+		newMockRoleRepo(),
+		newMockOrganizationRepo(),
+		repository.NewMemoryTokenRevocationStore(),
+		keySet,
+		mockLogger,
+		mockConfig,
+	)
+
+	ctx := context.WithValue(context.Background(), util.UserAgentCTX,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36")
+	ctx = context.WithValue(ctx, util.ClientIPCTX, "203.0.113.7")
+	resp, err := tokenService.IssueTokens(ctx, user)
+	require.NoError(t, err)
+
+	assert.Equal(t, user.ID, session.UserID)
+	assert.Equal(t, "Chrome on macOS", session.Device)
+	assert.Equal(t, "203.0.113.7", session.IPAddress)
+	assert.Equal(t, session.ID, refreshToken.FamilyID)
+	assert.Equal(t, refreshToken.ExpiresAt, session.ExpiresAt)
+
+	claims, err := keySet.ParseAccessToken(resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID.String(), claims.SessionID)
+}
+
+// TestSessionService tests listing, revoking and checking sessions
+func TestSessionService(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	mockLogger := logger.NewLogger(mockConfig)
+	userID := uuid.New()
+	now := time.Now()
+	current := entity.Session{ID: uuid.New(), UserID: userID, Device: "Chrome on macOS", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	other := entity.Session{ID: uuid.New(), UserID: userID, Device: "curl", LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, userID.String())
+	ctx = context.WithValue(ctx, util.SessionIDCTX, current.ID.String())
+
+	t.Run("list flags the current session", func(t *testing.T) {
+		mockSessionRepo := new(MockSessionRepo)
+		mockSessionRepo.On("GetActiveSessionsByUserID", mock.Anything, userID).Return([]entity.Session{current, other}, nil)
+		sessionService := service.NewSessionService(mockSessionRepo, new(MockRefreshTokenRepo), mockLogger, mockConfig)
+
+		sessions, err := sessionService.ListSessions(ctx)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.True(t, sessions[0].Current)
+		assert.False(t, sessions[1].Current)
+		assert.Equal(t, "curl", sessions[1].Device)
+	})
+
+	t.Run("revoke own session", func(t *testing.T) {
+		mockSessionRepo := new(MockSessionRepo)
+		mockSessionRepo.On("GetSessionByID", mock.Anything, other.ID).Return(other, nil)
+		mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+		mockRefreshTokenRepo.On("RevokeRefreshTokenFamily", mock.Anything, other.ID).Return(nil)
+		sessionService := service.NewSessionService(mockSessionRepo, mockRefreshTokenRepo, mockLogger, mockConfig)
+
+		require.NoError(t, sessionService.RevokeSession(ctx, other.ID))
+		mockRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("revoke another user's session", func(t *testing.T) {
+		foreign := other
+		foreign.UserID = uuid.New()
+		mockSessionRepo := new(MockSessionRepo)
+		mockSessionRepo.On("GetSessionByID", mock.Anything, foreign.ID).Return(foreign, nil)
+		mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+		sessionService := service.NewSessionService(mockSessionRepo, mockRefreshTokenRepo, mockLogger, mockConfig)
+
+		err := sessionService.RevokeSession(ctx, foreign.ID)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, err.(*errors.AppError).Status)
+		mockRefreshTokenRepo.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything)
+	})
+
+	t.Run("revoked session is not active", func(t *testing.T) {
+		revoked := other
+		revokedAt := now
+		revoked.RevokedAt = &revokedAt
+		mockSessionRepo := new(MockSessionRepo)
+		mockSessionRepo.On("GetSessionByID", mock.Anything, revoked.ID).Return(revoked, nil)
+		sessionService := service.NewSessionService(mockSessionRepo, new(MockRefreshTokenRepo), mockLogger, mockConfig)
+
+		active, err := sessionService.IsSessionActive(context.Background(), revoked.ID.String())
+		require.NoError(t, err)
+		assert.False(t, active)
+	})
+
+	t.Run("active session is marked as seen", func(t *testing.T) {
+		mockSessionRepo := new(MockSessionRepo)
+		mockSessionRepo.On("GetSessionByID", mock.Anything, other.ID).Return(other, nil)
+		mockSessionRepo.On("TouchSession", mock.Anything, other.ID, mock.Anything).Return(nil)
+		sessionService := service.NewSessionService(mockSessionRepo, new(MockRefreshTokenRepo), mockLogger, mockConfig)
+
+		active, err := sessionService.IsSessionActive(context.Background(), other.ID.String())
+		require.NoError(t, err)
+		assert.True(t, active)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown session is not active", func(t *testing.T) {
+		mockSessionRepo := new(MockSessionRepo)
+		mockSessionRepo.On("GetSessionByID", mock.Anything, mock.Anything).
+			Return(entity.Session{}, errors.NewNotFoundError("Session not found"))
+		sessionService := service.NewSessionService(mockSessionRepo, new(MockRefreshTokenRepo), mockLogger, mockConfig)
+
+		active, err := sessionService.IsSessionActive(context.Background(), uuid.NewString())
+		require.NoError(t, err)
+		assert.False(t, active)
+	})
+}
@@ -0,0 +1,549 @@
+package service_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTokenService_RefreshTokens tests refresh token rotation and reuse detection
+func TestTokenService_RefreshTokens(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1", // 1 hour
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24", // 24 hours
+		},
+	}
+	mockLogger := logger.NewLogger(mockConfig)
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+
+	// issue logs a user in and returns the refresh token together with its stored record
+	issue := func(t *testing.T) (string, entity.RefreshToken) {
+		mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+		var stored entity.RefreshToken
+		mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(entity.RefreshToken)
+			}).
+			Return(nil)
+
+		tokenService := service.NewTokenService(
+			mockRefreshTokenRepo,
+			newMockSessionRepo(),
+			new(MockUserRepo),
+			newMockRoleRepo(),
+			newMockOrganizationRepo(),
+			repository.NewMemoryTokenRevocationStore(),
+			keySet,
+			mockLogger,
+			mockConfig,
+		)
+		resp, err := tokenService.IssueTokens(context.Background(), user)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.RefreshToken)
+		return resp.RefreshToken, stored
+	}
+
+	testCases := []struct {
+		name       string
+		token      func(*testing.T) (string, entity.RefreshToken)
+		mockSetup  func(*MockUserRepo, *MockRefreshTokenRepo, entity.RefreshToken)
+		expectErr  bool
+		errMessage string
+	}{
+		{
+			name:  "successful rotation",
+			token: issue,
+			mockSetup: func(u *MockUserRepo, r *MockRefreshTokenRepo, stored entity.RefreshToken) {
+				r.On("GetRefreshTokenByID", mock.Anything, stored.ID).Return(stored, nil)
+				u.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				r.On("RevokeRefreshToken", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+				r.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(next entity.RefreshToken) bool {
+					return next.FamilyID == stored.FamilyID && next.ID != stored.ID
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "reused token revokes family",
+			token: issue,
+			mockSetup: func(u *MockUserRepo, r *MockRefreshTokenRepo, stored entity.RefreshToken) {
+				revokedAt := time.Now()
+				stored.RevokedAt = &revokedAt
+				r.On("GetRefreshTokenByID", mock.Anything, stored.ID).Return(stored, nil)
+				r.On("RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID).Return(nil)
+			},
+			expectErr:  true,
+			errMessage: "Refresh token has already been used",
+		},
+		{
+			name:  "concurrent rotation revokes family",
+			token: issue,
+			mockSetup: func(u *MockUserRepo, r *MockRefreshTokenRepo, stored entity.RefreshToken) {
+				r.On("GetRefreshTokenByID", mock.Anything, stored.ID).Return(stored, nil)
+				u.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+				r.On("RevokeRefreshToken", mock.Anything, stored.ID, mock.Anything).Return(false, nil)
+				r.On("RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID).Return(nil)
+			},
+			expectErr:  true,
+			errMessage: "Refresh token has already been used",
+		},
+		{
+			name: "malformed token",
+			token: func(t *testing.T) (string, entity.RefreshToken) {
+				return "not-a-token", entity.RefreshToken{}
+			},
+			mockSetup:  func(u *MockUserRepo, r *MockRefreshTokenRepo, stored entity.RefreshToken) {},
+			expectErr:  true,
+			errMessage: "Invalid refresh token",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			refreshToken, stored := tc.token(t)
+
+			mockUserRepo := new(MockUserRepo)
+			mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+			tc.mockSetup(mockUserRepo, mockRefreshTokenRepo, stored)
+
+			tokenService := service.NewTokenService(
+				mockRefreshTokenRepo,
+				newMockSessionRepo(),
+				mockUserRepo,
+				newMockRoleRepo(),
+				newMockOrganizationRepo(),
+				repository.NewMemoryTokenRevocationStore(),
+				keySet,
+				mockLogger,
+				mockConfig,
+			)
+			resp, err := tokenService.RefreshTokens(context.Background(), refreshToken)
+
+			if tc.expectErr {
+				require.Error(t, err)
+				appErr, ok := err.(*errors.AppError)
+				require.True(t, ok)
+				assert.Equal(t, http.StatusUnauthorized, appErr.Status)
+				assert.Equal(t, tc.errMessage, appErr.Message)
+				assert.Empty(t, resp.Token)
+			} else {
+				require.NoError(t, err)
+				assert.NotEmpty(t, resp.Token)
+				assert.NotEmpty(t, resp.RefreshToken)
+				assert.NotEqual(t, refreshToken, resp.RefreshToken)
+			}
+			mockUserRepo.AssertExpectations(t)
+			mockRefreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestTokenService_AsymmetricSigning tests signing with PEM keys, kid headers and key rotation
+func TestTokenService_AsymmetricSigning(t *testing.T) {
+	t.Parallel()
+
+	writeKey := func(t *testing.T, dir, name string, key interface{}, public bool) string {
+		var (
+			der       []byte
+			blockType string
+			err       error
+		)
+		if public {
+			der, err = x509.MarshalPKIXPublicKey(key)
+			blockType = "PUBLIC KEY"
+		} else {
+			der, err = x509.MarshalPKCS8PrivateKey(key)
+			blockType = "PRIVATE KEY"
+		}
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+		return path
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		current interface{}
+		old     interface{}
+		alg     string
+	}{
+		{name: "RS256", current: rsaKey, old: ecKey, alg: "RS256"},
+		{name: "ES256", current: ecKey, old: edKey, alg: "ES256"},
+		{name: "EdDSA", current: edKey, old: rsaKey, alg: "EdDSA"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			oldPrivate := writeKey(t, dir, "old.pem", tc.old, false)
+			oldPublic := writeKey(t, dir, "old.pub.pem", tc.old.(crypto.Signer).Public(), true)
+			currentPrivate := writeKey(t, dir, "current.pem", tc.current, false)
+
+			newConfig := func(privateKey string, verificationKeys ...string) *config.Config {
+				return &config.Config{
+					Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+					JWT: config.JWTConfig{
+						ExpirationTime:        "1",
+						RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+						RefreshExpirationTime: "24",
+						PrivateKeyFile:        privateKey,
+						VerificationKeyFiles:  verificationKeys,
+					},
+				}
+			}
+			oldConfig := newConfig(oldPrivate)
+			currentConfig := newConfig(currentPrivate, oldPublic)
+
+			oldKeySet, err := util.NewJWTKeySet(oldConfig)
+			require.NoError(t, err)
+			currentKeySet, err := util.NewJWTKeySet(currentConfig)
+			require.NoError(t, err)
+			assert.Len(t, currentKeySet.JWKS().Keys, 2)
+
+			issueWith := func(keySet *util.JWTKeySet, cfg *config.Config) string {
+				mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+				mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+				tokenService := service.NewTokenService(
+					mockRefreshTokenRepo,
+					newMockSessionRepo(),
+					new(MockUserRepo),
+					newMockRoleRepo(),
+					newMockOrganizationRepo(),
+					repository.NewMemoryTokenRevocationStore(),
+					keySet,
+					logger.NewLogger(cfg),
+					cfg,
+				)
+				resp, err := tokenService.IssueTokens(context.Background(), entity.User{
+					ID:    uuid.New(),
+					Email: "test@example.com",
+				})
+				require.NoError(t, err)
+				return resp.Token
+			}
+
+			verify := func(token string) error {
+				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+				c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+				return util.ExtractTokenID(c, currentKeySet, nil, nil)
+			}
+
+			currentToken := issueWith(currentKeySet, currentConfig)
+			parsed, _, err := jwt.NewParser().ParseUnverified(currentToken, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.alg, parsed.Method.Alg())
+			assert.Equal(t, currentKeySet.JWKS().Keys[0].Kid, parsed.Header["kid"])
+			assert.NoError(t, verify(currentToken))
+
+			// Tokens signed before the rotation stay valid while the old public key is configured
+			assert.NoError(t, verify(issueWith(oldKeySet, oldConfig)))
+
+			// Tokens signed with an unknown key or with the shared secret are rejected
+			_, unknownKey, err := ed25519.GenerateKey(rand.Reader)
+			require.NoError(t, err)
+			unknownConfig := newConfig(writeKey(t, dir, "unknown.pem", unknownKey, false))
+			unknownKeySet, err := util.NewJWTKeySet(unknownConfig)
+			require.NoError(t, err)
+			assert.Error(t, verify(issueWith(unknownKeySet, unknownConfig)))
+
+			hmacConfig := newConfig("")
+			hmacConfig.JWT.Secret = "test-jwt-secret-of-at-least-32-bytes"
+			hmacKeySet, err := util.NewJWTKeySet(hmacConfig)
+			require.NoError(t, err)
+			assert.Error(t, verify(issueWith(hmacKeySet, hmacConfig)))
+		})
+	}
+}
+
+// TestTokenService_AccessTokenClaims tests that access tokens carry the registered claims
+// and that tokens with a wrong issuer or audience, or missing claims, are rejected
+func TestTokenService_AccessTokenClaims(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+			Issuer:                "https://auth.example.com",
+			Audience:              "example-api",
+			Leeway:                30 * time.Second,
+		},
+	}
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+
+	mockRefreshTokenRepo := new(MockRefreshTokenRepo)
+	mockRefreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+	tokenService := service.NewTokenService(
+		mockRefreshTokenRepo,
+		newMockSessionRepo(),
+		new(MockUserRepo),
+		newMockRoleRepo(),
+		newMockOrganizationRepo(),
+		repository.NewMemoryTokenRevocationStore(),
+		keySet,
+		logger.NewLogger(mockConfig),
+		mockConfig,
+	)
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+	resp, err := tokenService.IssueTokens(context.Background(), user)
+	require.NoError(t, err)
+
+	verify := func(token string) (*gin.Context, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		return c, util.ExtractTokenID(c, keySet, nil, nil)
+	}
+
+	claims, err := keySet.ParseAccessToken(resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"example-api"}, claims.Audience)
+	assert.Equal(t, user.ID.String(), claims.Subject)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotNil(t, claims.NotBefore)
+
+	c, err := verify(resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, util.UserIDFromCTX(c))
+	assert.Equal(t, claims.ID, util.TokenIDFromCTX(c))
+
+	now := time.Now()
+	validClaims := func() util.AccessClaims {
+		return util.AccessClaims{
+			Email: user.Email,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "https://auth.example.com",
+				Audience:  jwt.ClaimStrings{"example-api"},
+				Subject:   user.ID.String(),
+				ID:        uuid.NewString(),
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*util.AccessClaims)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*util.AccessClaims) {}},
+		{name: "clock skew within leeway", modify: func(c *util.AccessClaims) {
+			c.NotBefore = jwt.NewNumericDate(now.Add(10 * time.Second))
+			c.IssuedAt = jwt.NewNumericDate(now.Add(10 * time.Second))
+		}},
+		{name: "not yet valid", modify: func(c *util.AccessClaims) {
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+		}, wantErr: true},
+		{name: "expired beyond leeway", modify: func(c *util.AccessClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+		}, wantErr: true},
+		{name: "wrong issuer", modify: func(c *util.AccessClaims) { c.Issuer = "https://evil.example.com" }, wantErr: true},
+		{name: "wrong audience", modify: func(c *util.AccessClaims) { c.Audience = jwt.ClaimStrings{"other-api"} }, wantErr: true},
+		{name: "missing subject", modify: func(c *util.AccessClaims) { c.Subject = "" }, wantErr: true},
+		{name: "malformed subject", modify: func(c *util.AccessClaims) { c.Subject = "42" }, wantErr: true},
+		{name: "missing email", modify: func(c *util.AccessClaims) { c.Email = "" }, wantErr: true},
+		{name: "missing token ID", modify: func(c *util.AccessClaims) { c.ID = "" }, wantErr: true},
+		{name: "missing issued at", modify: func(c *util.AccessClaims) { c.IssuedAt = nil }, wantErr: true},
+		{name: "missing not before", modify: func(c *util.AccessClaims) { c.NotBefore = nil }, wantErr: true},
+		{name: "missing expiry", modify: func(c *util.AccessClaims) { c.ExpiresAt = nil }, wantErr: true},
+		{name: "malformed session", modify: func(c *util.AccessClaims) { c.SessionID = "not-a-session" }, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims := validClaims()
+			tc.modify(&claims)
+			token, err := keySet.Sign(claims)
+			require.NoError(t, err)
+
+			_, err = verify(token)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("legacy map claims", func(t *testing.T) {
+		t.Parallel()
+
+		token, err := keySet.Sign(jwt.MapClaims{
+			constant.UserID:     user.ID.String(),
+			constant.Email:      user.Email,
+			constant.ExpireDate: now.Add(time.Hour).Unix(),
+		})
+		require.NoError(t, err)
+		_, err = verify(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("other HMAC algorithm", func(t *testing.T) {
+		t.Parallel()
+
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, validClaims()).
+			SignedString([]byte(mockConfig.JWT.Secret))
+		require.NoError(t, err)
+		_, err = verify(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("short secret", func(t *testing.T) {
+		t.Parallel()
+
+		shortConfig := *mockConfig
+		shortConfig.JWT.Secret = "too-short"
+		_, err := util.NewJWTKeySet(&shortConfig)
+		assert.Error(t, err)
+	})
+}
+
+// TestTokenService_SessionOrganization tests that tokens work in the organization of their session,
+// starting with the one the user joined first, and that a refresh leaves an organization the user was removed from
+func TestTokenService_SessionOrganization(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		JWT: config.JWTConfig{
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "1",
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "24",
+		},
+	}
+	keySet, err := util.NewJWTKeySet(mockConfig)
+	require.NoError(t, err)
+
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+	firstID := uuid.New()
+	leftID := uuid.New()
+
+	organizationRepo := new(MockOrganizationRepo)
+	organizationRepo.On("GetUserMemberships", mock.Anything, user.ID).Return([]entity.OrganizationMember{
+		{OrganizationID: firstID, UserID: user.ID, Role: constant.OrganizationRoleMember},
+	}, nil)
+	organizationRepo.On("GetUserMembership", mock.Anything, leftID, user.ID).
+		Return(entity.OrganizationMember{}, errors.NewNotFoundError("Not a member of the organization"))
+
+	var session entity.Session
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("CreateSession", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			session = args.Get(1).(entity.Session)
+		}).
+		Return(nil)
+	sessionRepo.On("RotateSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	var stored entity.RefreshToken
+	refreshTokenRepo := new(MockRefreshTokenRepo)
+	refreshTokenRepo.On("CreateRefreshToken", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(entity.RefreshToken)
+		}).
+		Return(nil)
+	refreshTokenRepo.On("RevokeRefreshToken", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+
+	userRepo := new(MockUserRepo)
+	userRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+
+	tokenService := service.NewTokenService(
+		refreshTokenRepo,
+		sessionRepo,
+		userRepo,
+		newMockRoleRepo(),
+		organizationRepo,
+		repository.NewMemoryTokenRevocationStore(),
+		keySet,
+		logger.NewLogger(mockConfig),
+		mockConfig,
+	)
+
+	resp, err := tokenService.IssueTokens(context.Background(), user)
+	require.NoError(t, err)
+	require.NotNil(t, session.OrganizationID)
+	assert.Equal(t, firstID, *session.OrganizationID)
+	claims, err := keySet.ParseAccessToken(resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, firstID.String(), claims.OrganizationID)
+
+	// The session switched to an organization the user was removed from since
+	switched := session
+	switched.OrganizationID = &leftID
+	sessionRepo.On("GetSessionByID", mock.Anything, session.ID).Return(switched, nil)
+	sessionRepo.On("SetSessionOrganization", mock.Anything, session.ID, &firstID).Return(nil)
+	refreshTokenRepo.On("GetRefreshTokenByID", mock.Anything, stored.ID).Return(stored, nil)
+
+	resp, err = tokenService.RefreshTokens(context.Background(), resp.RefreshToken)
+	require.NoError(t, err)
+	claims, err = keySet.ParseAccessToken(resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, firstID.String(), claims.OrganizationID)
+	sessionRepo.AssertExpectations(t)
+}
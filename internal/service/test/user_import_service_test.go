@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func newUserImportTestService(
+	lc fx.Lifecycle,
+	userImportRepo *MockUserImportRepo,
+	authService *MockAuthService,
+) service.UserImportService {
+	mockConfig := &config.Config{
+		Server: config.ServerCfg{Env: constant.DevelopmentEnv},
+		Admin:  config.AdminConfig{UserImportMaxRows: 2},
+	}
+	return service.NewUserImportService(lc, userImportRepo, authService, logger.NewLogger(mockConfig), mockConfig)
+}
+
+// TestUserImportService_ImportUsers tests that the layout of an upload is checked before it is queued
+func TestUserImportService_ImportUsers(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, adminID.String())
+
+	testCases := []struct {
+		name          string
+		content       string
+		expectedRows  int
+		expectedError string
+	}{
+		{
+			name:         "columns in any order with a BOM",
+			content:      "\xef\xbb\xbfPassword,Email,Last_Name,First_Name,Team\nsecret123,a@example.com,Doe,Jane,ops\n",
+			expectedRows: 1,
+		},
+		{
+			name:          "empty file",
+			content:       "",
+			expectedError: "The CSV is empty",
+		},
+		{
+			name:          "missing column",
+			content:       "email,first_name,last_name\na@example.com,Jane,Doe\n",
+			expectedError: "The CSV has no password column",
+		},
+		{
+			name:          "header only",
+			content:       "email,first_name,last_name,password\n",
+			expectedError: "The CSV has no users",
+		},
+		{
+			name:          "too many rows",
+			content:       "email,first_name,last_name,password\na@x.com,A,A,secret123\nb@x.com,B,B,secret123\nc@x.com,C,C,secret123\n",
+			expectedError: "The CSV has more than 2 users",
+		},
+		{
+			name:          "ragged row",
+			content:       "email,first_name,last_name,password\na@example.com,Jane\n",
+			expectedError: "Invalid CSV: record on line 2: wrong number of fields",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserImportRepo := new(MockUserImportRepo)
+			mockUserImportRepo.On("CreateUserImport", mock.Anything, mock.MatchedBy(func(userImport entity.UserImport) bool {
+				return userImport.Status == entity.UserImportPending &&
+					userImport.TotalRows == tc.expectedRows &&
+					userImport.CreatedBy == adminID.String() &&
+					string(userImport.Content) == tc.content
+			})).Return(nil)
+
+			resp, err := newUserImportTestService(fxtest.NewLifecycle(t), mockUserImportRepo, new(MockAuthService)).
+				ImportUsers(ctx, "users.csv", []byte(tc.content))
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Equal(t, errors.NewBadRequestError(tc.expectedError).Error(), err.Error())
+				mockUserImportRepo.AssertNotCalled(t, "CreateUserImport", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "users.csv", resp.FileName)
+			assert.Equal(t, entity.UserImportPending, resp.Status)
+			assert.Equal(t, tc.expectedRows, resp.TotalRows)
+			mockUserImportRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUserImportService_ProcessImport tests that the worker registers each valid row and records
+// the line and reason of each row that is not imported
+func TestUserImportService_ProcessImport(t *testing.T) {
+	t.Parallel()
+
+	userImport := entity.UserImport{
+		ID:     uuid.New(),
+		Status: entity.UserImportPending,
+		Content: []byte("email,first_name,last_name,password,confirm_password\n" +
+			"jane@example.com,Jane,Doe,secret123,secret123\n" +
+			"taken@example.com,John,Doe,secret123,secret123\n" +
+			"\"multi\nline\",Bad,Row,secret123,different\n" +
+			"weak@example.com,Weak,Password,password,password\n" +
+			"long@example.com,Long,Password," + strings.Repeat("x", 151) + "," + strings.Repeat("x", 151) + "\n"),
+	}
+
+	mockAuthService := new(MockAuthService)
+	mockAuthService.On("Register", mock.Anything, request.UserRegisterRequest{
+		Email: "jane@example.com", FirstName: "Jane", LastName: "Doe", Password: "secret123", ConfirmPassword: "secret123",
+	}).Return(response.UserInfoResponse{UserID: uuid.New()}, nil)
+	mockAuthService.On("Register", mock.Anything, mock.MatchedBy(func(req request.UserRegisterRequest) bool {
+		return req.Email == "taken@example.com"
+	})).Return(response.UserInfoResponse{}, errors.NewConflictError("Email already exists"))
+	mockAuthService.On("Register", mock.Anything, mock.MatchedBy(func(req request.UserRegisterRequest) bool {
+		return req.Email == "weak@example.com"
+	})).Return(response.UserInfoResponse{}, errors.NewInvalidDataError(
+		"Password does not meet the password policy",
+		map[string][]string{"password": {"must contain a digit", "must not be a common password"}},
+	))
+
+	var rowErrors []entity.UserImportError
+	completed := make(chan struct{})
+	mockUserImportRepo := new(MockUserImportRepo)
+	mockUserImportRepo.On("GetPendingUserImports", mock.Anything).Return([]entity.UserImport{userImport}, nil).Once()
+	mockUserImportRepo.On("GetPendingUserImports", mock.Anything).Return([]entity.UserImport{}, nil)
+	mockUserImportRepo.On("CompleteUserImport", mock.Anything, userImport.ID, 1, mock.Anything).
+		Run(func(args mock.Arguments) {
+			rowErrors = args.Get(3).([]entity.UserImportError)
+			close(completed)
+		}).
+		Return(true, nil)
+
+	lc := fxtest.NewLifecycle(t)
+	newUserImportTestService(lc, mockUserImportRepo, mockAuthService)
+	lc.RequireStart()
+	select {
+	case <-completed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("user import was not processed")
+	}
+	lc.RequireStop()
+
+	require.Len(t, rowErrors, 4)
+	assert.Equal(t, entity.UserImportError{
+		Line: 3, Email: "taken@example.com", Message: "Email already exists",
+	}, rowErrors[0])
+	assert.Equal(t, 4, rowErrors[1].Line, "a quoted value spanning lines is reported at its first line")
+	assert.Equal(t, "Password and confirm password are not meet!", rowErrors[1].Message)
+	assert.Equal(t, 6, rowErrors[2].Line)
+	assert.Equal(t,
+		"Password does not meet the password policy: password must contain a digit, must not be a common password",
+		rowErrors[2].Message,
+	)
+	assert.Equal(t, "Password must be at most 150 bytes", rowErrors[3].Message)
+	mockAuthService.AssertNumberOfCalls(t, "Register", 3)
+}
+
+// TestUserImportService_ImportUsersLongFileName tests that a long file name is cut to its column
+// without splitting a multi-byte character
+func TestUserImportService_ImportUsersLongFileName(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), util.UserIDCTX, uuid.New().String())
+	mockUserImportRepo := new(MockUserImportRepo)
+	mockUserImportRepo.On("CreateUserImport", mock.Anything, mock.Anything).Return(nil)
+
+	// The 128th two-byte character straddles the 255 byte limit
+	fileName := strings.Repeat("é", 130) + ".csv"
+	resp, err := newUserImportTestService(fxtest.NewLifecycle(t), mockUserImportRepo, new(MockAuthService)).
+		ImportUsers(ctx, fileName, []byte("email,first_name,last_name,password\na@example.com,Jane,Doe,secret123\n"))
+	require.NoError(t, err)
+	assert.True(t, utf8.ValidString(resp.FileName))
+	assert.Equal(t, strings.Repeat("é", 127), resp.FileName)
+}
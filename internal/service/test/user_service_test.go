@@ -2,14 +2,21 @@ package service_test
 
 import (
 	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/model/response"
 	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
 	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -24,7 +31,8 @@ func TestUserService_GetUserInfo(t *testing.T) {
 	// Setup mock database to return a valid DB instance
 	mockDB.On("GetDB").Return(&gorm.DB{})
 
-	userService := service.NewUserService(mockUserRepo, mockDB)
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	userService := service.NewUserService(mockUserRepo, mockDB, new(MockVerificationService), logger.NewLogger(mockConfig))
 
 	// Define test cases
 	testCases := []struct {
@@ -97,3 +105,129 @@ func TestUserService_GetUserInfo(t *testing.T) {
 		})
 	}
 }
+
+// TestUserService_UpdateUserInfo tests partial profile updates and that a new email is only pending
+func TestUserService_UpdateUserInfo(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := &config.Config{Server: config.ServerCfg{Env: constant.DevelopmentEnv}}
+	mockLogger := logger.NewLogger(mockConfig)
+	stringPtr := func(value string) *string { return &value }
+
+	testCases := []struct {
+		name          string
+		req           request.UpdateUserInfoRequest
+		pendingEmail  string
+		ctx           func(context.Context) context.Context
+		setupMocks    func(*MockUserRepo, *MockVerificationService, entity.User)
+		expectedError error
+		expectedResp  func(entity.User) response.UserInfoResponse
+	}{
+		{
+			name: "last name only",
+			req:  request.UpdateUserInfoRequest{LastName: stringPtr("Smith")},
+			setupMocks: func(userRepo *MockUserRepo, _ *MockVerificationService, user entity.User) {
+				userRepo.On("UpdateUserProfile", mock.Anything, mock.MatchedBy(func(updated entity.User) bool {
+					return updated.FirstName == "John" && updated.LastName == "Smith" &&
+						updated.Email == user.Email && updated.UpdatedBy == user.ID.String()
+				})).Return(nil)
+			},
+			expectedResp: func(user entity.User) response.UserInfoResponse {
+				return response.UserInfoResponse{
+					UserID: user.ID, Email: user.Email, FullName: "John Smith", FirstName: "John", LastName: "Smith",
+				}
+			},
+		},
+		{
+			name: "new email waits for confirmation",
+			req:  request.UpdateUserInfoRequest{Email: stringPtr("new@example.com")},
+			setupMocks: func(userRepo *MockUserRepo, verificationService *MockVerificationService, user entity.User) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "new@example.com").Return(nil)
+				verificationService.On("SendEmailChangeConfirmation", mock.Anything, user, "new@example.com").Return(nil)
+			},
+			expectedResp: func(user entity.User) response.UserInfoResponse {
+				return response.UserInfoResponse{
+					UserID: user.ID, Email: user.Email, FullName: "John Doe", FirstName: "John", LastName: "Doe",
+					PendingEmail: "new@example.com",
+				}
+			},
+		},
+		{
+			name:         "current email cancels the pending change",
+			req:          request.UpdateUserInfoRequest{Email: stringPtr("john@example.com")},
+			pendingEmail: "new@example.com",
+			setupMocks: func(userRepo *MockUserRepo, _ *MockVerificationService, user entity.User) {
+				userRepo.On("SetPendingEmail", mock.Anything, user.ID, "").Return(nil)
+			},
+			expectedResp: func(user entity.User) response.UserInfoResponse {
+				return response.UserInfoResponse{
+					UserID: user.ID, Email: user.Email, FullName: "John Doe", FirstName: "John", LastName: "Doe",
+				}
+			},
+		},
+		{
+			name: "email taken",
+			req:  request.UpdateUserInfoRequest{Email: stringPtr("taken@example.com")},
+			setupMocks: func(userRepo *MockUserRepo, _ *MockVerificationService, _ entity.User) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "taken@example.com").
+					Return(errors.NewConflictError("Email already exists"))
+			},
+			expectedError: errors.NewConflictError("Email already exists"),
+		},
+		{
+			name: "email change with an API key",
+			req:  request.UpdateUserInfoRequest{Email: stringPtr("new@example.com")},
+			ctx: func(ctx context.Context) context.Context {
+				return context.WithValue(ctx, util.APIKeyIDCTX, uuid.NewString())
+			},
+			setupMocks:    func(*MockUserRepo, *MockVerificationService, entity.User) {},
+			expectedError: errors.NewForbiddenError("The email address can't be changed with an API key"),
+		},
+		{
+			name: "email change while impersonating",
+			req:  request.UpdateUserInfoRequest{Email: stringPtr("new@example.com")},
+			ctx: func(ctx context.Context) context.Context {
+				return context.WithValue(ctx, util.ActorIDCTX, uuid.NewString())
+			},
+			setupMocks:    func(*MockUserRepo, *MockVerificationService, entity.User) {},
+			expectedError: errors.NewForbiddenError("Not allowed while impersonating a user"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := entity.User{
+				ID:           uuid.New(),
+				FirstName:    "John",
+				LastName:     "Doe",
+				Email:        "john@example.com",
+				PendingEmail: tc.pendingEmail,
+			}
+			mockUserRepo := new(MockUserRepo)
+			mockVerificationService := new(MockVerificationService)
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+			tc.setupMocks(mockUserRepo, mockVerificationService, user)
+
+			ctx := context.WithValue(context.Background(), util.UserIDCTX, user.ID.String())
+			if tc.ctx != nil {
+				ctx = tc.ctx(ctx)
+			}
+			userService := service.NewUserService(mockUserRepo, new(MockDatabase), mockVerificationService, mockLogger)
+			resp, err := userService.UpdateUserInfo(ctx, tc.req)
+			if tc.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				mockVerificationService.AssertNotCalled(t, "SendEmailChangeConfirmation", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedResp(user), resp)
+			mockUserRepo.AssertExpectations(t)
+			mockVerificationService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package service_test
+
+import (
+	"context"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newVerificationTestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerCfg{
+			Env: constant.DevelopmentEnv,
+		},
+		Auth: config.AuthConfig{
+			ActionTokenSecret:               "action_secret",
+			EmailVerificationURL:            "http://localhost:3000/verify-email",
+			EmailVerificationTokenTTL:       time.Hour,
+			EmailVerificationResendInterval: time.Minute,
+			EmailChangeURL:                  "http://localhost:3000/confirm-email",
+		},
+	}
+}
+
+// TestVerificationService_SendVerificationEmail tests that verification links are signed and throttled
+func TestVerificationService_SendVerificationEmail(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newVerificationTestConfig()
+	mockLogger := logger.NewLogger(mockConfig)
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+
+	t.Run("sends a signed link", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		mockNotifier := new(MockNotifier)
+		var sent notifier.Message
+		mockUserRepo.On("MarkEmailVerificationSent", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+		mockNotifier.On("Send", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				sent = args.Get(1).(notifier.Message)
+			}).
+			Return(nil)
+
+		verificationService := service.NewVerificationService(mockUserRepo, mockNotifier, mockLogger, mockConfig)
+		require.NoError(t, verificationService.SendVerificationEmail(context.Background(), user))
+
+		assert.Equal(t, user.Email, sent.To)
+		linkStart := strings.Index(sent.Body, mockConfig.Auth.EmailVerificationURL)
+		require.GreaterOrEqual(t, linkStart, 0)
+		link, err := url.Parse(strings.Fields(sent.Body[linkStart:])[0])
+		require.NoError(t, err)
+		claims, err := util.ParseActionToken(
+			mockConfig.Auth.ActionTokenSecret,
+			constant.PurposeEmailVerification,
+			link.Query().Get("token"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), claims.Subject)
+		assert.Equal(t, user.Email, claims.Email)
+	})
+
+	t.Run("throttles repeated sends", func(t *testing.T) {
+		t.Parallel()
+
+		mockUserRepo := new(MockUserRepo)
+		mockNotifier := new(MockNotifier)
+		mockUserRepo.On("MarkEmailVerificationSent", mock.Anything, user.ID, mock.Anything).Return(false, nil)
+
+		verificationService := service.NewVerificationService(mockUserRepo, mockNotifier, mockLogger, mockConfig)
+		err := verificationService.SendVerificationEmail(context.Background(), user)
+		require.Error(t, err)
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusTooManyRequests, appErr.Status)
+		mockNotifier.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+// TestVerificationService_VerifyEmail tests redeeming verification tokens
+func TestVerificationService_VerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newVerificationTestConfig()
+	mockLogger := logger.NewLogger(mockConfig)
+	user := entity.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+	sign := func(t *testing.T, secret, purpose, email string, ttl time.Duration) string {
+		token, err := util.SignActionToken(secret, purpose, user.ID.String(), email, ttl)
+		require.NoError(t, err)
+		return token
+	}
+	secret := mockConfig.Auth.ActionTokenSecret
+
+	testCases := []struct {
+		name      string
+		token     func(*testing.T) string
+		markUser  bool
+		expectErr bool
+	}{
+		{
+			name: "valid token",
+			token: func(t *testing.T) string {
+				return sign(t, secret, constant.PurposeEmailVerification, user.Email, time.Hour)
+			},
+			markUser: true,
+		},
+		{
+			name: "expired token",
+			token: func(t *testing.T) string {
+				return sign(t, secret, constant.PurposeEmailVerification, user.Email, -time.Minute)
+			},
+			expectErr: true,
+		},
+		{
+			name: "token for another purpose",
+			token: func(t *testing.T) string {
+				return sign(t, secret, "other", user.Email, time.Hour)
+			},
+			expectErr: true,
+		},
+		{
+			name: "token signed with another secret",
+			token: func(t *testing.T) string {
+				return sign(t, "other_secret", constant.PurposeEmailVerification, user.Email, time.Hour)
+			},
+			expectErr: true,
+		},
+		{
+			name: "email changed since the token was sent",
+			token: func(t *testing.T) string {
+				return sign(t, secret, constant.PurposeEmailVerification, "old@example.com", time.Hour)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+			if tc.markUser {
+				mockUserRepo.On("MarkEmailVerified", mock.Anything, user.ID, user.Email).Return(true, nil)
+			}
+
+			verificationService := service.NewVerificationService(mockUserRepo, new(MockNotifier), mockLogger, mockConfig)
+			err := verificationService.VerifyEmail(context.Background(), request.VerifyEmailRequest{Token: tc.token(t)})
+
+			if tc.expectErr {
+				assert.Error(t, err)
+				mockUserRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestVerificationService_VerifyEmailWithoutSecret tests that a token forged with an empty key
+// is refused when no action token secret is configured
+func TestVerificationService_VerifyEmailWithoutSecret(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newVerificationTestConfig()
+	mockConfig.Auth.ActionTokenSecret = ""
+	user := entity.User{ID: uuid.New(), Email: "test@example.com"}
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, util.ActionClaims{
+		Purpose: constant.PurposeEmailVerification,
+		Email:   user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte{})
+	require.NoError(t, err)
+
+	mockUserRepo := new(MockUserRepo)
+	verificationService := service.NewVerificationService(mockUserRepo, new(MockNotifier), logger.NewLogger(mockConfig), mockConfig)
+	err = verificationService.VerifyEmail(context.Background(), request.VerifyEmailRequest{Token: forged})
+	assert.Error(t, err)
+	mockUserRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestVerificationService_SendEmailChangeConfirmation tests that the new address gets a confirmation link
+// and the current address is warned
+func TestVerificationService_SendEmailChangeConfirmation(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newVerificationTestConfig()
+	user := entity.User{ID: uuid.New(), Email: "old@example.com"}
+
+	mockUserRepo := new(MockUserRepo)
+	mockNotifier := new(MockNotifier)
+	sent := map[string]notifier.Message{}
+	mockUserRepo.On("MarkEmailVerificationSent", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+	mockUserRepo.On("SetPendingEmail", mock.Anything, user.ID, "new@example.com").Return(nil)
+	mockNotifier.On("Send", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			message := args.Get(1).(notifier.Message)
+			sent[message.To] = message
+		}).
+		Return(nil)
+
+	verificationService := service.NewVerificationService(mockUserRepo, mockNotifier, logger.NewLogger(mockConfig), mockConfig)
+	require.NoError(t, verificationService.SendEmailChangeConfirmation(context.Background(), user, "new@example.com"))
+
+	require.Contains(t, sent, "new@example.com")
+	body := sent["new@example.com"].Body
+	linkStart := strings.Index(body, mockConfig.Auth.EmailChangeURL)
+	require.GreaterOrEqual(t, linkStart, 0)
+	link, err := url.Parse(strings.Fields(body[linkStart:])[0])
+	require.NoError(t, err)
+	claims, err := util.ParseActionToken(
+		mockConfig.Auth.ActionTokenSecret,
+		constant.PurposeEmailChange,
+		link.Query().Get("token"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), claims.Subject)
+	assert.Equal(t, "new@example.com", claims.Email)
+
+	require.Contains(t, sent, user.Email)
+	assert.Contains(t, sent[user.Email].Body, "new@example.com")
+	assert.NotContains(t, sent[user.Email].Body, mockConfig.Auth.EmailChangeURL)
+}
+
+// TestVerificationService_ConfirmEmailChange tests that only the latest email change link replaces the email
+func TestVerificationService_ConfirmEmailChange(t *testing.T) {
+	t.Parallel()
+
+	mockConfig := newVerificationTestConfig()
+	mockLogger := logger.NewLogger(mockConfig)
+	userID := uuid.New()
+	sign := func(t *testing.T, purpose, email string) string {
+		token, err := util.SignActionToken(mockConfig.Auth.ActionTokenSecret, purpose, userID.String(), email, time.Hour)
+		require.NoError(t, err)
+		return token
+	}
+
+	testCases := []struct {
+		name          string
+		purpose       string
+		email         string
+		setupMocks    func(*MockUserRepo)
+		expectedError error
+	}{
+		{
+			name:    "pending email is confirmed",
+			purpose: constant.PurposeEmailChange,
+			email:   "new@example.com",
+			setupMocks: func(userRepo *MockUserRepo) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "new@example.com").Return(nil)
+				userRepo.On("ConfirmPendingEmail", mock.Anything, userID, "new@example.com").Return(true, nil)
+			},
+		},
+		{
+			name:    "link of an earlier change request",
+			purpose: constant.PurposeEmailChange,
+			email:   "earlier@example.com",
+			setupMocks: func(userRepo *MockUserRepo) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "earlier@example.com").Return(nil)
+				userRepo.On("ConfirmPendingEmail", mock.Anything, userID, "earlier@example.com").Return(false, nil)
+			},
+			expectedError: errors.NewBadRequestError("Invalid or expired email change token"),
+		},
+		{
+			name:    "address registered in the meantime",
+			purpose: constant.PurposeEmailChange,
+			email:   "taken@example.com",
+			setupMocks: func(userRepo *MockUserRepo) {
+				userRepo.On("VerifyUserEmail", mock.Anything, "taken@example.com").
+					Return(errors.NewConflictError("Email already exists"))
+			},
+			expectedError: errors.NewConflictError("Email already exists"),
+		},
+		{
+			name:          "email verification token",
+			purpose:       constant.PurposeEmailVerification,
+			email:         "new@example.com",
+			setupMocks:    func(*MockUserRepo) {},
+			expectedError: errors.NewBadRequestError("Invalid or expired email change token"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockUserRepo := new(MockUserRepo)
+			tc.setupMocks(mockUserRepo)
+
+			verificationService := service.NewVerificationService(mockUserRepo, new(MockNotifier), mockLogger, mockConfig)
+			err := verificationService.ConfirmEmailChange(
+				context.Background(),
+				request.VerifyEmailRequest{Token: sign(t, tc.purpose, tc.email)},
+			)
+			if tc.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// maxUserAgentLength is the size of the sessions.user_agent column
+const maxUserAgentLength = 512
+
+// TokenService defines the interface for issuing and rotating token pairs
+type TokenService interface {
+	IssueTokens(ctx context.Context, user entity.User) (response.TokenResponse, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (response.TokenResponse, error)
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeRefreshToken(ctx context.Context, userID uuid.UUID, refreshToken string) error
+	IssueAccessToken(ctx context.Context, user entity.User, sessionID uuid.UUID, organizationID *uuid.UUID) (string, error)
+	IssueImpersonationToken(
+		ctx context.Context,
+		actor entity.User,
+		actorSessionID uuid.UUID,
+		user entity.User,
+	) (response.ImpersonationResponse, error)
+}
+
+// tokenService implements TokenService
+type tokenService struct {
+	refreshTokenRepo repository.RefreshTokenRepo
+	sessionRepo      repository.SessionRepo
+	userRepo         repository.UserRepo
+	roleRepo         repository.RoleRepo
+	organizationRepo repository.OrganizationRepo
+	revocationStore  repository.TokenRevocationStore
+	keySet           *util.JWTKeySet
+	logger           *logger.StandardLogger
+	config           *config.Config
+}
+
+// NewTokenService creates a new token service
+func NewTokenService(
+	refreshTokenRepo repository.RefreshTokenRepo,
+	sessionRepo repository.SessionRepo,
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	organizationRepo repository.OrganizationRepo,
+	revocationStore repository.TokenRevocationStore,
+	keySet *util.JWTKeySet,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) TokenService {
+	return &tokenService{
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		organizationRepo: organizationRepo,
+		revocationStore:  revocationStore,
+		keySet:           keySet,
+		logger:           logger,
+		config:           config,
+	}
+}
+
+// IssueTokens starts a new session, whose ID is also the refresh token family, and returns the first token pair.
+// The session starts in the organization the user joined first.
+func (s *tokenService) IssueTokens(ctx context.Context, user entity.User) (response.TokenResponse, error) {
+	expiresAt, err := s.refreshTokenExpiry()
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	organizationID, err := s.defaultOrganization(ctx, user.ID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	userAgent := truncateString(util.UserAgentFromCTX(ctx), maxUserAgentLength)
+	session := entity.Session{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		Device:         util.DescribeDevice(userAgent),
+		UserAgent:      userAgent,
+		IPAddress:      util.ClientIPFromCTX(ctx),
+		LastSeenAt:     time.Now(),
+		ExpiresAt:      expiresAt,
+		OrganizationID: organizationID,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: user.Email,
+		},
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		s.logger.WithError(err).Error("Failed to store session")
+		return response.TokenResponse{}, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID, organizationID, uuid.New(), expiresAt)
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair.
+// The presented token is revoked and replaced by one in the same family; presenting
+// a token that was already rotated is treated as theft and revokes the whole family.
+func (s *tokenService) RefreshTokens(ctx context.Context, refreshToken string) (response.TokenResponse, error) {
+	tokenID, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		s.logger.WithField("err", err.Error()).Info("Refresh token rejected")
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Invalid refresh token")
+	}
+
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByID(ctx, tokenID)
+	if err != nil {
+		s.logger.WithField("token_id", tokenID).WithError(err).Info("Refresh token not found")
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Invalid refresh token")
+	}
+
+	if stored.IsRevoked() {
+		return response.TokenResponse{}, s.handleReuse(ctx, stored)
+	}
+
+	if stored.IsExpired(time.Now()) {
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Refresh token has expired")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		s.logger.WithField("user_id", stored.UserID).WithError(err).Info("Refresh token owner not found")
+		return response.TokenResponse{}, errors.NewUnauthorizedError("Invalid refresh token")
+	}
+	if err := checkAccountActive(user); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	nextID := uuid.New()
+	revoked, err := s.refreshTokenRepo.RevokeRefreshToken(ctx, stored.ID, &nextID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if !revoked {
+		// Another request rotated this token between our read and our update
+		return response.TokenResponse{}, s.handleReuse(ctx, stored)
+	}
+
+	expiresAt, err := s.refreshTokenExpiry()
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	err = s.sessionRepo.RotateSession(
+		ctx,
+		stored.FamilyID,
+		util.ClientIPFromCTX(ctx),
+		truncateString(util.UserAgentFromCTX(ctx), maxUserAgentLength),
+		expiresAt,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to update session")
+		return response.TokenResponse{}, err
+	}
+
+	organizationID, err := s.sessionOrganization(ctx, user.ID, stored.FamilyID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	return s.issueTokens(ctx, user, stored.FamilyID, organizationID, nextID, expiresAt)
+}
+
+// IssueAccessToken signs an access token for an existing session, such as after switching organization.
+// No refresh token is issued; the session's refresh token keeps working.
+func (s *tokenService) IssueAccessToken(
+	ctx context.Context,
+	user entity.User,
+	sessionID uuid.UUID,
+	organizationID *uuid.UUID,
+) (string, error) {
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, user.ID)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load user roles")
+		return "", err
+	}
+
+	token, tokenErr := s.generateToken(user.ID, user.Email, sessionID, organizationID, roles)
+	if tokenErr != nil {
+		s.logger.WithError(tokenErr).Error("Failed to generate token")
+		return "", errors.NewInternalServerError("Failed to generate token: " + tokenErr.Error())
+	}
+	return token, nil
+}
+
+// defaultOrganization returns the organization the user joined first, or nil when they belong to none
+func (s *tokenService) defaultOrganization(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
+	memberships, err := s.organizationRepo.GetUserMemberships(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load user organizations")
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+	return &memberships[0].OrganizationID, nil
+}
+
+// sessionOrganization returns the organization a session is working in. When the user has left it
+// since, the session moves to their default organization.
+func (s *tokenService) sessionOrganization(ctx context.Context, userID, sessionID uuid.UUID) (*uuid.UUID, error) {
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load session")
+		return nil, err
+	}
+	if session.OrganizationID != nil {
+		_, err := s.organizationRepo.GetUserMembership(ctx, *session.OrganizationID, userID)
+		if err == nil {
+			return session.OrganizationID, nil
+		}
+		if !isNotFound(err) {
+			s.logger.WithError(err).Error("Failed to check organization membership")
+			return nil, err
+		}
+	}
+
+	organizationID, err := s.defaultOrganization(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !sameOrganization(organizationID, session.OrganizationID) {
+		if err := s.sessionRepo.SetSessionOrganization(ctx, sessionID, organizationID); err != nil {
+			s.logger.WithError(err).Error("Failed to update session organization")
+			return nil, err
+		}
+	}
+	return organizationID, nil
+}
+
+// sameOrganization reports whether two organization IDs are equal, nil meaning no organization
+func sameOrganization(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// RevokeAccessToken rejects the access token with the given ID until it expires
+func (s *tokenService) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if expiresAt.IsZero() {
+		lifespan, err := lifespanHours(s.config.JWT.ExpirationTime)
+		if err != nil {
+			return errors.NewInternalServerError("Invalid token expiration time")
+		}
+		expiresAt = time.Now().Add(lifespan)
+	}
+	return s.revocationStore.Revoke(ctx, tokenID, expiresAt)
+}
+
+// RevokeRefreshToken revokes the family of a refresh token owned by the given user
+func (s *tokenService) RevokeRefreshToken(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	tokenID, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return errors.NewBadRequestError("Invalid refresh token")
+	}
+
+	stored, err := s.refreshTokenRepo.GetRefreshTokenByID(ctx, tokenID)
+	if err != nil {
+		return errors.NewBadRequestError("Invalid refresh token")
+	}
+	if stored.UserID != userID {
+		return errors.NewForbiddenError("Refresh token belongs to another user")
+	}
+
+	return s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+}
+
+// handleReuse revokes every token descended from the same login
+func (s *tokenService) handleReuse(ctx context.Context, stored entity.RefreshToken) error {
+	s.logger.
+		WithField("user_id", stored.UserID).
+		WithField("family_id", stored.FamilyID).
+		Warn("Refresh token reuse detected, revoking token family")
+
+	if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		s.logger.WithError(err).Error("Failed to revoke refresh token family")
+	}
+	return errors.NewUnauthorizedError("Refresh token has already been used")
+}
+
+// issueTokens signs an access token and persists a refresh token with the given ID and family
+func (s *tokenService) issueTokens(
+	ctx context.Context,
+	user entity.User,
+	familyID uuid.UUID,
+	organizationID *uuid.UUID,
+	refreshTokenID uuid.UUID,
+	expiresAt time.Time,
+) (response.TokenResponse, error) {
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, user.ID)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load user roles")
+		return response.TokenResponse{}, err
+	}
+
+	token, tokenErr := s.generateToken(user.ID, user.Email, familyID, organizationID, roles)
+	if tokenErr != nil {
+		s.logger.WithError(tokenErr).Error("Failed to generate token")
+		return response.TokenResponse{}, errors.NewInternalServerError("Failed to generate token: " + tokenErr.Error())
+	}
+
+	refreshToken, err := s.generateRefreshToken(user.ID, familyID, refreshTokenID, expiresAt)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to generate refresh token")
+		return response.TokenResponse{}, errors.NewInternalServerError("Failed to generate refresh token")
+	}
+
+	err = s.refreshTokenRepo.CreateRefreshToken(ctx, entity.RefreshToken{
+		ID:        refreshTokenID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ExpiresAt: expiresAt,
+		BaseEntity: entity.BaseEntity{
+			CreatedBy: user.Email,
+		},
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to store refresh token")
+		return response.TokenResponse{}, err
+	}
+
+	return response.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// IssueImpersonationToken signs a short-lived access token for user with the actor in the act claim.
+// No refresh token is issued, and the token belongs to the actor's session so logging the actor out ends it.
+// The token works in the user's default organization.
+func (s *tokenService) IssueImpersonationToken(
+	ctx context.Context,
+	actor entity.User,
+	actorSessionID uuid.UUID,
+	user entity.User,
+) (response.ImpersonationResponse, error) {
+	roles, err := s.roleRepo.GetRolesByUserID(ctx, user.ID)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load user roles")
+		return response.ImpersonationResponse{}, err
+	}
+	organizationID, err := s.defaultOrganization(ctx, user.ID)
+	if err != nil {
+		return response.ImpersonationResponse{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.Auth.ImpersonationTokenTTL)
+	claims := util.AccessClaims{
+		Email:       user.Email,
+		Roles:       entity.RoleNames(roles),
+		Permissions: entity.PermissionNames(roles),
+		Actor: &util.ActorClaim{
+			Subject: actor.ID.String(),
+			Email:   actor.Email,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if actorSessionID != uuid.Nil {
+		claims.SessionID = actorSessionID.String()
+	}
+	if organizationID != nil {
+		claims.OrganizationID = organizationID.String()
+	}
+
+	token, err := s.keySet.SignAccessToken(claims)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to sign impersonation token")
+		return response.ImpersonationResponse{}, errors.NewInternalServerError("Failed to generate token")
+	}
+
+	return response.ImpersonationResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		UserID:    user.ID,
+		Email:     user.Email,
+	}, nil
+}
+
+// generateToken generates JWT token
+func (s *tokenService) generateToken(
+	userID uuid.UUID,
+	email string,
+	sessionID uuid.UUID,
+	organizationID *uuid.UUID,
+	roles []entity.Role,
+) (string, *errors.AppError) {
+	lifespan, err := strconv.Atoi(s.config.JWT.ExpirationTime)
+	if err != nil {
+		return "", errors.NewUnauthorizedError("Invalid token expiration time: " + err.Error())
+	}
+
+	now := time.Now()
+	claims := util.AccessClaims{
+		Email:       email,
+		SessionID:   sessionID.String(),
+		Roles:       entity.RoleNames(roles),
+		Permissions: entity.PermissionNames(roles),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour * time.Duration(lifespan))),
+		},
+	}
+	if organizationID != nil {
+		claims.OrganizationID = organizationID.String()
+	}
+
+	tokenString, err := s.keySet.SignAccessToken(claims)
+	if err != nil {
+		return "", errors.NewUnauthorizedError("Failed to sign token: " + err.Error())
+	}
+
+	return tokenString, nil
+}
+
+// generateRefreshToken signs a refresh token that points at its server-side record
+func (s *tokenService) generateRefreshToken(
+	userID uuid.UUID,
+	familyID uuid.UUID,
+	tokenID uuid.UUID,
+	expiresAt time.Time,
+) (string, error) {
+	claims := jwt.MapClaims{
+		constant.UserID:     userID,
+		constant.FamilyID:   familyID,
+		constant.TokenID:    tokenID,
+		constant.ExpireDate: expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.config.JWT.RefreshSecret))
+}
+
+// parseRefreshToken verifies a refresh token and returns the ID of its server-side record
+func (s *tokenService) parseRefreshToken(tokenString string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.config.JWT.RefreshSecret), nil
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return uuid.Nil, fmt.Errorf("invalid refresh token claims")
+	}
+
+	return uuid.Parse(fmt.Sprint(claims[constant.TokenID]))
+}
+
+// refreshTokenExpiry returns when a refresh token issued now expires
+func (s *tokenService) refreshTokenExpiry() (time.Time, error) {
+	refreshLifespan, err := lifespanHours(s.config.JWT.RefreshExpirationTime)
+	if err != nil {
+		s.logger.WithError(err).Error("Invalid refresh token expiration time")
+		return time.Time{}, errors.NewInternalServerError("Invalid refresh token expiration time")
+	}
+	return time.Now().Add(refreshLifespan), nil
+}
+
+// truncateString cuts a client supplied value to at most maxLength bytes, the size of the column it is stored in.
+// It cuts before a multi-byte character rather than through it, since Postgres rejects invalid UTF-8.
+func truncateString(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	end := maxLength
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end]
+}
+
+// lifespanHours parses a token lifetime configured as a number of hours
+func lifespanHours(value string) (time.Duration, error) {
+	hours, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Hour * time.Duration(hours), nil
+}
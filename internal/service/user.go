@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/model/response"
 	"ienergy-template-go/internal/repository"
 	"ienergy-template-go/pkg/database"
 	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
 	"ienergy-template-go/pkg/util"
 
 	"github.com/google/uuid"
@@ -14,11 +17,14 @@ import (
 
 type UserService interface {
 	GetUserInfo(ctx context.Context) (user response.UserInfoResponse, err error)
+	UpdateUserInfo(ctx context.Context, req request.UpdateUserInfoRequest) (user response.UserInfoResponse, err error)
 }
 
 type userService struct {
-	userRepo repository.UserRepo
-	db       database.Database
+	userRepo            repository.UserRepo
+	db                  database.Database
+	verificationService VerificationService
+	logger              *logger.StandardLogger
 }
 
 // GetUserInfo implements IUserService.
@@ -32,21 +38,88 @@ func (u *userService) GetUserInfo(ctx context.Context) (user response.UserInfoRe
 		return user, err
 	}
 
-	user = response.UserInfoResponse{
-		UserID:   userID,
-		Email:    userEntity.Email,
-		FullName: fmt.Sprintf("%s %s", userEntity.FirstName, userEntity.LastName),
+	return toUserInfoResponse(userEntity), nil
+}
+
+// UpdateUserInfo implements IUserService.
+// Names change right away. A new email only replaces the current one once the link mailed to it is opened,
+// and only the account owner can ask for it, not an API key or an impersonating admin.
+func (u *userService) UpdateUserInfo(
+	ctx context.Context,
+	req request.UpdateUserInfoRequest,
+) (user response.UserInfoResponse, err error) {
+	userID := util.UserIDFromCTX(ctx)
+	if userID == uuid.Nil {
+		return user, errors.NewBadRequestError("User ID is not found")
+	}
+	userEntity, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+
+	if req.Email != nil && *req.Email != userEntity.Email {
+		if util.ActorIDFromCTX(ctx) != uuid.Nil {
+			return user, errors.NewForbiddenError("Not allowed while impersonating a user")
+		}
+		if util.APIKeyIDFromCTX(ctx) != uuid.Nil {
+			return user, errors.NewForbiddenError("The email address can't be changed with an API key")
+		}
+		if err := u.userRepo.VerifyUserEmail(ctx, *req.Email); err != nil {
+			return user, err
+		}
+		if err := u.verificationService.SendEmailChangeConfirmation(ctx, userEntity, *req.Email); err != nil {
+			return user, err
+		}
+		userEntity.PendingEmail = *req.Email
+	} else if req.Email != nil && userEntity.PendingEmail != "" {
+		// Asking for the current address again cancels the pending change
+		if err := u.userRepo.SetPendingEmail(ctx, userID, ""); err != nil {
+			return user, err
+		}
+		userEntity.PendingEmail = ""
+	}
+
+	if req.FirstName != nil || req.LastName != nil {
+		if req.FirstName != nil {
+			userEntity.FirstName = *req.FirstName
+		}
+		if req.LastName != nil {
+			userEntity.LastName = *req.LastName
+		}
+		userEntity.UpdatedBy = userID.String()
+		if err := u.userRepo.UpdateUserProfile(ctx, userEntity); err != nil {
+			return user, err
+		}
 	}
 
-	return user, nil
+	u.logger.
+		WithContext(ctx).
+		WithField("user_id", userID).
+		Info("Profile updated")
+	return toUserInfoResponse(userEntity), nil
+}
+
+func toUserInfoResponse(user entity.User) response.UserInfoResponse {
+	return response.UserInfoResponse{
+		UserID:       user.ID,
+		Email:        user.Email,
+		FullName:     fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		PendingEmail: user.PendingEmail,
+	}
 }
 
 func NewUserService(
 	userRepo repository.UserRepo,
 	db database.Database,
+	verificationService VerificationService,
+	logger *logger.StandardLogger,
 ) UserService {
 	return &userService{
-		userRepo: userRepo,
-		db:       db,
+		userRepo:            userRepo,
+		db:                  db,
+		verificationService: verificationService,
+		logger:              logger,
 	}
 }
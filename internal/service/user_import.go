@@ -0,0 +1,338 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/model/response"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/util"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+// userImportColumns are the CSV columns every import needs. A confirm_password column is optional
+// and defaults to the password.
+var userImportColumns = []string{"email", "first_name", "last_name", "password"}
+
+// utf8BOM is written at the start of CSV files by spreadsheet programs
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// UserImportService defines the interface for admins registering users in bulk from a CSV
+type UserImportService interface {
+	ImportUsers(ctx context.Context, fileName string, content []byte) (response.UserImportResponse, error)
+	ListImports(ctx context.Context) ([]response.UserImportResponse, error)
+	GetImport(ctx context.Context, importID uuid.UUID) (response.UserImportResponse, error)
+}
+
+// userImportService implements UserImportService.
+// Imports are run by a background worker, which is woken up by new uploads and also
+// picks up imports left pending by a restart.
+type userImportService struct {
+	userImportRepo repository.UserImportRepo
+	authService    AuthService
+	logger         *logger.StandardLogger
+	config         *config.Config
+	wake           chan struct{}
+}
+
+// NewUserImportService creates a new user import service and runs its worker while the app is running
+func NewUserImportService(
+	lc fx.Lifecycle,
+	userImportRepo repository.UserImportRepo,
+	authService AuthService,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) UserImportService {
+	s := &userImportService{
+		userImportRepo: userImportRepo,
+		authService:    authService,
+		logger:         logger,
+		config:         config,
+		wake:           make(chan struct{}, 1),
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.run(stop, done)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	return s
+}
+
+// ImportUsers checks the layout of an uploaded CSV and queues its users to be registered.
+// Rows are validated one by one when the import runs, see GetImport for the outcome.
+func (s *userImportService) ImportUsers(
+	ctx context.Context,
+	fileName string,
+	content []byte,
+) (response.UserImportResponse, error) {
+	adminID := util.UserIDFromCTX(ctx)
+	if adminID == uuid.Nil {
+		return response.UserImportResponse{}, errors.NewBadRequestError("User ID is not found")
+	}
+
+	rows, err := parseUserImportCSV(content)
+	if err != nil {
+		return response.UserImportResponse{}, err
+	}
+	if len(rows) == 0 {
+		return response.UserImportResponse{}, errors.NewBadRequestError("The CSV has no users")
+	}
+	if len(rows) > s.config.Admin.UserImportMaxRows {
+		return response.UserImportResponse{}, errors.NewBadRequestError(
+			fmt.Sprintf("The CSV has more than %d users", s.config.Admin.UserImportMaxRows),
+		)
+	}
+
+	now := time.Now()
+	userImport := entity.UserImport{
+		ID:        uuid.New(),
+		FileName:  truncateString(fileName, 255),
+		Status:    entity.UserImportPending,
+		Content:   content,
+		TotalRows: len(rows),
+		BaseEntity: entity.BaseEntity{
+			CreatedAt: &now,
+			CreatedBy: adminID.String(),
+		},
+	}
+	if err := s.userImportRepo.CreateUserImport(ctx, userImport); err != nil {
+		return response.UserImportResponse{}, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// The worker is already due to look for pending imports
+	}
+
+	s.logger.
+		WithField("admin_id", adminID).
+		WithField("import_id", userImport.ID).
+		WithField("total_rows", userImport.TotalRows).
+		Info("User import queued")
+	return toUserImportResponse(userImport), nil
+}
+
+// ListImports returns every import, newest first, without their row errors
+func (s *userImportService) ListImports(ctx context.Context) ([]response.UserImportResponse, error) {
+	imports, err := s.userImportRepo.GetUserImports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]response.UserImportResponse, 0, len(imports))
+	for _, userImport := range imports {
+		resp = append(resp, toUserImportResponse(userImport))
+	}
+	return resp, nil
+}
+
+// GetImport returns the status of an import with the reason each failed row was not imported
+func (s *userImportService) GetImport(ctx context.Context, importID uuid.UUID) (response.UserImportResponse, error) {
+	userImport, err := s.userImportRepo.GetUserImportByID(ctx, importID)
+	if err != nil {
+		return response.UserImportResponse{}, err
+	}
+	return toUserImportResponse(userImport), nil
+}
+
+// run processes pending imports on start and whenever it is woken up
+func (s *userImportService) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	s.processPendingImports(stop)
+	for {
+		select {
+		case <-s.wake:
+			s.processPendingImports(stop)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *userImportService) processPendingImports(stop <-chan struct{}) {
+	imports, err := s.userImportRepo.GetPendingUserImports(context.Background())
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load pending user imports")
+		return
+	}
+	for _, userImport := range imports {
+		if !s.processImport(context.Background(), userImport, stop) {
+			return
+		}
+	}
+}
+
+// processImport registers the users of an import through AuthService.Register, recording why rows failed.
+// It reports false when stopped, leaving the import pending: it then runs again from the start
+// after a restart, and the rows registered before are reported as existing emails.
+func (s *userImportService) processImport(ctx context.Context, userImport entity.UserImport, stop <-chan struct{}) bool {
+	log := s.logger.WithField("import_id", userImport.ID)
+
+	rows, err := parseUserImportCSV(userImport.Content)
+	if err != nil {
+		log.WithError(err).Error("Failed to read user import")
+		if err := s.userImportRepo.FailUserImport(ctx, userImport.ID); err != nil {
+			log.WithError(err).Error("Failed to mark user import as failed")
+		}
+		return true
+	}
+
+	createdRows := 0
+	rowErrors := make([]entity.UserImportError, 0)
+	for _, row := range rows {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+
+		err := row.req.Validate()
+		if err == nil {
+			_, err = s.authService.Register(ctx, row.req)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, entity.UserImportError{
+				Line:    row.line,
+				Email:   truncateString(row.req.Email, 255),
+				Message: truncateString(userImportErrorMessage(err), 500),
+			})
+			continue
+		}
+		createdRows++
+	}
+
+	completed, err := s.userImportRepo.CompleteUserImport(ctx, userImport.ID, createdRows, rowErrors)
+	if err != nil {
+		log.WithError(err).Error("Failed to store user import result")
+		return true
+	}
+	if completed {
+		log.
+			WithField("created_rows", createdRows).
+			WithField("failed_rows", len(rowErrors)).
+			Info("User import completed")
+	}
+	return true
+}
+
+// userImportRow is a user to register, read from line of the CSV
+type userImportRow struct {
+	line int
+	req  request.UserRegisterRequest
+}
+
+// parseUserImportCSV reads the users of an import. The header names the columns in any order,
+// matched without regard to case, and other columns are ignored.
+func parseUserImportCSV(content []byte) ([]userImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, utf8BOM)))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.NewBadRequestError("The CSV is empty")
+	}
+	if err != nil {
+		return nil, errors.NewBadRequestError("Invalid CSV: " + err.Error())
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range userImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.NewBadRequestError("The CSV has no " + name + " column")
+		}
+	}
+	confirmColumn, hasConfirm := columns["confirm_password"]
+
+	var rows []userImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid CSV: " + err.Error())
+		}
+		line, _ := reader.FieldPos(0)
+		req := request.UserRegisterRequest{
+			Email:     strings.TrimSpace(record[columns["email"]]),
+			FirstName: strings.TrimSpace(record[columns["first_name"]]),
+			LastName:  strings.TrimSpace(record[columns["last_name"]]),
+			Password:  record[columns["password"]],
+		}
+		req.ConfirmPassword = req.Password
+		if hasConfirm {
+			req.ConfirmPassword = record[confirmColumn]
+		}
+		rows = append(rows, userImportRow{line: line, req: req})
+	}
+	return rows, nil
+}
+
+// userImportErrorMessage describes why a row was not imported, including what is wrong with each field
+func userImportErrorMessage(err error) string {
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || len(appErr.Fields) == 0 {
+		return err.Error()
+	}
+
+	fields := make([]string, 0, len(appErr.Fields))
+	for field := range appErr.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	problems := make([]string, 0, len(fields))
+	for _, field := range fields {
+		problems = append(problems, field+" "+strings.Join(appErr.Fields[field], ", "))
+	}
+	return appErr.Message + ": " + strings.Join(problems, "; ")
+}
+
+func toUserImportResponse(userImport entity.UserImport) response.UserImportResponse {
+	resp := response.UserImportResponse{
+		ID:          userImport.ID,
+		FileName:    userImport.FileName,
+		Status:      userImport.Status,
+		TotalRows:   userImport.TotalRows,
+		CreatedRows: userImport.CreatedRows,
+		FailedRows:  userImport.FailedRows,
+		CreatedAt:   userImport.CreatedAt,
+		CompletedAt: userImport.CompletedAt,
+	}
+	for _, rowError := range userImport.Errors {
+		resp.Errors = append(resp.Errors, response.UserImportErrorResponse{
+			Line:    rowError.Line,
+			Email:   rowError.Email,
+			Message: rowError.Message,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/internal/model/entity"
+	"ienergy-template-go/internal/model/request"
+	"ienergy-template-go/internal/repository"
+	"ienergy-template-go/pkg/constant"
+	"ienergy-template-go/pkg/errors"
+	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/util"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VerificationService defines the interface for email verification operations
+type VerificationService interface {
+	SendVerificationEmail(ctx context.Context, user entity.User) error
+	VerifyEmail(ctx context.Context, req request.VerifyEmailRequest) error
+	ResendVerificationEmail(ctx context.Context, req request.ResendVerificationEmailRequest) error
+	SendEmailChangeConfirmation(ctx context.Context, user entity.User, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, req request.VerifyEmailRequest) error
+}
+
+// verificationService implements VerificationService
+type verificationService struct {
+	userRepo repository.UserRepo
+	notifier notifier.Notifier
+	logger   *logger.StandardLogger
+	config   *config.Config
+}
+
+// NewVerificationService creates a new verification service
+func NewVerificationService(
+	userRepo repository.UserRepo,
+	notifier notifier.Notifier,
+	logger *logger.StandardLogger,
+	config *config.Config,
+) VerificationService {
+	return &verificationService{
+		userRepo: userRepo,
+		notifier: notifier,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// SendVerificationEmail mails a signed verification link to the user.
+// Only one email is sent per EMAIL_VERIFICATION_RESEND_INTERVAL, later calls get a TooManyRequests error.
+func (s *verificationService) SendVerificationEmail(ctx context.Context, user entity.User) error {
+	marked, err := s.userRepo.MarkEmailVerificationSent(
+		ctx,
+		user.ID,
+		time.Now().Add(-s.config.Auth.EmailVerificationResendInterval),
+	)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return errors.NewTooManyRequestsError("Verification email was sent recently, please try again later")
+	}
+
+	token, err := util.SignActionToken(
+		s.config.Auth.ActionTokenSecret,
+		constant.PurposeEmailVerification,
+		user.ID.String(),
+		user.Email,
+		s.config.Auth.EmailVerificationTokenTTL,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to sign email verification token")
+		return errors.NewInternalServerError("Failed to generate email verification token")
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.config.Auth.EmailVerificationURL, url.QueryEscape(token))
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s",
+			user.FirstName, s.config.Auth.EmailVerificationTokenTTL, link,
+		),
+	})
+	if err != nil {
+		s.logger.WithField("email", user.Email).WithError(err).Error("Failed to send verification email")
+		return errors.NewInternalServerError("Failed to send verification email")
+	}
+	return nil
+}
+
+// VerifyEmail redeems a verification token. Verifying an already verified address succeeds.
+func (s *verificationService) VerifyEmail(ctx context.Context, req request.VerifyEmailRequest) error {
+	invalidToken := errors.NewBadRequestError("Invalid or expired email verification token")
+
+	claims, err := util.ParseActionToken(s.config.Auth.ActionTokenSecret, constant.PurposeEmailVerification, req.Token)
+	if err != nil {
+		s.logger.WithError(err).Info("Invalid email verification token")
+		return invalidToken
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return invalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil || user.Email != claims.Email {
+		return invalidToken
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	_, err = s.userRepo.MarkEmailVerified(ctx, user.ID, claims.Email)
+	return err
+}
+
+// ResendVerificationEmail sends a fresh verification link.
+// Unknown and already verified addresses succeed silently so callers cannot probe for accounts.
+func (s *verificationService) ResendVerificationEmail(
+	ctx context.Context,
+	req request.ResendVerificationEmailRequest,
+) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.WithField("email", req.Email).WithError(err).Info("Verification resend requested for unknown email")
+		return nil
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+	return s.SendVerificationEmail(ctx, user)
+}
+
+// SendEmailChangeConfirmation records newEmail as the user's pending address and mails it a confirmation link.
+// The current address is told about the request, so the owner notices if someone else got into the account.
+// Requests share the EMAIL_VERIFICATION_RESEND_INTERVAL limit with verification emails.
+func (s *verificationService) SendEmailChangeConfirmation(ctx context.Context, user entity.User, newEmail string) error {
+	marked, err := s.userRepo.MarkEmailVerificationSent(
+		ctx,
+		user.ID,
+		time.Now().Add(-s.config.Auth.EmailVerificationResendInterval),
+	)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return errors.NewTooManyRequestsError("Verification email was sent recently, please try again later")
+	}
+	if err := s.userRepo.SetPendingEmail(ctx, user.ID, newEmail); err != nil {
+		return err
+	}
+
+	token, err := util.SignActionToken(
+		s.config.Auth.ActionTokenSecret,
+		constant.PurposeEmailChange,
+		user.ID.String(),
+		newEmail,
+		s.config.Auth.EmailVerificationTokenTTL,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to sign email change token")
+		return errors.NewInternalServerError("Failed to generate email change token")
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.config.Auth.EmailChangeURL, url.QueryEscape(token))
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm that you want to use this email address by opening the link below. It expires in %s.\n\n%s",
+			user.FirstName, s.config.Auth.EmailVerificationTokenTTL, link,
+		),
+	})
+	if err != nil {
+		s.logger.WithField("email", newEmail).WithError(err).Error("Failed to send email change confirmation")
+		return errors.NewInternalServerError("Failed to send email change confirmation")
+	}
+
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to change the email address of your account to %s. "+
+				"If this wasn't you, please reset your password.",
+			user.FirstName, newEmail,
+		),
+	})
+	if err != nil {
+		s.logger.WithField("email", user.Email).WithError(err).Warn("Failed to notify the current email address of a change")
+	}
+	return nil
+}
+
+// ConfirmEmailChange redeems an email change token, replacing the user's email with the confirmed one.
+func (s *verificationService) ConfirmEmailChange(ctx context.Context, req request.VerifyEmailRequest) error {
+	invalidToken := errors.NewBadRequestError("Invalid or expired email change token")
+
+	claims, err := util.ParseActionToken(s.config.Auth.ActionTokenSecret, constant.PurposeEmailChange, req.Token)
+	if err != nil {
+		s.logger.WithError(err).Info("Invalid email change token")
+		return invalidToken
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return invalidToken
+	}
+
+	// The address may have been registered by someone else since the change was requested
+	if err := s.userRepo.VerifyUserEmail(ctx, claims.Email); err != nil {
+		return err
+	}
+	changed, err := s.userRepo.ConfirmPendingEmail(ctx, userID, claims.Email)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return invalidToken
+	}
+	s.logger.
+		WithContext(ctx).
+		WithField("user_id", userID).
+		Info("Email address changed")
+	return nil
+}
@@ -0,0 +1,7 @@
+package constant
+
+// Formats of the admin user export
+const (
+	UserExportFormatCSV  = "csv"
+	UserExportFormatJSON = "json"
+)
@@ -0,0 +1,10 @@
+package constant
+
+// Audit log actions
+const (
+	AuditActionImpersonate    = "user.impersonate"
+	AuditActionUserSuspend    = "user.suspend"
+	AuditActionUserReactivate = "user.reactivate"
+	AuditActionUserDeactivate = "user.deactivate"
+	AuditActionUserErase      = "user.erase"
+)
@@ -46,4 +46,10 @@ const (
 	ConflictError = -13
 	// ForbiddenError
 	ForbiddenError = -14
+	// EmailNotVerified
+	EmailNotVerified = -15
+	// TooManyRequests
+	TooManyRequests = -16
+	// AccountLocked
+	AccountLocked = -17
 )
@@ -7,4 +7,14 @@ const (
 	Lastname   = "lastname"
 	Email      = "email"
 	ExpireDate = "exp"
+	TokenID    = "jti"
+	FamilyID   = "family_id"
+)
+
+// Action token purposes
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposeMFAChallenge      = "mfa_challenge"
+	PurposeEmailChange       = "email_change"
+	PurposeInvitation        = "invitation"
 )
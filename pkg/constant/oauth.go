@@ -0,0 +1,15 @@
+package constant
+
+// OAuth 2.0 grant types accepted by /oauth/token
+const (
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuth 2.0 error codes (RFC 6749 section 5.2)
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorServerError          = "server_error"
+)
@@ -0,0 +1,50 @@
+package constant
+
+// Roles a user can have in an organization
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// Permissions granted inside the active organization
+const (
+	PermissionOrganizationRead  = "organization:read"
+	PermissionOrganizationWrite = "organization:write"
+	PermissionMembersRead       = "members:read"
+	PermissionMembersWrite      = "members:write"
+)
+
+// OrganizationRolePermissions lists the permissions each organization role grants in its organization
+var OrganizationRolePermissions = map[string][]string{
+	OrganizationRoleOwner: {
+		PermissionOrganizationRead,
+		PermissionOrganizationWrite,
+		PermissionMembersRead,
+		PermissionMembersWrite,
+	},
+	OrganizationRoleAdmin: {
+		PermissionOrganizationRead,
+		PermissionOrganizationWrite,
+		PermissionMembersRead,
+		PermissionMembersWrite,
+	},
+	OrganizationRoleMember: {
+		PermissionOrganizationRead,
+		PermissionMembersRead,
+	},
+}
+
+// IsOrganizationRole reports whether role is one of the organization roles
+func IsOrganizationRole(role string) bool {
+	_, ok := OrganizationRolePermissions[role]
+	return ok
+}
+
+// Statuses of an invitation to join an organization
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
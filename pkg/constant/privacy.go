@@ -0,0 +1,7 @@
+package constant
+
+// Formats a user can download their data export in
+const (
+	DataExportFormatJSON = "json"
+	DataExportFormatZIP  = "zip"
+)
@@ -0,0 +1,36 @@
+package constant
+
+// Roles
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permissions
+const (
+	PermissionProfileRead  = "profile:read"
+	PermissionProfileWrite = "profile:write"
+	PermissionUserRead     = "users:read"
+	PermissionUserWrite    = "users:write"
+	PermissionClientManage = "clients:manage"
+	PermissionImpersonate  = "users:impersonate"
+	// PermissionTokenIntrospect is meant as an OAuth client scope for gateways calling /oauth/introspect
+	PermissionTokenIntrospect = "tokens:introspect"
+)
+
+// DefaultRolePermissions lists the roles seeded at startup with the permissions each one grants
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionProfileRead,
+		PermissionProfileWrite,
+		PermissionUserRead,
+		PermissionUserWrite,
+		PermissionClientManage,
+		PermissionImpersonate,
+		PermissionTokenIntrospect,
+	},
+	RoleUser: {
+		PermissionProfileRead,
+		PermissionProfileWrite,
+	},
+}
@@ -53,7 +53,30 @@ func NewDatabase(lc fx.Lifecycle, config *config.Config, log *loggerCustom.Stand
 		return nil, err
 	}
 
-	db.AutoMigrate(&entity.User{})
+	db.AutoMigrate(
+		&entity.User{},
+		&entity.Role{},
+		&entity.Permission{},
+		&entity.RefreshToken{},
+		&entity.RevokedToken{},
+		&entity.PasswordResetToken{},
+		&entity.MFAFactor{},
+		&entity.MFARecoveryCode{},
+		&entity.LoginAttempt{},
+		&entity.UserIdentity{},
+		&entity.OIDCLoginState{},
+		&entity.APIKey{},
+		&entity.Session{},
+		&entity.MagicLinkToken{},
+		&entity.OAuthClient{},
+		&entity.AuditLog{},
+		&entity.DataExport{},
+		&entity.Organization{},
+		&entity.OrganizationMember{},
+		&entity.OrganizationInvitation{},
+		&entity.UserImport{},
+		&entity.UserImportError{},
+	)
 
 	if config.DB.SetMaxIdleConns != "" {
 		sqlDb.SetMaxIdleConns(cast.ToInt(config.DB.SetMaxIdleConns))
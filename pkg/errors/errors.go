@@ -11,6 +11,8 @@ type AppError struct {
 	Code    int
 	Message string
 	Status  int
+	// Fields lists what is wrong with each invalid request field, keyed by its JSON name
+	Fields map[string][]string
 }
 
 // Error implements the error interface
@@ -75,3 +77,40 @@ func NewConflictError(message string) *AppError {
 		Status:  http.StatusConflict,
 	}
 }
+
+// NewEmailNotVerifiedError creates a new error for accounts that have not confirmed their email address
+func NewEmailNotVerifiedError(message string) *AppError {
+	return &AppError{
+		Code:    constant.EmailNotVerified,
+		Message: message,
+		Status:  http.StatusForbidden,
+	}
+}
+
+// NewTooManyRequestsError creates a new error for throttled requests
+func NewTooManyRequestsError(message string) *AppError {
+	return &AppError{
+		Code:    constant.TooManyRequests,
+		Message: message,
+		Status:  http.StatusTooManyRequests,
+	}
+}
+
+// NewAccountLockedError creates a new error for logins refused after too many failed attempts
+func NewAccountLockedError(message string) *AppError {
+	return &AppError{
+		Code:    constant.AccountLocked,
+		Message: message,
+		Status:  http.StatusTooManyRequests,
+	}
+}
+
+// NewInvalidDataError creates a new bad request error describing which fields are invalid and why
+func NewInvalidDataError(message string, fields map[string][]string) *AppError {
+	return &AppError{
+		Code:    constant.InvalidData,
+		Message: message,
+		Status:  http.StatusBadRequest,
+		Fields:  fields,
+	}
+}
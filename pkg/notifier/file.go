@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+type fileNotifier struct {
+	dir string
+}
+
+// NewFileNotifier creates a notifier that writes each message to its own file in dir, for local development
+func NewFileNotifier(dir string) (Notifier, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("can't create notifier directory %s: %w", dir, err)
+	}
+	return &fileNotifier{
+		dir: dir,
+	}, nil
+}
+
+// Send implements Notifier.
+func (n *fileNotifier) Send(ctx context.Context, msg Message) error {
+	name := fmt.Sprintf("%s-%s.txt", time.Now().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	return os.WriteFile(filepath.Join(n.dir, name), []byte(content), 0o600)
+}
@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"context"
+	"ienergy-template-go/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type logNotifier struct {
+	logger *logger.StandardLogger
+}
+
+// NewLogNotifier creates a notifier that only writes messages to the application log, for local development
+func NewLogNotifier(logger *logger.StandardLogger) Notifier {
+	return &logNotifier{
+		logger: logger,
+	}
+}
+
+// Send implements Notifier.
+func (n *logNotifier) Send(ctx context.Context, msg Message) error {
+	n.logger.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	}).Info("Notification sent")
+	return nil
+}
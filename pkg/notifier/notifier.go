@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/pkg/logger"
+)
+
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+)
+
+// Message is a notification addressed to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages such as password reset links to users
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewNotifier creates the notifier selected by NOTIFIER_DRIVER
+func NewNotifier(config *config.Config, logger *logger.StandardLogger) (Notifier, error) {
+	switch config.Notifier.Driver {
+	case DriverLog, "":
+		return NewLogNotifier(logger), nil
+	case DriverFile:
+		return NewFileNotifier(config.Notifier.FileDir)
+	default:
+		return nil, fmt.Errorf("unknown notifier driver: %s", config.Notifier.Driver)
+	}
+}
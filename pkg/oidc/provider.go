@@ -0,0 +1,303 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"ienergy-template-go/config"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Discovery is the subset of the OpenID Provider metadata used by the login flow
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDTokenClaims are the ID token claims we rely on to identify and provision users
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified Bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Name          string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// Bool accepts both JSON booleans and the "true"/"false" strings some providers send
+type Bool bool
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	*b = Bool(strings.EqualFold(value, "true"))
+	return nil
+}
+
+// Registry holds the providers configured in OIDC_PROVIDERS
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry creates a provider for every configured OIDC provider. Discovery happens on first use.
+func NewRegistry(config *config.Config) *Registry {
+	client := &http.Client{Timeout: 10 * time.Second}
+	registry := &Registry{providers: make(map[string]*Provider, len(config.OIDC.Providers))}
+	for name, providerConfig := range config.OIDC.Providers {
+		registry.providers[name] = NewProvider(name, providerConfig, client)
+	}
+	return registry
+}
+
+// Provider returns the named provider
+func (r *Registry) Provider(name string) (*Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names returns the configured provider names in a stable order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Provider is an OpenID Connect provider we are registered with as a confidential client
+type Provider struct {
+	name   string
+	config config.OIDCProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]interface{}
+}
+
+// NewProvider creates a provider client
+func NewProvider(name string, providerConfig config.OIDCProviderConfig, client *http.Client) *Provider {
+	return &Provider{
+		name:   name,
+		config: providerConfig,
+		client: client,
+	}
+}
+
+// Name returns the provider name used in routes and stored identities
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// CodeChallenge derives the S256 PKCE challenge of a code verifier (RFC 7636)
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL builds the URL the browser is sent to for an authorization-code login with PKCE
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := p.Discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	discovery, err := p.Discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("client_secret", p.config.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &tokenResponse)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s %s", status, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+	if tokenResponse.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return tokenResponse.IDToken, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (IDTokenClaims, error) {
+	discovery, err := p.Discovery(ctx)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+
+	var claims IDTokenClaims
+	_, err = jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	if claims.Subject == "" {
+		return IDTokenClaims{}, fmt.Errorf("id token has no subject")
+	}
+	if claims.Nonce != nonce {
+		return IDTokenClaims{}, fmt.Errorf("id token nonce does not match")
+	}
+	return claims, nil
+}
+
+// Discovery fetches and caches the provider metadata
+func (p *Provider) Discovery(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.config.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery Discovery
+	status, err := p.doJSON(req, &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery endpoint of %s returned %d", p.name, status)
+	}
+	// OpenID Connect Discovery 1.0 section 4.3
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %s does not match configured issuer %s", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s is incomplete", p.name)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// key returns the signing key for a kid, refreshing the JWKS once when the kid is unknown to pick up rotations
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	// A provider publishing a single key may omit kid from its tokens
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id: %s", kid)
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]interface{}, error) {
+	discovery, err := p.Discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var jwks jsonWebKeySet
+	status, err := p.doJSON(req, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint of %s returned %d", p.name, status)
+	}
+	return jwks.publicKeys(), nil
+}
+
+func (p *Provider) doJSON(req *http.Request, out interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid JSON from %s: %w", req.URL.Host, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,175 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // SHA-1 is what breach lists such as Have I Been Pwned are published in
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"ienergy-template-go/config"
+	"ienergy-template-go/pkg/errors"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcryptMaxBytes is the longest input bcrypt hashes; longer passwords are rejected by the hasher
+const bcryptMaxBytes = 72
+
+// minPersonalInfoLength ignores very short names so "Al" does not rule out every password containing "al"
+const minPersonalInfoLength = 3
+
+//go:embed breached_passwords.txt
+var bundledBreachedPasswords string
+
+// Policy checks new passwords against the configured rules and a list of breached passwords
+type Policy struct {
+	config   config.PasswordPolicyConfig
+	breached map[string]struct{}
+}
+
+// NewPolicy builds the password policy from config, loading the bundled breached list and
+// PASSWORD_BREACHED_LIST_FILE when it is set
+func NewPolicy(config *config.Config) (*Policy, error) {
+	policy := &Policy{
+		config:   config.Password,
+		breached: make(map[string]struct{}),
+	}
+	if policy.config.MaxLength <= 0 || policy.config.MaxLength > bcryptMaxBytes {
+		policy.config.MaxLength = bcryptMaxBytes
+	}
+	if !policy.config.CheckBreached {
+		return policy, nil
+	}
+
+	if err := policy.loadBreached(strings.NewReader(bundledBreachedPasswords)); err != nil {
+		return nil, fmt.Errorf("load bundled breached passwords: %w", err)
+	}
+	if policy.config.BreachedListFile != "" {
+		file, err := os.Open(policy.config.BreachedListFile)
+		if err != nil {
+			return nil, fmt.Errorf("open breached password list: %w", err)
+		}
+		defer file.Close()
+		if err := policy.loadBreached(file); err != nil {
+			return nil, fmt.Errorf("load breached password list %s: %w", policy.config.BreachedListFile, err)
+		}
+	}
+	return policy, nil
+}
+
+// Check returns every rule the password breaks, or nothing when it is acceptable.
+// personalInfo holds the user's email and names, which the password must not contain.
+func (p *Policy) Check(password string, personalInfo ...string) []string {
+	violations := make([]string, 0)
+
+	if utf8.RuneCountInString(password) < p.config.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.config.MinLength))
+	}
+	if len(password) > p.config.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes", p.config.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if p.config.RequireUppercase && !hasUpper {
+		violations = append(violations, "must contain an upper case letter")
+	}
+	if p.config.RequireLowercase && !hasLower {
+		violations = append(violations, "must contain a lower case letter")
+	}
+	if p.config.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if p.config.DisallowPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		violations = append(violations, "must not contain your name or email address")
+	}
+	if p.config.CheckBreached && p.isBreached(password) {
+		violations = append(violations, "has appeared in a data breach and cannot be used")
+	}
+	return violations
+}
+
+// Validate checks the password and reports the broken rules against the given request field
+func (p *Policy) Validate(field, password string, personalInfo ...string) error {
+	violations := p.Check(password, personalInfo...)
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.NewInvalidDataError(
+		"Password does not meet the password policy",
+		map[string][]string{field: violations},
+	)
+}
+
+// loadBreached adds a list of passwords or SHA-1 hashes, one per line. Lines starting with # are ignored,
+// and a ":count" suffix as used by Have I Been Pwned downloads is allowed after a hash.
+func (p *Policy) loadBreached(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			p.breached[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		p.breached[sha1Hex(strings.ToLower(line))] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// isBreached matches hashed lists exactly and plain lists case-insensitively
+func (p *Policy) isBreached(password string) bool {
+	if _, ok := p.breached[sha1Hex(password)]; ok {
+		return true
+	}
+	_, ok := p.breached[sha1Hex(strings.ToLower(password))]
+	return ok
+}
+
+// containsPersonalInfo reports whether the password contains the local part of an email or a name
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	lowered := strings.ToLower(password)
+	for _, info := range personalInfo {
+		info, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(info)), "@")
+		if utf8.RuneCountInString(info) < minPersonalInfoLength {
+			continue
+		}
+		if strings.Contains(lowered, info) {
+			return true
+		}
+	}
+	return false
+}
+
+func sha1Hex(value string) string {
+	sum := sha1.Sum([]byte(value)) //nolint:gosec
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1Hex(value string) bool {
+	if len(value) != sha1.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
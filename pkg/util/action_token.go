@@ -0,0 +1,62 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ActionClaims are carried by short-lived tokens mailed to users, such as email verification links.
+// Purpose keeps a token issued for one flow from being redeemed by another.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// SignActionToken signs a token for the given purpose, subject and email with HS256.
+// Each token gets a unique jti, so flows can make their tokens single-use.
+func SignActionToken(secret, purpose, subject, email string, ttl time.Duration) (string, error) {
+	if secret == "" {
+		return "", fmt.Errorf("action token secret is not configured")
+	}
+	now := time.Now()
+	claims := ActionClaims{
+		Purpose: purpose,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseActionToken verifies the signature, expiry and purpose of an action token.
+// No token is valid without a secret, since anyone can sign with an empty HMAC key.
+func ParseActionToken(secret, purpose, tokenString string) (ActionClaims, error) {
+	var claims ActionClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		if secret == "" {
+			return nil, fmt.Errorf("action token secret is not configured")
+		}
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return ActionClaims{}, err
+	}
+	if claims.Purpose != purpose {
+		return ActionClaims{}, fmt.Errorf("unexpected token purpose: %s", claims.Purpose)
+	}
+	if claims.Subject == "" {
+		return ActionClaims{}, fmt.Errorf("token has no subject")
+	}
+	return claims, nil
+}
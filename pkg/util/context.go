@@ -2,13 +2,28 @@ package util
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const (
-	UserIDCTX    = "user_id"
-	UserEmailCTX = "email"
+	UserIDCTX         = "user_id"
+	UserEmailCTX      = "email"
+	TokenIDCTX        = "jti"
+	TokenExpiresAtCTX = "token_expires_at"
+	RolesCTX          = "roles"
+	PermissionsCTX    = "permissions"
+	APIKeyIDCTX       = "api_key_id"
+	SessionIDCTX      = "sid"
+	ClientIPCTX       = "client_ip"
+	UserAgentCTX      = "user_agent"
+	ClientIDCTX       = "client_id"
+	ScopesCTX         = "scopes"
+	ActorIDCTX        = "actor_id"
+	OrganizationIDCTX = "org"
+	TenantIDCTX       = "tenant_id"
+	OrgRoleCTX        = "org_role"
 )
 
 func UserIDFromCTX(ctx context.Context) (userID uuid.UUID) {
@@ -30,3 +45,143 @@ func UserEmailFromCTX(ctx context.Context) (email string) {
 	email, _ = user.(string)
 	return
 }
+
+func TokenIDFromCTX(ctx context.Context) (tokenID string) {
+	token := ctx.Value(TokenIDCTX)
+	tokenID, _ = token.(string)
+	return
+}
+
+func TokenExpiresAtFromCTX(ctx context.Context) (expiresAt time.Time) {
+	expiry := ctx.Value(TokenExpiresAtCTX)
+	expiresAt, _ = expiry.(time.Time)
+	return
+}
+
+func RolesFromCTX(ctx context.Context) (roles []string) {
+	value := ctx.Value(RolesCTX)
+	roles, _ = value.([]string)
+	return
+}
+
+func PermissionsFromCTX(ctx context.Context) (permissions []string) {
+	value := ctx.Value(PermissionsCTX)
+	permissions, _ = value.([]string)
+	return
+}
+
+// APIKeyIDFromCTX returns the ID of the API key that authenticated the request, or uuid.Nil for a JWT
+func APIKeyIDFromCTX(ctx context.Context) (keyID uuid.UUID) {
+	value, ok := ctx.Value(APIKeyIDCTX).(string)
+	if !ok || value == "" {
+		return uuid.Nil
+	}
+
+	keyID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return
+}
+
+// SessionIDFromCTX returns the session the access token belongs to, or uuid.Nil when it has none
+func SessionIDFromCTX(ctx context.Context) (sessionID uuid.UUID) {
+	value, ok := ctx.Value(SessionIDCTX).(string)
+	if !ok || value == "" {
+		return uuid.Nil
+	}
+
+	sessionID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return
+}
+
+func ClientIPFromCTX(ctx context.Context) (clientIP string) {
+	value := ctx.Value(ClientIPCTX)
+	clientIP, _ = value.(string)
+	return
+}
+
+func UserAgentFromCTX(ctx context.Context) (userAgent string) {
+	value := ctx.Value(UserAgentCTX)
+	userAgent, _ = value.(string)
+	return
+}
+
+// ClientIDFromCTX returns the OAuth client a client credentials token was issued to,
+// or "" when the request was made on behalf of a user
+func ClientIDFromCTX(ctx context.Context) (clientID string) {
+	value := ctx.Value(ClientIDCTX)
+	clientID, _ = value.(string)
+	return
+}
+
+func ScopesFromCTX(ctx context.Context) (scopes []string) {
+	value := ctx.Value(ScopesCTX)
+	scopes, _ = value.([]string)
+	return
+}
+
+// ActorIDFromCTX returns the admin acting through an impersonation token, or uuid.Nil when the user acts
+// for themselves. UserIDFromCTX returns the impersonated user.
+func ActorIDFromCTX(ctx context.Context) (actorID uuid.UUID) {
+	value, ok := ctx.Value(ActorIDCTX).(string)
+	if !ok || value == "" {
+		return uuid.Nil
+	}
+
+	actorID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return
+}
+
+// OrganizationIDFromCTX returns the active organization claimed by the access token, or uuid.Nil when it has none.
+// The claim is not proof of membership; use TenantIDFromCTX behind RequireOrganization.
+func OrganizationIDFromCTX(ctx context.Context) (organizationID uuid.UUID) {
+	value, ok := ctx.Value(OrganizationIDCTX).(string)
+	if !ok || value == "" {
+		return uuid.Nil
+	}
+
+	organizationID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return
+}
+
+// TenantIDFromCTX returns the organization the request was verified to act in, or uuid.Nil outside of one.
+// Queries on tenant scoped tables are restricted to it.
+func TenantIDFromCTX(ctx context.Context) (tenantID uuid.UUID) {
+	value, ok := ctx.Value(TenantIDCTX).(string)
+	if !ok || value == "" {
+		return uuid.Nil
+	}
+
+	tenantID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return
+}
+
+// WithTenant returns a context acting in the given organization, for work done outside of a request to it
+func WithTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, TenantIDCTX, tenantID.String()) //nolint:staticcheck
+}
+
+// OrgRoleFromCTX returns the caller's role in the tenant, such as constant.OrganizationRoleOwner
+func OrgRoleFromCTX(ctx context.Context) (role string) {
+	value := ctx.Value(OrgRoleCTX)
+	role, _ = value.(string)
+	return
+}
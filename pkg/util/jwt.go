@@ -1,13 +1,14 @@
 package util
 
 import (
+	"context"
 	"fmt"
-	"ienergy-template-go/config"
 	"ienergy-template-go/pkg/constant"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/spf13/cast"
 )
 
@@ -28,39 +29,175 @@ func ExtractToken(c *gin.Context) string {
 	return ""
 }
 
+// RevocationChecker reports whether a token ID (jti) has been revoked before its expiry
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// SessionChecker reports whether the session an access token belongs to is still active
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// AccountChecker reports whether a user's account is active, so tokens of suspended users stop working
+type AccountChecker interface {
+	IsAccountActive(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// MembershipChecker returns the role of a user in an organization, or a NotFound error when they are not a member
+type MembershipChecker interface {
+	OrganizationRole(ctx context.Context, organizationID, userID uuid.UUID) (string, error)
+}
+
+// ClientChecker reports whether the OAuth client a client credentials token was issued to is still registered
+type ClientChecker interface {
+	IsClientActive(ctx context.Context, clientID string) (bool, error)
+}
+
+// ActorClaim identifies who is really acting when a token is used to impersonate its subject (RFC 8693 section 4.1)
+type ActorClaim struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+}
+
+// AccessClaims are the claims of an access token. The user is the subject; issuer and audience
+// are stamped by JWTKeySet.SignAccessToken. Tokens from the client credentials grant have the
+// client as subject, carry client_id and scope instead of user claims, and have no session.
+type AccessClaims struct {
+	Email       string      `json:"email,omitempty"`
+	SessionID   string      `json:"sid,omitempty"`
+	Roles       []string    `json:"roles,omitempty"`
+	Permissions []string    `json:"permissions,omitempty"`
+	ClientID    string      `json:"client_id,omitempty"`
+	Scope       string      `json:"scope,omitempty"`
+	Actor       *ActorClaim `json:"act,omitempty"`
+	// OrganizationID is the organization the user is working in, chosen at login or by switching
+	OrganizationID string `json:"org,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IsClientToken reports whether the token was issued to an OAuth client rather than a user
+func (a AccessClaims) IsClientToken() bool {
+	return a.ClientID != ""
+}
+
+// Validate rejects access tokens missing a claim the middleware relies on.
+// It runs after the standard exp, nbf, iat, iss and aud checks.
+func (a AccessClaims) Validate() error {
+	if a.IsClientToken() {
+		if a.Subject != a.ClientID {
+			return fmt.Errorf("token subject is not its client")
+		}
+		if a.SessionID != "" || a.Email != "" || a.Actor != nil || a.OrganizationID != "" {
+			return fmt.Errorf("client token carries user claims")
+		}
+	} else {
+		if _, err := uuid.Parse(a.Subject); err != nil {
+			return fmt.Errorf("token subject is not a user ID")
+		}
+		if a.Email == "" {
+			return fmt.Errorf("token has no email")
+		}
+		if a.Scope != "" {
+			return fmt.Errorf("user token carries a scope")
+		}
+		if a.Actor != nil {
+			if _, err := uuid.Parse(a.Actor.Subject); err != nil {
+				return fmt.Errorf("token actor is not a user ID")
+			}
+			if a.Actor.Subject == a.Subject {
+				return fmt.Errorf("token actor is its own subject")
+			}
+		}
+	}
+	if a.ID == "" {
+		return fmt.Errorf("token has no ID")
+	}
+	if a.IssuedAt == nil {
+		return fmt.Errorf("token has no issued at time")
+	}
+	if a.NotBefore == nil {
+		return fmt.Errorf("token has no not before time")
+	}
+	if a.SessionID != "" {
+		if _, err := uuid.Parse(a.SessionID); err != nil {
+			return fmt.Errorf("token session is not a session ID")
+		}
+	}
+	if a.OrganizationID != "" {
+		if _, err := uuid.Parse(a.OrganizationID); err != nil {
+			return fmt.Errorf("token organization is not an organization ID")
+		}
+	}
+	return nil
+}
+
 // ExtractTokenID phân tích token và gán userID và email vào context
-func ExtractTokenID(c *gin.Context, config config.JWTConfig) error {
+func ExtractTokenID(c *gin.Context, keys *JWTKeySet, revocation RevocationChecker, sessions SessionChecker) error {
 	tokenString := ExtractToken(c)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.Secret), nil
-	})
+	if tokenString == "" {
+		return fmt.Errorf("token is missing")
+	}
+	claims, err := keys.ParseAccessToken(tokenString)
 	if err != nil {
-		return fmt.Errorf("can't parse token")
+		return fmt.Errorf("can't parse token: %w", err)
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		userID := fmt.Sprint(claims[constant.UserID])
-		if len(userID) == 0 {
-			return nil
+
+	if revocation != nil {
+		revoked, err := revocation.IsRevoked(c, claims.ID)
+		if err != nil {
+			return fmt.Errorf("can't check token revocation")
+		}
+		if revoked {
+			return fmt.Errorf("token has been revoked")
 		}
-		email := fmt.Sprint(claims[constant.Email])
-		if len(email) == 0 {
-			return nil
+	}
+	if claims.SessionID != "" {
+		if sessions != nil {
+			active, err := sessions.IsSessionActive(c, claims.SessionID)
+			if err != nil {
+				return fmt.Errorf("can't check session")
+			}
+			if !active {
+				return fmt.Errorf("session has been revoked")
+			}
 		}
-		// Set userID và email vào context
-		c.Set(constant.UserID, userID)
-		c.Set(constant.Email, email)
+		c.Set(SessionIDCTX, claims.SessionID)
+	}
+
+	c.Set(TokenIDCTX, claims.ID)
+	c.Set(TokenExpiresAtCTX, claims.ExpiresAt.Time)
+	if claims.IsClientToken() {
+		// No user values are set, so UserIDFromCTX stays uuid.Nil for service calls
+		c.Set(ClientIDCTX, claims.ClientID)
+		c.Set(ScopesCTX, strings.Fields(claims.Scope))
 		return nil
 	}
+	c.Set(RolesCTX, nonNilStrings(claims.Roles))
+	c.Set(PermissionsCTX, nonNilStrings(claims.Permissions))
+	if claims.Actor != nil {
+		c.Set(ActorIDCTX, claims.Actor.Subject)
+	}
+	if claims.OrganizationID != "" {
+		c.Set(OrganizationIDCTX, claims.OrganizationID)
+	}
+	// Set userID và email vào context
+	c.Set(constant.UserID, claims.Subject)
+	c.Set(constant.Email, claims.Email)
 	return nil
 }
 
+// nonNilStrings returns an empty slice for a claim that was absent from the token
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 // TokenValid kiểm tra tính hợp lệ của token
-func TokenValid(c *gin.Context, config config.JWTConfig) error {
-	err := ExtractTokenID(c, config)
+func TokenValid(c *gin.Context, keys *JWTKeySet, revocation RevocationChecker, sessions SessionChecker) error {
+	err := ExtractTokenID(c, keys, revocation, sessions)
 	if err != nil {
 		return fmt.Errorf("can't extract token")
 	}
@@ -0,0 +1,316 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"ienergy-template-go/config"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Used when the configuration leaves the access token issuer or audience empty
+const (
+	defaultTokenIssuer   = "ienergy-template-go"
+	defaultTokenAudience = "ienergy-api"
+)
+
+// JWK is a single public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the document served from /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// JWTKeySet holds the key used to sign access tokens and every key still accepted when verifying them.
+// With JWT_PRIVATE_KEY_FILE unset tokens are signed with HS256 and JWT_SECRET, and no public keys are published.
+type JWTKeySet struct {
+	method     jwt.SigningMethod
+	signingKey interface{}
+	signingKID string
+	verifyKeys map[string]verificationKey
+	jwks       JWKS
+	issuer     string
+	audience   string
+	leeway     time.Duration
+}
+
+// NewJWTKeySet builds the key set described by the JWT configuration
+func NewJWTKeySet(cfg *config.Config) (*JWTKeySet, error) {
+	keySet := &JWTKeySet{
+		verifyKeys: make(map[string]verificationKey),
+		jwks:       JWKS{Keys: []JWK{}},
+		issuer:     cfg.JWT.Issuer,
+		audience:   cfg.JWT.Audience,
+		leeway:     cfg.JWT.Leeway,
+	}
+	if keySet.issuer == "" {
+		keySet.issuer = defaultTokenIssuer
+	}
+	if keySet.audience == "" {
+		keySet.audience = defaultTokenAudience
+	}
+
+	if cfg.JWT.PrivateKeyFile == "" {
+		if len(cfg.JWT.Secret) < config.MinSecretLength {
+			return nil, fmt.Errorf("JWT_SECRET must be set to at least %d bytes", config.MinSecretLength)
+		}
+		keySet.method = jwt.SigningMethodHS256
+		keySet.signingKey = []byte(cfg.JWT.Secret)
+		return keySet, nil
+	}
+
+	privateKey, err := readPrivateKey(cfg.JWT.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	publicKey := privateKey.Public()
+	kid, err := keySet.addPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	keySet.method = keySet.verifyKeys[kid].method
+	keySet.signingKey = privateKey
+	keySet.signingKID = kid
+
+	for _, file := range cfg.JWT.VerificationKeyFiles {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		publicKey, err := readPublicKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := keySet.addPublicKey(publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return keySet, nil
+}
+
+// Sign signs the claims with the current signing key, setting the kid header for asymmetric keys
+func (k *JWTKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.signingKID != "" {
+		token.Header["kid"] = k.signingKID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// SignAccessToken stamps the configured issuer and audience on the claims and signs them
+func (k *JWTKeySet) SignAccessToken(claims AccessClaims) (string, error) {
+	claims.Issuer = k.issuer
+	claims.Audience = jwt.ClaimStrings{k.audience}
+	return k.Sign(claims)
+}
+
+// ParseAccessToken verifies the signature of an access token, its issuer, audience and time claims
+// within the configured leeway, and that every required claim is present
+func (k *JWTKeySet) ParseAccessToken(tokenString string) (AccessClaims, error) {
+	var claims AccessClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, k.Keyfunc,
+		jwt.WithIssuer(k.issuer),
+		jwt.WithAudience(k.audience),
+		jwt.WithLeeway(k.leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return AccessClaims{}, err
+	}
+	return claims, nil
+}
+
+// Keyfunc resolves the key for a token being parsed, rejecting unknown kids and algorithm mismatches
+func (k *JWTKeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if k.signingKID == "" {
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.signingKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no key id")
+	}
+	verifyKey, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	if token.Method.Alg() != verifyKey.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return verifyKey.key, nil
+}
+
+// JWKS returns the public keys other services can use to verify our tokens
+func (k *JWTKeySet) JWKS() JWKS {
+	return k.jwks
+}
+
+// addPublicKey registers a verification key under its RFC 7638 thumbprint and returns that kid
+func (k *JWTKeySet) addPublicKey(publicKey crypto.PublicKey) (string, error) {
+	var (
+		method jwt.SigningMethod
+		jwk    JWK
+	)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+		jwk = JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported EC curve %s, only P-256 is supported", key.Curve.Params().Name)
+		}
+		method = jwt.SigningMethodES256
+		jwk = JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+		jwk = JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	kid, err := jwkThumbprint(jwk)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := k.verifyKeys[kid]; exists {
+		return kid, nil
+	}
+
+	jwk.Kid = kid
+	jwk.Use = "sig"
+	jwk.Alg = method.Alg()
+	k.verifyKeys[kid] = verificationKey{method: method, key: publicKey}
+	k.jwks.Keys = append(k.jwks.Keys, jwk)
+	return kid, nil
+}
+
+// jwkThumbprint computes the RFC 7638 thumbprint, hashing only the required members in lexicographic order
+func jwkThumbprint(jwk JWK) (string, error) {
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+func readPEMBlock(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read key file %s: %w", file, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+	return block, nil
+}
+
+func readPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEMBlock(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key in %s", file)
+}
+
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEMBlock(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported public key in %s", file)
+}
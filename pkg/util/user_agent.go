@@ -0,0 +1,61 @@
+package util
+
+import "strings"
+
+// userAgentBrowsers is checked in order because most browsers also claim to be the ones they derive from
+var userAgentBrowsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+	{"okhttp/", "OkHttp"},
+	{"Go-http-client/", "Go HTTP client"},
+}
+
+var userAgentSystems = []struct{ token, name string }{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DescribeDevice turns a User-Agent header into a short label such as "Chrome on macOS" for session lists
+func DescribeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, candidate := range userAgentBrowsers {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+	system := ""
+	for _, candidate := range userAgentSystems {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	if len(userAgent) > 100 {
+		return userAgent[:100]
+	}
+	return userAgent
+}
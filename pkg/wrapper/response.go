@@ -15,6 +15,7 @@ type Response struct {
 	Code       int         `json:"code"`
 	Data       interface{} `json:"data,omitempty"`
 	Message    string      `json:"message,omitempty"`
+	Errors     interface{} `json:"errors,omitempty"`
 }
 
 func (r *Response) String() string {
@@ -44,12 +45,16 @@ func NewSuccessResponse(data interface{}) *Response {
 
 // NewErrorResponse creates an error response
 func NewErrorResponse(err *errors.AppError) *Response {
-	return NewResponse(
+	resp := NewResponse(
 		err.Status,
 		err.Code,
 		nil,
 		err.Message,
 	)
+	if len(err.Fields) != 0 {
+		resp.Errors = err.Fields
+	}
+	return resp
 }
 
 // JSONOk sends a success response
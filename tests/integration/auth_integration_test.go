@@ -6,12 +6,17 @@ import (
 	"encoding/json"
 	"ienergy-template-go/config"
 	"ienergy-template-go/internal/http/handler"
+	"ienergy-template-go/internal/middleware"
 	"ienergy-template-go/internal/model/entity"
 	"ienergy-template-go/internal/model/request"
 	"ienergy-template-go/internal/repository"
 	"ienergy-template-go/internal/service"
+	"ienergy-template-go/pkg/constant"
 	"ienergy-template-go/pkg/database"
 	"ienergy-template-go/pkg/logger"
+	"ienergy-template-go/pkg/notifier"
+	"ienergy-template-go/pkg/password"
+	"ienergy-template-go/pkg/util"
 	"ienergy-template-go/pkg/wrapper"
 	"net/http"
 	"net/http/httptest"
@@ -41,8 +46,10 @@ func setupTestEnvironment(t *testing.T) *testEnvironment {
 			SSLMode:  "disable",
 		},
 		JWT: config.JWTConfig{
-			Secret:         "test_secret_key",
-			ExpirationTime: "86400", // 24 hours in seconds
+			Secret:                "test-jwt-secret-of-at-least-32-bytes",
+			ExpirationTime:        "86400", // 24 hours in seconds
+			RefreshSecret:         "test-refresh-secret-of-at-least-32-bytes",
+			RefreshExpirationTime: "720", // 30 days in hours
 		},
 		Server: config.ServerCfg{
 			Port:       "8080",
@@ -63,19 +70,50 @@ func setupTestEnvironment(t *testing.T) *testEnvironment {
 	require.NoError(t, err)
 
 	// Ensure test database is clean
-	err = db.GetDB().Exec("DROP TABLE IF EXISTS users CASCADE").Error
+	err = db.GetDB().Exec("DROP TABLE IF EXISTS users, roles, permissions, user_roles, role_permissions, refresh_tokens, mfa_factors, sessions, organizations, organization_members CASCADE").Error
 	require.NoError(t, err)
 
 	// Run migrations
-	err = db.GetDB().AutoMigrate(&entity.User{})
+	err = db.GetDB().AutoMigrate(&entity.User{}, &entity.Role{}, &entity.Permission{}, &entity.RefreshToken{}, &entity.MFAFactor{}, &entity.Session{}, &entity.Organization{}, &entity.OrganizationMember{})
 	require.NoError(t, err)
 
 	// Create repositories
 	userRepo := repository.NewUserRepo(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepo(db)
+	sessionRepo := repository.NewSessionRepo(db)
+	roleRepo := repository.NewRoleRepo(db)
+	organizationRepo := repository.NewOrganizationRepo(db)
+	err = repository.RegisterTenantScope(db)
+	require.NoError(t, err)
+	err = roleRepo.SeedRoles(context.Background(), constant.DefaultRolePermissions)
+	require.NoError(t, err)
+	revocationStore := repository.NewMemoryTokenRevocationStore()
+
+	keySet, err := util.NewJWTKeySet(cfg)
+	require.NoError(t, err)
 
 	// Create services
-	authService := service.NewAuthService(userRepo, log, cfg)
-	userService := service.NewUserService(userRepo, db)
+	tokenService := service.NewTokenService(refreshTokenRepo, sessionRepo, userRepo, roleRepo, organizationRepo, revocationStore, keySet, log, cfg)
+	verificationService := service.NewVerificationService(userRepo, notifier.NewLogNotifier(log), log, cfg)
+	loginAttemptService := service.NewLoginAttemptService(repository.NewMemoryLoginAttemptStore(), log, cfg)
+	mfaService := service.NewMFAService(
+		repository.NewMFARepo(db), userRepo, tokenService, loginAttemptService, revocationStore, log, cfg,
+	)
+	passwordPolicy, err := password.NewPolicy(cfg)
+	require.NoError(t, err)
+	authService := service.NewAuthService(
+		userRepo,
+		roleRepo,
+		tokenService,
+		verificationService,
+		mfaService,
+		loginAttemptService,
+		passwordPolicy,
+		log,
+		cfg,
+	)
+	userService := service.NewUserService(userRepo, db, verificationService, log)
+	sessionService := service.NewSessionService(sessionRepo, refreshTokenRepo, log, cfg)
 
 	// Create handlers
 	authHandler := handler.NewAuthHandler(authService)
@@ -85,12 +123,14 @@ func setupTestEnvironment(t *testing.T) *testEnvironment {
 	router := gin.Default()
 	router.POST("/auth/register", authHandler.Register())
 	router.POST("/auth/login", authHandler.Login())
+	router.POST("/auth/refresh", authHandler.Refresh())
+	router.POST("/auth/logout", middleware.JwtAuthMiddleware(keySet, revocationStore, sessionService, nil, nil), authHandler.Logout())
 	router.GET("/user/info", userHandler.Info())
 
 	// Cleanup function
 	cleanup := func() {
 		// Clean up test database
-		err := db.GetDB().Exec("DROP TABLE IF EXISTS users CASCADE").Error
+		err := db.GetDB().Exec("DROP TABLE IF EXISTS users, roles, permissions, user_roles, role_permissions, refresh_tokens, mfa_factors, sessions, organizations, organization_members CASCADE").Error
 		require.NoError(t, err)
 		sqlDB, err := db.GetDB().DB()
 		require.NoError(t, err)